@@ -6,37 +6,59 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"sort"
 	"time"
 
+	"github.com/kraserh/energozvit/internal/config"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/report"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
 var Version string
 
 type tmpl struct {
-	stor     *storage.Storage
+	stor     storage.Store
+	config   *config.Config
 	date     time.Time
 	sortName []string
 }
 
 // Програма приймає шаблон із стандартного вводу і видає результат в
-// стандартний вивід. Аргументи команди є імʼя бази даних та дата в
-// форматі YYYY-MM
+// стандартний вивід. Аргументи команди є імʼя бази даних, дата в
+// форматі YYYY-MM та необовʼязкова назва організації
 func main() {
 	log.SetFlags(log.Lshortfile)
-	if len(os.Args) != 3 {
+	if len(os.Args) != 3 && len(os.Args) != 4 {
 		usageAndExit()
 	}
 
-	// база даних
+	// база даних відкривається тільки для читання, тому шаблон можна
+	// обробляти поки інша програма записує
 	pathDB := os.Args[1]
-	stor, err := storage.Open(pathDB)
+	cfg, err := config.Load()
+	if err == nil {
+		err = cfg.ForDatabase(pathDB)
+	}
+	if err == nil {
+		err = i18n.SetLocale(cfg.Locale)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	stor, err := storage.OpenReadOnly(pathDB)
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer stor.Close()
 
+	// організація
+	if len(os.Args) == 4 && os.Args[3] != "" {
+		err := storage.SelectSite(stor, os.Args[3])
+		if err != nil {
+			log.Fatalf("%s: %s", err, os.Args[3])
+		}
+	}
+
 	// дата
 	yymm := os.Args[2]
 	date, err := storage.DateParse(yymm)
@@ -45,7 +67,7 @@ func main() {
 	}
 
 	// обробка шаблону
-	t := &tmpl{stor, date, nil}
+	t := &tmpl{stor, cfg, date, nil}
 	err = t.parse(os.Stdin, os.Stdout)
 	if err != nil {
 		log.Fatal(err)
@@ -55,7 +77,8 @@ func main() {
 func usageAndExit() {
 	fmt.Println("EnergoZvit templates handler")
 	fmt.Printf("Version: %s\n", Version)
-	fmt.Printf("Usage:\n  <template> | energozvit-tmpl db_file YYYY-MM > file\n")
+	fmt.Printf("Usage:\n  <template> | energozvit-tmpl db_file YYYY-MM " +
+		"[site] > file\n")
 	os.Exit(0)
 }
 
@@ -64,13 +87,28 @@ func (t *tmpl) parse(in, out *os.File) error {
 	// Функції які доступні в шаблонах
 	funcs := template.FuncMap{
 		"add":             func(x, y int) int { return x + y },
+		"budgetMonth":     t.budget,
+		"compareMonth":    t.compare,
+		"compareTotal":    t.compareTotal,
 		"year":            t.date.Year,
 		"month":           func() int { return int(t.date.Month()) },
 		"monthName":       t.monthName,
+		"monthNameOf":     monthNameOf,
+		"orgAddress":      t.orgAddress,
+		"orgName":         t.orgName,
+		"percent":         percent,
+		"quarter":         func() int { return storage.Quarter(t.date) },
 		"query":           t.query,
 		"reportMonth":     t.report,
+		"reportPeriod":    t.reportPeriod,
+		"reportQuarter":   t.reportQuarter,
+		"reportYear":      t.reportYear,
+		"signed":          signed,
+		"siteName":        t.siteName,
 		"sortReportMonth": t.setSortReport,
 		"totalMonth":      t.totalMonth,
+		"totalQuarter":    t.totalQuarter,
+		"totalYear":       t.totalYear,
 	}
 
 	templateText, err := ioutil.ReadAll(in)
@@ -91,15 +129,33 @@ func (t *tmpl) parse(in, out *os.File) error {
 	return nil
 }
 
-// Назви місяців
+// Назва місяця
 func (t *tmpl) monthName() string {
-	monthMap := [13]string{
-		"", "січень", "лютий", "березень", "квітень", "травень",
-		"червень", "липень", "серпень", "вересень", "жовтень",
-		"листопад", "грудень",
+	return report.MonthName(t.date.Month())
+}
+
+// Назва вказаного місяця, наприклад, колонки звіту за період
+func monthNameOf(date time.Time) string {
+	return report.MonthName(date.Month())
+}
+
+// Назва організації
+func (t *tmpl) siteName() string {
+	return t.stor.GetSite().Name
+}
+
+// Назва організації з налаштувань, або назва організації в базі даних,
+// якщо в налаштуваннях її не задано
+func (t *tmpl) orgName() string {
+	if t.config.Organization.Name != "" {
+		return t.config.Organization.Name
 	}
+	return t.siteName()
+}
 
-	return monthMap[t.date.Month()]
+// Адреса організації з налаштувань
+func (t *tmpl) orgAddress() string {
+	return t.config.Organization.Address
 }
 
 // Запит до бази даних
@@ -111,95 +167,41 @@ func (t *tmpl) query(query string) [][]string {
 	return result
 }
 
-// Точка обліку
-type Place struct {
-	Substation int
-	Eic        string
-	Name       string
-	Meters     []*Meter
-	Lines      int // Кількість лічильників
+// Місячний звіт за вказану дату
+func (t *tmpl) report() []*report.Place {
+	places := report.Group(t.stor.GetReports(t.date))
+	return report.Sort(places, t.sortName)
 }
 
-// Лічильник
-type Meter struct {
-	Model  string
-	Year   int
-	Serial string
-	Digits int
-	Ratio  int
-	Zones  []*Zone
-	Lines  int // Кількість тарифних зон
+// Порівняння місяця з попереднім місяцем і тим самим місяцем
+// минулого року по точках обліку
+func (t *tmpl) compare() []*storage.PlaceComparison {
+	places := storage.GetComparison(t.stor, t.date).Places
+	return report.SortComparison(places, t.sortName)
 }
 
-// Тарифна зона
-type Zone struct {
-	CurKwh     int
-	PrevKwh    int
-	Diff       int
-	Energy     int
-	Annotation string
+// Порівняння місяця по всіх точках обліку
+func (t *tmpl) compareTotal() *storage.PlaceComparison {
+	return storage.GetComparison(t.stor, t.date).Total
 }
 
-// Місячний звіт за вказану дату
-func (t *tmpl) report() []*Place {
-	reports := t.stor.GetReports(t.date)
-	var places []*Place
-	var place *Place
-	var emeter *Meter
-	for _, report := range reports {
-
-		// точки обліку
-		if place == nil || place.Name != report.Name {
-			place = &Place{
-				report.Substation,
-				report.Eic,
-				report.Name,
-				[]*Meter{},
-				0,
-			}
-			places = append(places, place)
-		}
-
-		// лічильники
-		if emeter == nil || emeter.Serial != report.Serial {
-			emeter = &Meter{
-				report.Model,
-				report.Year,
-				report.Serial,
-				report.Digits,
-				report.Ratio,
-				[]*Zone{},
-				0,
-			}
-			place.Meters = append(place.Meters, emeter)
-		}
-
-		// тарифні зони
-		zone := &Zone{
-			report.CurKwh,
-			report.PreKwh,
-			report.Diff,
-			report.Energy,
-			report.Annotation,
-		}
-		emeter.Zones = append(emeter.Zones, zone)
-		place.Lines++
-		emeter.Lines++
+// Різниця у відсотках зі знаком, або пустий рядок якщо її не можна
+// порахувати. Знак "+" не екранується шаблоном.
+func percent(delta storage.Delta) template.HTML {
+	if !delta.HasPercent() {
+		return ""
 	}
-	return t.sortReport(places)
+	return template.HTML(fmt.Sprintf("%+.1f", delta.Percent))
 }
 
-// Сортує точки обліку відповідно до t.sortName
-func (t *tmpl) sortReport(places []*Place) []*Place {
-	order := make(map[string]int)
-	maxIdx := len(places)
-	for i, name := range t.sortName {
-		order[name] = maxIdx - i
-	}
-	sort.SliceStable(places, func(i, j int) bool {
-		return order[places[i].Name] > order[places[j].Name]
-	})
-	return places
+// Число зі знаком
+func signed(x int) template.HTML {
+	return template.HTML(fmt.Sprintf("%+d", x))
+}
+
+// Використання лімітів за місяць
+func (t *tmpl) budget() []*storage.Usage {
+	return storage.GetMonthUsage(t.stor, t.date)
 }
 
 // Встановлює сортування точок обліку
@@ -212,3 +214,36 @@ func (t *tmpl) setSortReport(names ...string) string {
 func (t *tmpl) totalMonth() int {
 	return t.stor.GetTotal(t.date, t.date)
 }
+
+// Звіт за квартал, в який входить дата
+func (t *tmpl) reportQuarter() *storage.Period {
+	return storage.GetQuarter(t.stor, t.date)
+}
+
+// Звіт за рік, в який входить дата
+func (t *tmpl) reportYear() *storage.Period {
+	return storage.GetYear(t.stor, t.date)
+}
+
+// Звіт за місяці від from до to в форматі YYYY-MM
+func (t *tmpl) reportPeriod(from, to string) (*storage.Period, error) {
+	fromDate, err := storage.DateParse(from)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := storage.DateParse(to)
+	if err != nil {
+		return nil, err
+	}
+	return storage.GetPeriod(t.stor, fromDate, toDate), nil
+}
+
+// Спожита потужність за квартал
+func (t *tmpl) totalQuarter() int {
+	return t.stor.GetTotal(storage.QuarterRange(t.date))
+}
+
+// Спожита потужність за рік
+func (t *tmpl) totalYear() int {
+	return t.stor.GetTotal(storage.YearRange(t.date))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// backup створює резервну копію БД. Якщо вказано каталог, то копія
+// отримує мітку часу, а в каталозі залишається keep останніх копій.
+func backup(file string, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		usageAndExit()
+	}
+	dest := userPath(args[0])
+	keep := storage.BackupKeep
+	if len(args) == 2 {
+		var err error
+		keep, err = strconv.Atoi(args[1])
+		if err != nil || keep < 1 {
+			log.Fatal(i18n.T("Невірна кількість резервних копій"))
+		}
+	}
+
+	dest, err := backupDatabase(file, dest, keep)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(dest)
+}
+
+// backupDatabase створює резервну копію БД file і повертає шлях до неї.
+// БД відкривається тільки для читання, тому копію можна створити, поки
+// інша програма записує.
+func backupDatabase(file, dest string, keep int) (string, error) {
+	stor, err := storage.OpenReadOnly(file)
+	if err != nil {
+		return "", err
+	}
+	defer stor.Close()
+
+	fileInfo, err := os.Stat(dest)
+	if err == nil && fileInfo.IsDir() {
+		return stor.BackupRotate(dest, keep)
+	}
+	return dest, stor.Backup(dest)
+}
+
+// restore відновлює БД з резервної копії.
+func restore(file string, args []string) {
+	if len(args) != 1 {
+		usageAndExit()
+	}
+	err := storage.Restore(file, userPath(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
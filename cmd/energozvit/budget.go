@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// setBudget встановлює або видаляє (remove) ліміт точки обліку чи всієї
+// організації, якщо точку обліку не вказано. YYYY задає річний ліміт,
+// YYYY-MM місячний, який діє з цього місяця.
+func setBudget(file string, args []string) {
+	if len(args) < 2 {
+		usageAndExit()
+	}
+	budget := new(storage.Budget)
+	if len(args[0]) == 4 {
+		budget.Yearly = true
+		budget.Date = parseMonth(args[0] + "-01")
+	} else {
+		budget.Date = parseMonth(args[0])
+	}
+	remove := args[1] == "remove"
+	if !remove {
+		kwh, err := strconv.Atoi(args[1])
+		if err != nil || kwh < 0 {
+			log.Fatal(i18n.T("Невірний ліміт, очікується кВт·год або remove"))
+		}
+		budget.Kwh = kwh
+	}
+	site, rest := siteOption(args[2:])
+	switch len(rest) {
+	case 0:
+	case 1:
+		budget.Name = rest[0]
+	default:
+		usageAndExit()
+	}
+
+	stor := openSite(file, site)
+	defer stor.Close()
+	var err error
+	if remove {
+		err = stor.RemoveBudget(budget)
+	} else {
+		err = stor.SetBudget(budget)
+	}
+	if err != nil {
+		log.Fatalf("%s: %s", err, budget.Name)
+	}
+}
+
+// printBudgets виводить ліміти організації і їх використання за місяць.
+// Без місяця береться останній закритий місяць.
+func printBudgets(file string, args []string) {
+	site, rest := siteOption(args)
+	if len(rest) > 1 {
+		usageAndExit()
+	}
+	stor := openSite(file, site)
+	defer stor.Close()
+	date := stor.GetNextDate().AddDate(0, -1, 0)
+	if len(rest) == 1 {
+		date = parseMonth(rest[0])
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Назва\tДата\tЛіміт"))
+	for _, budget := range stor.GetBudgets() {
+		period := i18n.Sprintf("з %d-%02d", budget.Date.Year(),
+			budget.Date.Month())
+		if budget.Yearly {
+			period = i18n.Sprintf("%d рік", budget.Date.Year())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", budgetName(budget.Name), period,
+			budget.Kwh)
+	}
+	w.Flush()
+
+	i18n.Printf("\nВикористання за %d-%02d\n\n", date.Year(), date.Month())
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Назва\tПеріод\tЕнергія\tЛіміт\t%\t"))
+	for _, usage := range storage.GetMonthUsage(stor, date) {
+		period := i18n.T("місяць")
+		if usage.Yearly {
+			period = i18n.T("рік")
+		}
+		var over string
+		if usage.Over() {
+			over = i18n.T("перевищено")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.1f\t%s\n",
+			budgetName(usage.Name), period, usage.Energy,
+			usage.Budget, usage.Percent, over)
+	}
+	w.Flush()
+}
+
+// budgetName повертає назву точки обліку ліміту.
+func budgetName(name string) string {
+	if name == "" {
+		return i18n.T("Всього")
+	}
+	return name
+}
+
+// siteOption вибирає з параметрів команди --site name. Повертає назву
+// організації і решту параметрів.
+func siteOption(args []string) (string, []string) {
+	var site string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		case strings.HasPrefix(args[i], "--"):
+			usageAndExit()
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return site, rest
+}
+
+// openSite відкриває базу даних і робить поточною організацію site,
+// якщо її вказано.
+func openSite(file, site string) *storage.Storage {
+	stor, err := storage.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			stor.Close()
+			log.Fatalf("%s: %s", err, site)
+		}
+	}
+	return stor
+}
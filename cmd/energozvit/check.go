@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// check перевіряє БД і виводить звіт про знайдені проблеми. З
+// параметром --repair виправляє безпечні проблеми. Якщо залишились
+// проблеми, то програма завершується з кодом 1.
+func check(file string, args []string) {
+	repair := len(args) == 1 && args[0] == "--repair"
+	if len(args) != 0 && !repair {
+		usageAndExit()
+	}
+
+	problems, err := checkDatabase(file, repair)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// checkDatabase перевіряє БД, виводить звіт і з repair виправляє
+// безпечні проблеми. Повертає кількість проблем, що залишились. Для
+// перевірки БД відкривається тільки для читання, для виправлення
+// блокується для запису.
+func checkDatabase(file string, repair bool) (int, error) {
+	var stor *storage.Storage
+	var err error
+	if repair {
+		stor, err = storage.Open(file)
+	} else {
+		stor, err = storage.OpenReadOnly(file)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer stor.Close()
+
+	problems, err := stor.Check()
+	if err != nil {
+		return 0, err
+	}
+	printProblems(problems)
+
+	if repair {
+		repaired, err := stor.Repair(problems)
+		if err != nil {
+			return 0, err
+		}
+		i18n.Printf("\nВиправлено проблем: %d\n", repaired)
+		problems, err = stor.Check()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(problems), nil
+}
+
+// printProblems виводить звіт про проблеми.
+func printProblems(problems []*storage.Problem) {
+	if len(problems) == 0 {
+		fmt.Println(i18n.T("Проблем не знайдено"))
+		return
+	}
+	i18n.Printf("Знайдено проблем: %d\n\n", len(problems))
+	for i, problem := range problems {
+		repairable := ""
+		if problem.Repairable {
+			repairable = i18n.T(" (можна виправити: --repair)")
+		}
+		fmt.Printf("%3d. [%s] %s%s\n", i+1, problem.Kind,
+			problem.Message, repairable)
+	}
+}
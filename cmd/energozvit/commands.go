@@ -0,0 +1,613 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Коди завершення команд для сценаріїв
+const (
+	exitOK       = 0
+	exitError    = 1 // помилка БД або файлів
+	exitUsage    = 2 // невірні параметри команди
+	exitNotFound = 3 // організацію, лічильник або звіт не знайдено
+	exitRejected = 4 // дані не прийнято: обмеження БД, не всі показники
+	exitLocked   = 5 // БД відкрита для запису іншою програмою
+)
+
+// Команда для сценаріїв: позиційні параметри і опції
+type command struct {
+	args    []string
+	json    bool
+	site    string
+	options map[string]string
+	flags   map[string]bool
+}
+
+// Опис команди
+type commandSpec struct {
+	run     func(stor storage.Store, c *command) (int, error)
+	minArgs int
+	maxArgs int
+	options []string // опції зі значенням, крім --site
+	flags   []string // опції без значення, крім --json
+	write   bool     // команда змінює БД
+}
+
+// Команди за назвою: група і дія
+var commands = map[string]commandSpec{
+	"meters list": {run: metersList},
+	"meters add": {run: metersAdd, minArgs: 5, maxArgs: 7, write: true,
+		options: []string{"--eic", "--model", "--year", "--substation"}},
+	"meters remove": {run: metersRemove, minArgs: 1, maxArgs: 1, write: true},
+	"readings set":  {run: readingsSet, minArgs: 3, maxArgs: 3, write: true},
+	"readings batch": {run: readingsBatch, write: true,
+		flags: []string{"--close"}},
+	"month close": {run: monthClose, write: true},
+	"report show": {run: reportShow, minArgs: 1, maxArgs: 1},
+	"total":       {run: printTotal, minArgs: 2, maxArgs: 2},
+}
+
+// isCommand перевіряє чи перший параметр є групою команд.
+func isCommand(name string) bool {
+	for key := range commands {
+		if group, _, _ := strings.Cut(key, " "); group == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runCommand виконує команду для сценаріїв і завершує програму з кодом
+// завершення. З опцією --json результат виводиться в JSON, інакше
+// таблицею. Помилки виводяться в стандартний потік помилок.
+func runCommand(file string, args []string) {
+	name := args[0]
+	if name != "total" && len(args) > 1 {
+		name += " " + args[1]
+	}
+	spec, ok := commands[name]
+	if !ok {
+		commandUsage()
+	}
+
+	c := parseCommand(args[len(strings.Fields(name)):], spec)
+	code, err := execCommand(file, spec, c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+// execCommand відкриває БД і виконує команду c. Повертає код
+// завершення і помилку, БД на цей час вже закрита і блокування знято.
+// Команди, які тільки читають дані, відкривають БД тільки для читання,
+// тому працюють і під час роботи інтерфейсу.
+func execCommand(file string, spec commandSpec, c *command) (int, error) {
+	var stor *storage.Storage
+	var err error
+	if spec.write {
+		stor, err = storage.Open(file)
+	} else {
+		stor, err = storage.OpenReadOnly(file)
+	}
+	switch {
+	case errors.Is(err, storage.ErrLocked):
+		return exitLocked, err
+	case err != nil:
+		return exitError, err
+	}
+	defer stor.Close()
+
+	if c.site != "" {
+		err := storage.SelectSite(stor, c.site)
+		if err != nil {
+			return exitNotFound, fmt.Errorf("%w: %s", err, c.site)
+		}
+	}
+	return spec.run(stor, c)
+}
+
+// parseCommand розбирає параметри команди за описом.
+func parseCommand(args []string, spec commandSpec) *command {
+	c := &command{
+		options: make(map[string]string),
+		flags:   make(map[string]bool),
+	}
+	allowed := make(map[string]bool)
+	for _, option := range spec.options {
+		allowed[option] = true
+	}
+	isFlag := make(map[string]bool)
+	for _, flag := range spec.flags {
+		isFlag[flag] = true
+	}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+			c.json = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			c.site = args[i]
+		case isFlag[args[i]]:
+			c.flags[args[i]] = true
+		case allowed[args[i]] && i+1 < len(args):
+			c.options[args[i]] = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--"):
+			commandUsage()
+		default:
+			c.args = append(c.args, args[i])
+		}
+	}
+	if len(c.args) < spec.minArgs || len(c.args) > spec.maxArgs {
+		commandUsage()
+	}
+	return c
+}
+
+// storeExit повертає код завершення для помилки сховища err: дані не
+// прийнято або інша помилка.
+func storeExit(err error) int {
+	if storage.IsConstraint(err) {
+		return exitRejected
+	}
+	return exitError
+}
+
+// printJSON виводить data в JSON.
+func printJSON(data any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// commandUsage виводить параметри команд для сценаріїв.
+func commandUsage() {
+	fmt.Fprint(os.Stderr, i18n.T("Використання:")+"\n"+
+		"  energozvit db_file meters list [--json] [--site name]\n"+
+		"  energozvit db_file meters add name serial digits ratio "+
+		"kwh [kwh kwh]\n"+
+		"      [--eic eic] [--model model] [--year year] "+
+		"[--substation n] [--json] [--site name]\n"+
+		"  energozvit db_file meters remove serial [--json] "+
+		"[--site name]\n"+
+		"  energozvit db_file readings set serial zone kwh [--json] "+
+		"[--site name]\n"+
+		"  energozvit db_file readings batch [--close] [--json] "+
+		"[--site name] < file\n"+
+		"  energozvit db_file month close [--json] [--site name]\n"+
+		"  energozvit db_file report show YYYY-MM [--json] [--site name]\n"+
+		"  energozvit db_file total YYYY-MM YYYY-MM [--json] "+
+		"[--site name]\n")
+	fmt.Fprintln(os.Stderr, i18n.T("Коди завершення: 0 успіх, 1 помилка, "+
+		"2 параметри, 3 не знайдено, 4 відхилено, 5 заблоковано"))
+	os.Exit(exitUsage)
+}
+
+// number повертає параметр як невідʼємне ціле число.
+func (c *command) number(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, i18n.Errorf("невірне значення %s: %q", i18n.T(name),
+			value)
+	}
+	return n, nil
+}
+
+// month повертає параметр i як місяць YYYY-MM.
+func (c *command) month(i int) (time.Time, error) {
+	date, err := time.Parse("2006-01", c.args[i])
+	if err != nil {
+		return date, i18n.Errorf("невірний місяць %q, очікується "+
+			"YYYY-MM", c.args[i])
+	}
+	return date, nil
+}
+
+// Вивід функцій експорту в JSON
+var jsonOptions = exchange.Options{Format: exchange.FormatJSON}
+
+//---------------------------- METERS FUNCTIONS --------------------------
+
+// metersList виводить діючі лічильники.
+func metersList(stor storage.Store, c *command) (int, error) {
+	if c.json {
+		err := exchange.ExportMeters(os.Stdout, stor, jsonOptions)
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Назва\tНомер\tРозрядність\tКоефіцієнт\tEIC\t"+
+		"Модель\tРік\tПідстанція"))
+	for _, m := range stor.GetActiveMeters() {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%d\t%d\n", m.Name,
+			m.Serial, m.Digits, m.Ratio, m.Eic, m.Model, m.Year,
+			m.Substation)
+	}
+	w.Flush()
+	return exitOK, nil
+}
+
+// metersAdd додає лічильник з початковими показниками по зонах і
+// виводить діючі лічильники.
+func metersAdd(stor storage.Store, c *command) (int, error) {
+	// перша помилка в числових параметрах
+	var err error
+	number := func(name, value string) int {
+		n, numErr := c.number(name, value)
+		if err == nil {
+			err = numErr
+		}
+		return n
+	}
+	meter := &storage.Meter{
+		Name:   c.args[0],
+		Serial: c.args[1],
+		Digits: number("розрядності", c.args[2]),
+		Ratio:  number("коефіцієнта", c.args[3]),
+		Eic:    c.options["--eic"],
+		Model:  c.options["--model"],
+	}
+	if year, ok := c.options["--year"]; ok {
+		meter.Year = number("року", year)
+	}
+	if substation, ok := c.options["--substation"]; ok {
+		meter.Substation = number("підстанції", substation)
+	}
+	kwh := make([]int, 0, 3)
+	for _, arg := range c.args[4:] {
+		kwh = append(kwh, number("показника", arg))
+	}
+	if err != nil {
+		return exitUsage, err
+	}
+
+	err = stor.AddMeter(meter, kwh)
+	if err != nil {
+		return storeExit(err), fmt.Errorf("%w: %s", err, meter.Serial)
+	}
+	return metersList(stor, c)
+}
+
+// metersRemove видаляє діючий лічильник за номером і виводить діючі
+// лічильники. Якщо діє кілька лічильників з таким номером, то жоден не
+// видаляється.
+func metersRemove(stor storage.Store, c *command) (int, error) {
+	serial := c.args[0]
+	found := make([]*storage.Meter, 0, 1)
+	for _, m := range stor.GetActiveMeters() {
+		if m.Serial == serial {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return exitNotFound, fmt.Errorf("%w: %s", exchange.ErrUnknown,
+			serial)
+	case 1:
+	default:
+		return exitRejected, fmt.Errorf("%w: %s", exchange.ErrAmbiguous,
+			serial)
+	}
+	if err := stor.RemoveMeter(found[0]); err != nil {
+		return exitError, err
+	}
+	return metersList(stor, c)
+}
+
+//---------------------------- REPORTS FUNCTIONS -------------------------
+
+// readingsSet заносить показник лічильника в наступний звіт як чернетку
+// і виводить занесений показник або всю форму в JSON.
+func readingsSet(stor storage.Store, c *command) (int, error) {
+	zone, err := c.number("зони", c.args[1])
+	if err != nil {
+		return exitUsage, err
+	}
+	kwh, err := c.number("показника", c.args[2])
+	if err != nil {
+		return exitUsage, err
+	}
+	row := &exchange.Row{Line: 1, Key: c.args[0], Zone: zone, Kwh: kwh}
+	reports := stor.GetNextReports()
+	changed, errs := exchange.Apply(reports, []*exchange.Row{row})
+	if len(errs) > 0 {
+		err := errs[0].Err
+		code := exitRejected
+		if errors.Is(err, exchange.ErrUnknown) {
+			code = exitNotFound
+		}
+		return code, i18n.Errorf("%s, зона %d: %w", row.Key, row.Zone,
+			err)
+	}
+	err = stor.SaveDrafts(changed)
+	if err != nil {
+		return storeExit(err), err
+	}
+
+	if c.json {
+		err := exchange.ExportNext(os.Stdout, stor, reports, jsonOptions)
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+	printImport(stor, reports, changed)
+	return exitOK, nil
+}
+
+// Результат рядка пакетного введення в JSON
+type jsonLine struct {
+	Line       int    `json:"line"`
+	Serial     string `json:"serial"`
+	Zone       int    `json:"zone"`
+	CurKwh     int    `json:"cur_kwh"`
+	PreKwh     int    `json:"pre_kwh"`
+	Diff       int    `json:"diff"`
+	Energy     int    `json:"energy"`
+	Annotation string `json:"annotation"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Результат пакетного введення в JSON
+type jsonBatch struct {
+	Site   string     `json:"site"`
+	Date   string     `json:"date"`
+	Lines  []jsonLine `json:"lines"`
+	Total  int        `json:"total"`
+	Saved  int        `json:"saved"`
+	Closed bool       `json:"closed"`
+}
+
+// readingsBatch заносить в наступний звіт показники зі стандартного
+// вводу, рядки якого мають поля: номер лічильника, зона, показник і
+// необовʼязкова примітка. Виводить результат кожного рядка з обчисленою
+// енергією. Якщо хоч один рядок не прочитано або не занесено, то
+// нічого не зберігається. З опцією --close після збереження
+// закривається місяць.
+func readingsBatch(stor storage.Store, c *command) (int, error) {
+	rows, err := exchange.ReadText(os.Stdin)
+	var lineErrs exchange.LinesError
+	if errors.As(err, &lineErrs) {
+		return readErrors(stor, c, lineErrs)
+	}
+	if err != nil {
+		return exitError, err
+	}
+	reports := stor.GetNextReports()
+	changed, errs := exchange.Apply(reports, rows)
+	rowErrs := make(map[*exchange.Row]error)
+	for _, e := range errs {
+		rowErrs[e.Row] = e.Err
+	}
+
+	batch := jsonBatch{
+		Site:  stor.GetSite().Name,
+		Date:  monthString(stor.GetNextDate()),
+		Lines: make([]jsonLine, 0, len(rows)),
+		Total: stor.GetNextTotal(reports),
+	}
+	for _, row := range rows {
+		line := jsonLine{Line: row.Line, Serial: row.Key, Zone: row.Zone,
+			CurKwh: row.Kwh, Annotation: row.Annotation}
+		if err, ok := rowErrs[row]; ok {
+			line.Error = err.Error()
+		} else if r, err := exchange.MatchReport(reports, row); err == nil {
+			line.Serial, line.PreKwh = r.Serial, r.PreKwh
+			line.Diff, line.Energy = r.Diff, r.Energy
+			line.Annotation = r.Annotation
+		}
+		batch.Lines = append(batch.Lines, line)
+	}
+
+	// збереження і закриття місяця, помилка після виводу результату
+	code, failure := exitOK, error(nil)
+	switch {
+	case len(errs) > 0:
+		code, failure = exitRejected, i18n.Errorf("не занесено рядків: "+
+			"%d, нічого не збережено", len(errs))
+	default:
+		err := stor.SaveDrafts(changed)
+		if err != nil {
+			return exitError, err
+		}
+		batch.Saved = len(changed)
+		if !c.flags["--close"] {
+			break
+		}
+		if missing := stor.GetMissingReadings(); missing > 0 {
+			code, failure = exitRejected, i18n.Errorf("не введено "+
+				"показників: %d, місяць не закрито", missing)
+			break
+		}
+		err = stor.SaveReports([]*storage.Report{})
+		switch {
+		case storage.IsConstraint(err):
+			code, failure = exitRejected, err
+		case err != nil:
+			return exitError, err
+		default:
+			batch.Closed = true
+		}
+	}
+
+	if c.json {
+		if err := printJSON(batch); err != nil {
+			return exitError, err
+		}
+	} else {
+		printBatch(batch)
+	}
+	return code, failure
+}
+
+// readErrors виводить помилки всіх рядків, які не вдалось прочитати, і
+// повертає код завершення невірних параметрів.
+func readErrors(stor storage.Store, c *command,
+	errs exchange.LinesError) (int, error) {
+	err := i18n.Errorf("не прочитано рядків: %d, нічого не збережено",
+		len(errs))
+	if !c.json {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "  %s\n", e)
+		}
+		return exitUsage, err
+	}
+	batch := jsonBatch{
+		Site:  stor.GetSite().Name,
+		Date:  monthString(stor.GetNextDate()),
+		Lines: make([]jsonLine, 0, len(errs)),
+		Total: stor.GetNextTotal(stor.GetNextReports()),
+	}
+	for _, e := range errs {
+		batch.Lines = append(batch.Lines, jsonLine{Line: e.Line,
+			Error: e.Err.Error()})
+	}
+	if err := printJSON(batch); err != nil {
+		return exitError, err
+	}
+	return exitUsage, err
+}
+
+// printBatch виводить результат пакетного введення таблицею.
+func printBatch(batch jsonBatch) {
+	fmt.Printf("%s, %s\n\n", batch.Site, batch.Date)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Рядок\tНомер\tЗона\tТеперешні\tПопередні\t"+
+		"Різниця\tВсього\tПримітка"))
+	for _, l := range batch.Lines {
+		if l.Error != "" {
+			i18n.Fprintf(w, "%d\t%s\t%d\t%d\tпомилка: %s\n", l.Line,
+				l.Serial, l.Zone, l.CurKwh, l.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n", l.Line,
+			l.Serial, l.Zone, l.CurKwh, l.PreKwh, l.Diff, l.Energy,
+			l.Annotation)
+	}
+	w.Flush()
+	i18n.Printf("\nВсього по формі: %d\nЗбережено показників: %d\n",
+		batch.Total, batch.Saved)
+	if batch.Closed {
+		i18n.Printf("Місяць %s закрито\n", batch.Date)
+	}
+}
+
+// Результат закриття місяця в JSON
+type jsonClose struct {
+	Site     string `json:"site"`
+	Closed   string `json:"closed"`
+	NextDate string `json:"next_date"`
+}
+
+// monthClose закриває місяць, якщо введено всі показники.
+func monthClose(stor storage.Store, c *command) (int, error) {
+	if missing := stor.GetMissingReadings(); missing > 0 {
+		return exitRejected, i18n.Errorf("не введено показників: %d",
+			missing)
+	}
+	date := stor.GetNextDate()
+	err := stor.SaveReports([]*storage.Report{})
+	if err != nil {
+		return storeExit(err), err
+	}
+
+	result := jsonClose{
+		Site:     stor.GetSite().Name,
+		Closed:   monthString(date),
+		NextDate: monthString(stor.GetNextDate()),
+	}
+	if c.json {
+		if err := printJSON(result); err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+	i18n.Printf("%s: закрито %s, наступний звіт %s\n", result.Site,
+		result.Closed, result.NextDate)
+	return exitOK, nil
+}
+
+// reportShow виводить звіт за місяць.
+func reportShow(stor storage.Store, c *command) (int, error) {
+	date, err := c.month(0)
+	if err != nil {
+		return exitUsage, err
+	}
+	reports := stor.GetReports(date)
+	if len(reports) == 0 {
+		return exitNotFound, i18n.Errorf("звіт за %s не знайдено",
+			monthString(date))
+	}
+	if c.json {
+		err := exchange.ExportReports(os.Stdout, stor, date, date,
+			jsonOptions)
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+
+	fmt.Printf("%s, %s\n\n", stor.GetSite().Name, monthString(date))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Назва\tНомер\tЗона\tТеперешні\tПопередні\t"+
+		"Різниця\tВсього\tПримітка"))
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.Name, r.Serial, r.Zone, r.CurKwh, r.PreKwh,
+			r.Diff, r.Energy, r.Annotation)
+	}
+	w.Flush()
+	i18n.Printf("\nВсього: %d\n", stor.GetTotal(date, date))
+	return exitOK, nil
+}
+
+// printTotal виводить суми спожитої енергії по місяцях за період.
+func printTotal(stor storage.Store, c *command) (int, error) {
+	from, err := c.month(0)
+	if err != nil {
+		return exitUsage, err
+	}
+	to, err := c.month(1)
+	if err != nil {
+		return exitUsage, err
+	}
+	if from.After(to) {
+		return exitUsage, i18n.NewError("початок періоду пізніше кінця")
+	}
+	if c.json {
+		err := exchange.ExportTotals(os.Stdout, stor, from, to, jsonOptions)
+		if err != nil {
+			return exitError, err
+		}
+		return exitOK, nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, i18n.T("Місяць\tВсього\t"))
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		fmt.Fprintf(w, "%s\t%d\t\n", monthString(date),
+			stor.GetTotal(date, date))
+	}
+	i18n.Fprintf(w, "Всього\t%d\t\n", stor.GetTotal(from, to))
+	w.Flush()
+	return exitOK, nil
+}
+
+// monthString повертає місяць в форматі YYYY-MM.
+func monthString(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// export записує звіти, суми спожитої енергії або діючі лічильники в CSV
+// чи JSON. Період задається як YYYY-MM або YYYY-MM:YYYY-MM, без періоду
+// береться останній закритий місяць.
+func export(file string, args []string) {
+	if len(args) == 0 {
+		usageAndExit()
+	}
+	kind := args[0]
+	if kind != "reports" && kind != "totals" && kind != "meters" {
+		usageAndExit()
+	}
+
+	// Параметри
+	opts := exchange.Options{
+		Format: exchange.FormatCSV,
+		Comma:  exchange.DefaultComma,
+	}
+	var period, output, site string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--json":
+			opts.Format = exchange.FormatJSON
+		case args[i] == "--delimiter" && i+1 < len(args):
+			i++
+			comma, size := utf8.DecodeRuneInString(args[i])
+			if size == 0 || size != len(args[i]) {
+				log.Fatal(i18n.T("Невірний роздільник, очікується один символ"))
+			}
+			opts.Comma = comma
+		case args[i] == "--output" && i+1 < len(args):
+			i++
+			output = userPath(args[i])
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		case period == "" && !strings.HasPrefix(args[i], "--"):
+			period = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+
+	err := exportFile(file, kind, period, output, site, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// exportFile записує дані kind організації site за період period в
+// файл output або в стандартний вивід. БД відкривається тільки для
+// читання.
+func exportFile(file, kind, period, output, site string,
+	opts exchange.Options) error {
+	stor, err := storage.OpenReadOnly(file)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, site)
+		}
+	}
+	from, to := parsePeriod(stor, period)
+
+	// Запис в файл або в стандартний вивід
+	var w io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	switch kind {
+	case "reports":
+		return exchange.ExportReports(w, stor, from, to, opts)
+	case "totals":
+		return exchange.ExportTotals(w, stor, from, to, opts)
+	}
+	return exchange.ExportMeters(w, stor, opts)
+}
+
+// parsePeriod розбирає період YYYY-MM або YYYY-MM:YYYY-MM. Пустий
+// період означає останній закритий місяць.
+func parsePeriod(stor storage.Store, period string) (from, to time.Time) {
+	if period == "" {
+		last := stor.GetNextDate().AddDate(0, -1, 0)
+		return last, last
+	}
+	first, last, found := strings.Cut(period, ":")
+	from = parseMonth(first)
+	to = from
+	if found {
+		to = parseMonth(last)
+	}
+	return from, to
+}
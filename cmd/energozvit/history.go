@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// importHistory додає лічильники і показники за минулі місяці з двох
+// CSV файлів в організацію без лічильників і встановлює дату
+// наступного звіту. Якщо БД не існує, то вона створюється. З
+// параметром --dry-run показники тільки перевіряються. Якщо є помилки,
+// то нічого не додається і програма завершується з кодом 1.
+func importHistory(file string, args []string) {
+	if len(args) < 2 || strings.HasPrefix(args[0], "--") ||
+		strings.HasPrefix(args[1], "--") {
+		usageAndExit()
+	}
+	var dryRun bool
+	var site string
+	for i := 2; i < len(args); i++ {
+		switch {
+		case args[i] == "--dry-run":
+			dryRun = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+
+	// Лічильники і показники
+	src, err := os.Open(userPath(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	meters, err := exchange.ReadMetersCSV(src)
+	src.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err = os.Open(userPath(args[1]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := exchange.ReadHistoryCSV(src)
+	src.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	history, errs := exchange.BuildHistory(meters, rows)
+	if len(errs) > 0 {
+		printHistoryErrors(errs)
+		os.Exit(1)
+	}
+
+	// Нова БД створюється тільки для запису показників і видаляється,
+	// якщо показники не додано
+	_, err = os.Stat(file)
+	created := err != nil
+	next, errs, err := writeHistory(file, created, dryRun, site, meters,
+		history)
+	if created && !dryRun && (err != nil || len(errs) > 0) {
+		os.Remove(file)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(errs) > 0 {
+		printHistoryErrors(errs)
+		os.Exit(1)
+	}
+	if dryRun {
+		i18n.Printf("Перевірено лічильників: %d, показників: %d, "+
+			"помилок нема\n", len(meters), len(rows))
+		return
+	}
+	i18n.Printf("Додано лічильників: %d, показників: %d. "+
+		"Дата наступного звіту %d-%02d\n", len(meters), len(rows),
+		next.Year(), next.Month())
+}
+
+// writeHistory додає лічильники і показники в організацію site БД file,
+// створеної, якщо created, і повертає дату наступного звіту. БД
+// закривається до повернення, тому створену БД можна видалити.
+func writeHistory(file string, created, dryRun bool, site string,
+	meters []*exchange.MeterRow, history []*storage.History) (
+	time.Time, []error, error) {
+	var stor storage.Store
+	var err error
+	switch {
+	case created && dryRun:
+		stor = storage.NewMemory(time.Now())
+	case created:
+		err = storage.Create(file, time.Now())
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		fallthrough
+	default:
+		stor, err = storage.Open(file)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("%w: %s", err, site)
+		}
+	}
+
+	errs := exchange.ImportHistory(stor, meters, history, dryRun)
+	return stor.GetNextDate(), errs, nil
+}
+
+func printHistoryErrors(errs []error) {
+	i18n.Printf("Помилок: %d, лічильники не додано\n", len(errs))
+	for _, err := range errs {
+		fmt.Printf("  %s\n", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// importCSV заносить показники з CSV файла в форму введення показників і
+// виводить їх з обчисленою енергією. З параметром --save показники
+// зберігаються без закриття місяця, закрити місяць потрібно в програмі.
+// Якщо є рядки, які не вдалось занести, то нічого не зберігається і
+// програма завершується з кодом 1.
+func importCSV(file string, args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		usageAndExit()
+	}
+	var save bool
+	var site string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--save":
+			save = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+
+	src, err := os.Open(userPath(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := exchange.ReadCSV(src)
+	src.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	applyRows(file, rows, save, site)
+}
+
+// applyRows заносить показники в форму введення показників організації
+// site (або організації за замовчуванням), виводить їх і з save
+// зберігає. Якщо є рядки, які не вдалось занести, то нічого не
+// зберігається і програма завершується з кодом 1.
+func applyRows(file string, rows []*exchange.Row, save bool, site string) {
+	stor, err := storage.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			stor.Close()
+			log.Fatalf("%s: %s", err, site)
+		}
+	}
+
+	reports := stor.GetNextReports()
+	changed, errs := exchange.Apply(reports, rows)
+	printImport(stor, reports, changed)
+	if len(errs) > 0 {
+		i18n.Printf("\nНе занесено рядків: %d\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  %s\n", err)
+		}
+		stor.Close()
+		os.Exit(1)
+	}
+
+	if save {
+		err := stor.SaveDrafts(changed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		i18n.Printf("\nЗбережено показників: %d\n", len(changed))
+	}
+}
+
+// printImport виводить занесені показники.
+func printImport(stor storage.Store, reports, changed []*storage.Report) {
+	date := stor.GetNextDate()
+	i18n.Printf("%s, %d-%02d, занесено показників: %d з %d\n\n",
+		stor.GetSite().Name, date.Year(), date.Month(),
+		len(changed), len(reports))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, i18n.T("Назва\tНомер\tЗона\tТеперешні\tПопередні\t"+
+		"Різниця\tВсього\tПримітка"))
+	for _, r := range changed {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.Name, r.Serial, r.Zone, r.CurKwh, r.PreKwh,
+			r.Diff, r.Energy, r.Annotation)
+	}
+	w.Flush()
+	i18n.Printf("\nВсього по формі: %d\n", stor.GetNextTotal(reports))
+}
@@ -1,27 +1,62 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/kraserh/energozvit/internal/tui"
 
+	"github.com/kraserh/energozvit/internal/config"
+	"github.com/kraserh/energozvit/internal/i18n"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
 var Version string
 
+// Каталог з якого запущено програму.
+var workDir string
+
 func main() {
 	log.SetFlags(log.Lshortfile)
-	if len(os.Args) != 2 && len(os.Args) != 4 {
-		usageAndExit()
+	cfg, err := config.Load()
+	if err == nil {
+		err = i18n.SetLocale(cfg.Locale)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Перший параметр: імʼя бази даних. Якщо його не задано, береться
+	// база даних з налаштувань.
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") ||
+		isCommand(args[0]) {
+		if cfg.Database == "" {
+			usageAndExit()
+		}
+		args = append([]string{cfg.Database}, args...)
+	}
+	pathDB := args[0]
+	args = args[1:]
+	err = cfg.ForDatabase(pathDB)
+	if err == nil {
+		err = i18n.SetLocale(cfg.Locale)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Шляхи до файлів в параметрах задані відносно поточного каталогу
+	workDir, err = os.Getwd()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	// Перший параметр: імʼя бази даних
-	pathDB := os.Args[1]
 	dir, file := filepath.Split(pathDB)
 	if dir != "" {
 		err := os.Chdir(dir)
@@ -30,38 +65,198 @@ func main() {
 		}
 	}
 
-	// Другий параметр необовʼязковий: --create, для створення БД
-	if len(os.Args) == 4 {
-		if os.Args[2] != "--create" {
+	// Відкриття БД і запуск інтерфейса
+	if len(args) == 0 || args[0] == "--readonly" {
+		startTui(file, args, cfg)
+		return
+	}
+
+	// Другий параметр необовʼязковий: команда
+	switch args[0] {
+	case "--create":
+		create(file, args[1:])
+	case "--backup":
+		backup(file, args[1:])
+	case "--restore":
+		restore(file, args[1:])
+	case "--check":
+		check(file, args[1:])
+	case "--unlock":
+		unlock(file, args[1:])
+	case "--add-site":
+		addSite(file, args[1:])
+	case "--import":
+		importCSV(file, args[1:])
+	case "--import-meters":
+		importMeters(file, args[1:])
+	case "--import-history":
+		importHistory(file, args[1:])
+	case "--readout":
+		readout(file, args[1:])
+	case "--poll":
+		pollMeters(file, args[1:])
+	case "--simulate":
+		simulate(file, args[1:])
+	case "--budget":
+		setBudget(file, args[1:])
+	case "--budgets":
+		printBudgets(file, args[1:])
+	case "--export":
+		export(file, args[1:])
+	case "--spreadsheet":
+		writeSpreadsheet(file, args[1:])
+	case "--serve":
+		serve(file, args[1:])
+	default:
+		if !isCommand(args[0]) {
 			usageAndExit()
 		}
-		const dateFormat = "2006-01-02"
-		date := fmt.Sprintf("%s-01", os.Args[3])
-		initDate, err := time.Parse(dateFormat, date)
-		if err != nil {
-			log.Fatal("Bad date format, expect YYYY-MM")
-		}
-		err = storage.Create(file, initDate)
-		if err != nil {
-			log.Fatal(err)
-		}
+		runCommand(file, args)
 	}
+}
 
-	// Відкриття БД і запуск інтерфейса
-	if len(os.Args) == 2 {
-		stor, err := storage.Open(file)
-		if err != nil {
-			log.Fatal(err)
+// startTui відкриває БД і запускає інтерфейс з налаштуваннями cfg. З
+// параметром --readonly БД відкривається тільки для читання і зміна
+// даних вимкнена.
+func startTui(file string, args []string, cfg *config.Config) {
+	if len(args) > 1 {
+		usageAndExit()
+	}
+	var stor *storage.Storage
+	var err error
+	if len(args) == 1 {
+		stor, err = storage.OpenReadOnly(file)
+	} else {
+		stor, err = storage.Open(file)
+		if errors.Is(err, storage.ErrLocked) {
+			stor, err = openReadOnly(file, err)
 		}
-		defer stor.Close()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stor.Close()
 
-		tui.Start(stor)
+	tui.Start(stor, cfg)
+}
+
+// create створює БД з початковою датою YYYY-MM.
+func create(file string, args []string) {
+	if len(args) != 1 {
+		usageAndExit()
+	}
+	err := storage.Create(file, parseMonth(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openReadOnly пропонує відкрити тільки для читання базу даних, яку
+// вже відкрито для запису.
+func openReadOnly(file string, lockErr error) (*storage.Storage, error) {
+	fmt.Println(lockErr)
+	fmt.Print(i18n.T("Відкрити тільки для читання? [y/N] "))
+	var answer string
+	fmt.Scanln(&answer)
+	switch strings.ToLower(answer) {
+	case "y", "yes", "т", "так":
+		return storage.OpenReadOnly(file)
 	}
+	return nil, lockErr
+}
+
+// unlock знімає блокування БД, залишене програмою, яка аварійно
+// завершилась.
+func unlock(file string, args []string) {
+	if len(args) != 0 {
+		usageAndExit()
+	}
+	err := storage.Unlock(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addSite додає в БД організацію з назвою і початковою датою YYYY-MM.
+func addSite(file string, args []string) {
+	if len(args) != 2 {
+		usageAndExit()
+	}
+	firstDate := parseMonth(args[1])
+	stor, err := storage.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stor.Close()
+	err = stor.AddSite(&storage.Site{Name: args[0]}, firstDate)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseMonth розбирає дату в форматі YYYY-MM.
+func parseMonth(yymm string) time.Time {
+	const dateFormat = "2006-01-02"
+	date := fmt.Sprintf("%s-01", yymm)
+	month, err := time.Parse(dateFormat, date)
+	if err != nil {
+		log.Fatal(i18n.T("Невірний формат дати, очікується YYYY-MM"))
+	}
+	return month
+}
+
+// userPath повертає шлях до файла, вказаного в параметрах команди.
+func userPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
 }
 
 func usageAndExit() {
-	fmt.Println("EnergoZvit programm")
-	fmt.Printf("Version: %s\n", Version)
-	fmt.Printf("Usage:\n  energozvit db_file [--create YYYY-MM]\n")
+	fmt.Println(i18n.T("Програма EnergoZvit"))
+	i18n.Printf("Версія: %s\n", Version)
+	fmt.Print(i18n.T("Використання:") + "\n" +
+		"  energozvit db_file [--create YYYY-MM]\n" +
+		"  energozvit db_file --readonly\n" +
+		"  energozvit db_file --backup dest_file|dest_dir [keep]\n" +
+		"  energozvit db_file --restore src_file\n" +
+		"  energozvit db_file --check [--repair]\n" +
+		"  energozvit db_file --unlock\n" +
+		"  energozvit db_file --add-site name YYYY-MM\n" +
+		"  energozvit db_file --import file.csv [--save] [--site name]\n" +
+		"  energozvit db_file --import-meters file.csv [--dry-run] " +
+		"[--site name]\n" +
+		"  energozvit db_file --import-history meters.csv readings.csv\n" +
+		"      [--dry-run] [--site name]\n" +
+		"  energozvit db_file --readout file|device... [--save] " +
+		"[--site name]\n" +
+		"  energozvit db_file --poll config.json [--save] [--site name]\n" +
+		"  energozvit db_file --simulate config.json\n" +
+		"  energozvit db_file --budget YYYY|YYYY-MM kwh|remove [place] " +
+		"[--site name]\n" +
+		"  energozvit db_file --budgets [YYYY-MM] [--site name]\n" +
+		"  energozvit db_file --export reports|totals|meters " +
+		"[YYYY-MM[:YYYY-MM]]\n" +
+		"      [--json] [--delimiter char] [--output file] " +
+		"[--site name]\n" +
+		"  energozvit db_file --spreadsheet file.xlsx|file.ods " +
+		"[YYYY-MM[:YYYY-MM]]\n" +
+		"      [--order name,name] [--site name]\n" +
+		"  energozvit db_file --serve [host]:port [--readonly] " +
+		"[--site name]\n" +
+		"  energozvit db_file meters|readings|month|report|total ...\n" +
+		"      [--json] [--site name]\n")
+	i18n.Printf("db_file можна не вказувати, якщо базу даних задано в %s\n",
+		configPath())
 	os.Exit(0)
 }
+
+// configPath повертає шлях до загального файла налаштувань для довідки.
+func configPath() string {
+	path, err := config.Path()
+	if err != nil {
+		return "config.json"
+	}
+	return path
+}
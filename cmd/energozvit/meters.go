@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// importMeters додає точки обліку і лічильники з початковими показниками
+// з CSV файла в одній транзакції. З параметром --dry-run лічильники
+// тільки перевіряються. Якщо є помилки, то нічого не додається і
+// програма завершується з кодом 1.
+func importMeters(file string, args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		usageAndExit()
+	}
+	var dryRun bool
+	var site string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--dry-run":
+			dryRun = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+
+	src, err := os.Open(userPath(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := exchange.ReadMetersCSV(src)
+	src.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stor, err := storage.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			log.Fatalf("%s: %s", err, site)
+		}
+	}
+
+	errs := exchange.ImportMeters(stor, rows, dryRun)
+	if len(errs) > 0 {
+		i18n.Printf("Помилок: %d, лічильники не додано\n", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  %s\n", err)
+		}
+		stor.Close()
+		os.Exit(1)
+	}
+	if dryRun {
+		i18n.Printf("Перевірено лічильників: %d, помилок нема\n",
+			len(rows))
+		return
+	}
+	i18n.Printf("Додано лічильників: %d\n", len(rows))
+}
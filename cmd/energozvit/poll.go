@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/poll"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Споживання за місяць в симуляторі, якщо нема попереднього звіту
+const simulatedDiff = 100
+
+// pollMeters опитує лічильники Modbus з налаштувань і заносить показники
+// в форму введення показників за номером лічильника. З параметром --save
+// показники зберігаються як чернетка для перевірки, місяць не
+// закривається. Якщо якийсь лічильник не вдалось опитати, то показники
+// інших все одно заносяться, а програма завершується з кодом 1.
+func pollMeters(file string, args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		usageAndExit()
+	}
+	var save bool
+	var site string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--save":
+			save = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+	config := readPollConfig(args[0])
+
+	rows, errs := poll.Poll(config)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	applyRows(file, rows, save, site)
+	if len(errs) > 0 {
+		i18n.Printf("\nНе опитано лічильників: %d\n", len(errs))
+		os.Exit(1)
+	}
+}
+
+// simulate запускає симулятори Modbus TCP на адресах лічильників з
+// налаштувань до сигналу завершення. Показники в регістрах дорівнюють
+// попереднім показникам з БД плюс споживання за попередній місяць.
+func simulate(file string, args []string) {
+	if len(args) != 1 {
+		usageAndExit()
+	}
+	config := readPollConfig(args[0])
+
+	stor, err := storage.OpenReadOnly(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	kwh := simulatedReadings(stor)
+	stor.Close()
+
+	simulators, err := poll.Simulate(config,
+		func(serial string, zone int) int {
+			return kwh[serial+"/"+fmt.Sprint(zone)]
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Print(i18n.Sprintf("Симулятор Modbus TCP, лічильників: %d",
+		len(config.Meters)))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+	for _, sim := range simulators {
+		sim.Close()
+	}
+}
+
+// simulatedReadings повертає показники наступного звіту за ключем
+// "номер/зона".
+func simulatedReadings(stor storage.Store) map[string]int {
+	diffs := make(map[string]int)
+	for _, r := range stor.GetReports(stor.GetNextDate().AddDate(0, -1, 0)) {
+		diffs[fmt.Sprintf("%s/%d", r.Serial, r.Zone)] = r.Diff
+	}
+	kwh := make(map[string]int)
+	for _, r := range stor.GetNextReports() {
+		key := fmt.Sprintf("%s/%d", r.Serial, r.Zone)
+		diff := diffs[key]
+		if diff <= 0 {
+			diff = simulatedDiff
+		}
+		limit := 1
+		for i := 0; i < r.Digits; i++ {
+			limit *= 10
+		}
+		kwh[key] = (r.PreKwh + diff) % limit
+	}
+	return kwh
+}
+
+// readPollConfig читає налаштування опитування лічильників.
+func readPollConfig(path string) *poll.Config {
+	src, err := os.Open(userPath(path))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+	config, err := poll.ReadConfig(src)
+	if err != nil {
+		log.Fatal(fmt.Errorf("%s: %w", path, err))
+	}
+	return config
+}
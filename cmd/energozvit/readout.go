@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/iec62056"
+)
+
+// readout читає показники лічильників за протоколом IEC 62056-21 через
+// оптичну головку (шлях до послідовного порту) або з записаних файлів і
+// заносить їх в форму введення показників за номером лічильника. З
+// параметром --save показники зберігаються без закриття місяця.
+func readout(file string, args []string) {
+	var save bool
+	var site string
+	paths := make([]string, 0)
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--save":
+			save = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		case strings.HasPrefix(args[i], "--"):
+			usageAndExit()
+		default:
+			paths = append(paths, args[i])
+		}
+	}
+	if len(paths) == 0 {
+		usageAndExit()
+	}
+
+	rows := make([]*exchange.Row, 0)
+	for _, path := range paths {
+		readout, err := iec62056.ReadPath(userPath(path))
+		if err == nil {
+			var readRows []*exchange.Row
+			readRows, err = readout.Rows()
+			rows = append(rows, readRows...)
+		}
+		if err != nil {
+			log.Fatal(fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	applyRows(file, rows, save, site)
+}
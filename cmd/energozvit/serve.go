@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kraserh/energozvit/internal/server"
+	"github.com/kraserh/energozvit/internal/storage"
+	"github.com/kraserh/energozvit/internal/web"
+)
+
+// serve запускає REST API і вебінтерфейс введення показників на адресі
+// addr до сигналу завершення. З параметром --readonly БД відкривається
+// тільки для читання.
+func serve(file string, args []string) {
+	var addr, site string
+	readOnly := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--readonly":
+			readOnly = true
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		case addr == "" && args[i] != "" && args[i][0] != '-':
+			addr = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+	if addr == "" {
+		usageAndExit()
+	}
+
+	var stor *storage.Storage
+	var err error
+	if readOnly {
+		stor, err = storage.OpenReadOnly(file)
+	} else {
+		stor, err = storage.Open(file)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			stor.Close()
+			log.Printf("%s: %s", err, site)
+			os.Exit(exitNotFound)
+		}
+	}
+
+	// завершення по сигналу, щоб закрити БД і зняти блокування
+	handler := server.New(stor)
+	handler.Handle("/", web.Handler())
+	handler.Handle("/dashboard", web.Dashboard(stor))
+	srv := &http.Server{Addr: addr, Handler: handler}
+	done := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		err := srv.Shutdown(context.Background())
+		if err != nil {
+			log.Print(err)
+		}
+		close(done)
+	}()
+
+	log.Printf("http://%s/", addr)
+	err = srv.ListenAndServe()
+	if !errors.Is(err, http.ErrServerClosed) {
+		stor.Close()
+		log.Fatal(err)
+	}
+	<-done
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/spreadsheet"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// writeSpreadsheet записує звіт за місяць або період в XLSX чи ODS
+// файл. Параметр --order задає порядок точок обліку через кому.
+func writeSpreadsheet(file string, args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "--") {
+		usageAndExit()
+	}
+	output := userPath(args[0])
+	var period, site string
+	var order []string
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "--site" && i+1 < len(args):
+			i++
+			site = args[i]
+		case args[i] == "--order" && i+1 < len(args):
+			i++
+			order = strings.Split(args[i], ",")
+		case period == "" && !strings.HasPrefix(args[i], "--"):
+			period = args[i]
+		default:
+			usageAndExit()
+		}
+	}
+
+	err := writeSpreadsheetFile(file, output, period, site, order)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeSpreadsheetFile записує звіт організації site за період period в
+// файл output. БД відкривається тільки для читання.
+func writeSpreadsheetFile(file, output, period, site string,
+	order []string) error {
+	stor, err := storage.OpenReadOnly(file)
+	if err != nil {
+		return err
+	}
+	defer stor.Close()
+	if site != "" {
+		err := storage.SelectSite(stor, site)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, site)
+		}
+	}
+	from, to := parsePeriod(stor, period)
+	if from.After(to) {
+		return i18n.NewError("Невірний період, початок пізніше кінця")
+	}
+	return spreadsheet.New(stor, from, to, order).Write(output)
+}
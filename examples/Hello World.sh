@@ -5,6 +5,7 @@ echo Hello World
 echo This script name is $0
 echo Datebase name is $1
 echo Year and Month is $2
+echo Organization is $3
 echo
 read -n 1 -s -r -p "Press any key to continue"
 echo
@@ -1,14 +1,14 @@
 PRAGMA foreign_keys=ON;
 BEGIN TRANSACTION;
 --
-INSERT INTO places VALUES(1,208,NULL,'Госпдвір');
-INSERT INTO places VALUES(2,220,NULL,'АВМ');
-INSERT INTO places VALUES(3,205,NULL,'Контора');
-INSERT INTO places VALUES(4,408,NULL,'ДКУ');
-INSERT INTO places VALUES(5,55,NULL,'Госпдвір Олекс');
-INSERT INTO places VALUES(6,481,NULL,'Склад');
-INSERT INTO places VALUES(7,220,NULL,'Їдальня');
-INSERT INTO places VALUES(8,205,NULL,'Приїзжа');
+INSERT INTO places VALUES(1,1,208,NULL,'Госпдвір');
+INSERT INTO places VALUES(2,1,220,NULL,'АВМ');
+INSERT INTO places VALUES(3,1,205,NULL,'Контора');
+INSERT INTO places VALUES(4,1,408,NULL,'ДКУ');
+INSERT INTO places VALUES(5,1,55,NULL,'Госпдвір Олекс');
+INSERT INTO places VALUES(6,1,481,NULL,'Склад');
+INSERT INTO places VALUES(7,1,220,NULL,'Їдальня');
+INSERT INTO places VALUES(8,1,205,NULL,'Приїзжа');
 --
 INSERT INTO meters VALUES(1,1,0,NULL,NULL,'344848',4,40);
 INSERT INTO meters VALUES(2,2,0,NULL,NULL,'475434',4,40);
@@ -953,6 +953,6 @@ INSERT INTO readings VALUES('2020-02-01',22,1,18819,NULL);
 INSERT INTO readings VALUES('2020-02-01',12,1,373,NULL);
 INSERT INTO readings VALUES('2020-02-01',20,1,28690,NULL);
 --
-UPDATE service SET value = '2020-03-01' WHERE skey = 'next_date';
+UPDATE sites SET next_date = '2020-03-01' WHERE site_id = 1;
 --
 COMMIT;
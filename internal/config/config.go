@@ -0,0 +1,156 @@
+// Пакет config читає налаштування програми: базу даних за
+// замовчуванням, реквізити організації, каталог шаблонів звітів і
+// додаткових команд, мову і клавіші інтерфейсу. Якщо мову не задано,
+// вона визначається змінними середовища. Загальний файл
+// налаштувань знаходиться в каталозі налаштувань користувача
+// ($XDG_CONFIG_HOME/energozvit/config.json), а значення для окремої
+// бази даних можна змінити файлом з імʼям бази даних і розширенням
+// .json поруч з нею.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Config є налаштуваннями програми.
+type Config struct {
+	Database     string            `json:"database,omitempty"`  // шлях до БД
+	Organization Organization      `json:"organization"`        // реквізити
+	Templates    string            `json:"templates,omitempty"` // каталог
+	Locale       string            `json:"locale,omitempty"`    // uk, en
+	Keys         map[string]string `json:"keys,omitempty"`      // дія: клавіша
+}
+
+// Organization містить реквізити організації для заголовків звітів.
+type Organization struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// Path повертає шлях до загального файла налаштувань.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "energozvit", "config.json"), nil
+}
+
+// DatabasePath повертає шлях до файла налаштувань бази даних pathDB.
+func DatabasePath(pathDB string) string {
+	return pathDB + ".json"
+}
+
+// Load читає загальний файл налаштувань. Якщо файла немає, повертає
+// налаштування за замовчуванням.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile читає файл налаштувань path. Якщо файла немає, повертає
+// налаштування за замовчуванням.
+func LoadFile(path string) (*Config, error) {
+	c := &Config{Keys: make(map[string]string)}
+	err := c.merge(path, true)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ForDatabase доповнює налаштування файлом налаштувань бази даних
+// pathDB, якщо він є. Якщо каталог шаблонів не задано, ним стає
+// каталог бази даних.
+func (c *Config) ForDatabase(pathDB string) error {
+	err := c.merge(DatabasePath(pathDB), false)
+	if err != nil {
+		return err
+	}
+	if c.Templates == "" {
+		c.Templates, err = filepath.Abs(filepath.Dir(pathDB))
+	}
+	return err
+}
+
+// merge читає файл налаштувань path і замінює задані в ньому значення.
+// Відносні шляхи задаються відносно каталога файла. База даних за
+// замовчуванням задається тільки в загальному файлі налаштувань.
+func (c *Config) merge(path string, global bool) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var read Config
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&read)
+	if err == nil {
+		err = read.check(global)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if read.Database != "" {
+		c.Database = resolve(dir, read.Database)
+	}
+	if read.Organization.Name != "" {
+		c.Organization.Name = read.Organization.Name
+	}
+	if read.Organization.Address != "" {
+		c.Organization.Address = read.Organization.Address
+	}
+	if read.Templates != "" {
+		c.Templates = resolve(dir, read.Templates)
+	}
+	if read.Locale != "" {
+		c.Locale = read.Locale
+	}
+	for action, key := range read.Keys {
+		c.Keys[action] = key
+	}
+	return nil
+}
+
+// check перевіряє прочитані налаштування.
+func (c *Config) check(global bool) error {
+	if !global && c.Database != "" {
+		return i18n.NewError("database задається тільки в загальному " +
+			"файлі налаштувань")
+	}
+	for action, key := range c.Keys {
+		if utf8.RuneCountInString(key) != 1 {
+			return i18n.Errorf("клавіша дії %s має бути одним "+
+				"символом: %q", action, key)
+		}
+	}
+	return nil
+}
+
+// resolve повертає шлях path, заданий відносно каталога dir.
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
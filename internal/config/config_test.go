@@ -0,0 +1,121 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeFile записує файл налаштувань.
+func writeFile(t *testing.T, path, text string) {
+	err := os.WriteFile(path, []byte(text), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	config, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Keys: map[string]string{}}
+	if diff := cmp.Diff(want, config); diff != "" {
+		t.Errorf("defaults mismatch (-want +got):\n%s", diff)
+	}
+
+	err = os.Mkdir(filepath.Join(dir, "energozvit"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "energozvit", "config.json"), `{
+		"database": "db/zvit.sqlite",
+		"organization": {"name": "ПрАТ Рога і Копита",
+			"address": "м. Київ"},
+		"templates": "/srv/templates",
+		"keys": {"quit": "й"}}`)
+	config, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Config{
+		Database: filepath.Join(dir, "energozvit", "db", "zvit.sqlite"),
+		Organization: Organization{Name: "ПрАТ Рога і Копита",
+			Address: "м. Київ"},
+		Templates: "/srv/templates",
+		Keys:      map[string]string{"quit": "й"},
+	}
+	if diff := cmp.Diff(want, config); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestForDatabase(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "config.json")
+	writeFile(t, global, `{"organization": {"name": "ПрАТ Рога і Копита",
+		"address": "м. Київ"}, "keys": {"quit": "Q", "save": "w"}}`)
+	pathDB := filepath.Join(dir, "zvit.sqlite")
+
+	// без файла налаштувань бази даних
+	config, err := LoadFile(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.ForDatabase(pathDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Templates != dir {
+		t.Errorf("templates: want %s, got %s", dir, config.Templates)
+	}
+
+	writeFile(t, DatabasePath(pathDB), `{
+		"organization": {"name": "ТОВ Ромашка"},
+		"templates": "templates", "locale": "en", "keys": {"save": "S"}}`)
+	config, err = LoadFile(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.ForDatabase(pathDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{
+		Organization: Organization{Name: "ТОВ Ромашка",
+			Address: "м. Київ"},
+		Templates: filepath.Join(dir, "templates"),
+		Locale:    "en",
+		Keys:      map[string]string{"quit": "Q", "save": "S"},
+	}
+	if diff := cmp.Diff(want, config); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{`{"organisation": {}}`, "unknown field"},
+		{`{"keys": {"quit": "qq"}}`, "одним символом"},
+		{`{"keys": {"quit": ""}}`, "одним символом"},
+		{`{"database": "zvit.sqlite"}`, "загальному файлі"},
+	}
+	dir := t.TempDir()
+	pathDB := filepath.Join(dir, "zvit.sqlite")
+	for _, test := range tests {
+		writeFile(t, DatabasePath(pathDB), test.text)
+		config := &Config{Keys: make(map[string]string)}
+		err := config.ForDatabase(pathDB)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: want %q, got %v", test.text, test.err, err)
+		}
+	}
+}
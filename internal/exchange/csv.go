@@ -0,0 +1,138 @@
+// Пакет exchange переносить показники лічильників між файлами і формою
+// введення показників.
+package exchange
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Row є рядком файла з показниками лічильника.
+type Row struct {
+	Line       int    // номер рядка в файлі
+	Key        string // номер лічильника або назва точки обліку
+	Zone       int    // тарифна зона
+	Kwh        int    // показник лічильника
+	Annotation string // примітка
+}
+
+// Мітка порядку байтів, яку додають табличні редактори
+const bom = "\uFEFF"
+
+// ReadCSV читає показники з CSV файла з колонками: номер лічильника
+// (або назва точки обліку), зона, показник, примітка. Примітка
+// необовʼязкова, пуста зона означає першу зону. Роздільником може бути
+// кома або крапка з комою. Перший рядок пропускається, якщо це
+// заголовок.
+func ReadCSV(r io.Reader) ([]*Row, error) {
+	records, err := readRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*Row, 0)
+	for i, record := range records {
+		row, err := parseRecord(record.fields)
+		if err != nil {
+			// Заголовок
+			if i == 0 && record.line == 1 && isHeader(record.fields, 2) {
+				continue
+			}
+			return nil, i18n.Errorf("рядок %d: %w", record.line, err)
+		}
+		row.Line = record.line
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Запис CSV файла з номером рядка
+type record struct {
+	line   int
+	fields []string
+}
+
+// readRecords читає записи CSV файла. Мітка порядку байтів
+// пропускається, роздільник визначається за першим рядком.
+func readRecords(r io.Reader) ([]record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte(bom))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectComma(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records := make([]record, 0)
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		records = append(records, record{line, fields})
+	}
+	return records, nil
+}
+
+// detectComma визначає роздільник колонок за першим рядком.
+func detectComma(data []byte) rune {
+	first := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		first = data[:i]
+	}
+	if bytes.Count(first, []byte{';'}) > bytes.Count(first, []byte{','}) {
+		return ';'
+	}
+	return ','
+}
+
+// isHeader перевіряє чи рядок є заголовком: в колонці col, де має бути
+// число, є текст.
+func isHeader(fields []string, col int) bool {
+	if len(fields) <= col {
+		return false
+	}
+	_, err := strconv.Atoi(fields[col])
+	return err != nil
+}
+
+// parseRecord перетворює запис CSV в рядок з показником.
+func parseRecord(record []string) (*Row, error) {
+	if len(record) < 3 || len(record) > 4 {
+		return nil, i18n.NewError("очікується 3 або 4 колонки")
+	}
+	row := &Row{Key: record[0], Zone: 1}
+	if row.Key == "" {
+		return nil, i18n.NewError("не вказано лічильник")
+	}
+	if record[1] != "" {
+		zone, err := strconv.Atoi(record[1])
+		if err != nil || zone < 1 {
+			return nil, i18n.Errorf("невірна зона %q", record[1])
+		}
+		row.Zone = zone
+	}
+	kwh, err := strconv.Atoi(record[2])
+	if err != nil || kwh < 0 {
+		return nil, i18n.Errorf("невірний показник %q", record[2])
+	}
+	row.Kwh = kwh
+	if len(record) == 4 {
+		row.Annotation = record[3]
+	}
+	return row, nil
+}
@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadCSV(t *testing.T) {
+	text := "\uFEFFЛічильник;Зона;Показник;Примітка\n" +
+		"344848;1;74;Заміна пломби\n" +
+		"\n" +
+		" Контора ; ; 13800\n" +
+		"\"001930\";2;\"13750\";\"з; крапкою\"\n"
+	rows, err := ReadCSV(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Row{
+		{2, "344848", 1, 74, "Заміна пломби"},
+		{4, "Контора", 1, 13800, ""},
+		{5, "001930", 2, 13750, "з; крапкою"},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"columns", "344848,1\n"},
+		{"kwh", "344848,1,74\n344848,2,x\n"},
+		{"negative", "344848,1,-1\n"},
+		{"zone", "344848,0,74\n"},
+		{"key", ",1,74\n"},
+		{"quote", "344848,1,\"74\n"},
+	}
+	for _, test := range tests {
+		_, err := ReadCSV(strings.NewReader(test.text))
+		if err == nil {
+			t.Errorf("%s: error not found", test.name)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Формати експорту
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Роздільник колонок CSV, який очікує Excel з українськими
+// регіональними налаштуваннями.
+const DefaultComma = ';'
+
+// Options задає формат експорту.
+type Options struct {
+	Format string // FormatCSV або FormatJSON
+	Comma  rune   // роздільник колонок CSV
+}
+
+// FormatByPath повертає формат експорту за розширенням файла.
+func FormatByPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+var errBadPeriod = i18n.NewError("початок періоду пізніше кінця")
+
+// Лічильник в JSON
+type jsonMeter struct {
+	Substation int    `json:"substation"`
+	Eic        string `json:"eic"`
+	Name       string `json:"name"`
+	Model      string `json:"model"`
+	Year       int    `json:"year"`
+	Serial     string `json:"serial"`
+	Digits     int    `json:"digits"`
+	Ratio      int    `json:"ratio"`
+}
+
+// Рядок звіту в JSON
+type jsonReport struct {
+	jsonMeter
+	Zone       int    `json:"zone"`
+	CurKwh     int    `json:"cur_kwh"`
+	PreKwh     int    `json:"pre_kwh"`
+	Diff       int    `json:"diff"`
+	Energy     int    `json:"energy"`
+	Annotation string `json:"annotation"`
+}
+
+// Звіт за місяць в JSON
+type jsonMonth struct {
+	Date    string       `json:"date"`
+	Reports []jsonReport `json:"reports,omitempty"`
+	Total   int          `json:"total"`
+}
+
+// Звіти або суми за період в JSON
+type jsonPeriod struct {
+	Site   string      `json:"site"`
+	From   string      `json:"from"`
+	To     string      `json:"to"`
+	Months []jsonMonth `json:"months"`
+	Total  int         `json:"total"`
+}
+
+// Діючі лічильники в JSON
+type jsonMeters struct {
+	Site   string      `json:"site"`
+	Meters []jsonMeter `json:"meters"`
+}
+
+// Заголовки колонок CSV, перекладаються при записі функцією header
+var (
+	meterHeader = []string{"Підстанція", "EIC", "Назва", "Модель",
+		"Рік", "Номер", "Розрядність", "Коефіцієнт"}
+	reportHeader = append([]string{"Місяць"}, append(meterHeader,
+		"Зона", "Теперешні", "Попередні", "Різниця", "Всього",
+		"Примітка")...)
+	totalHeader = []string{"Місяць", "Всього"}
+)
+
+// header повертає заголовок колонок CSV мовою інтерфейсу. Мова
+// заголовка не впливає на імпорт, заголовок визначається за
+// нечисловою колонкою.
+func header(names []string) []string {
+	translated := make([]string, len(names))
+	for i, name := range names {
+		translated[i] = i18n.T(name)
+	}
+	return translated
+}
+
+// ExportReports записує звіти поточної організації за місяці з from по
+// to, разом з сумами спожитої енергії.
+func ExportReports(w io.Writer, stor storage.Store, from, to time.Time, opts Options) error {
+	if from.After(to) {
+		return errBadPeriod
+	}
+	if opts.Format == FormatJSON {
+		period := newJSONPeriod(stor, from, to)
+		for i := range period.Months {
+			month := &period.Months[i]
+			date, _ := storage.DateParse(month.Date)
+			month.Reports = make([]jsonReport, 0)
+			for _, r := range stor.GetReports(date) {
+				month.Reports = append(month.Reports,
+					newJSONReport(r))
+			}
+		}
+		return writeJSON(w, period)
+	}
+
+	records := [][]string{header(reportHeader)}
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		for _, r := range stor.GetReports(date) {
+			record := append([]string{monthString(date)},
+				meterRecord(r.Meter)...)
+			record = append(record, itoa(r.Zone), itoa(r.CurKwh),
+				itoa(r.PreKwh), itoa(r.Diff), itoa(r.Energy),
+				r.Annotation)
+			records = append(records, record)
+		}
+	}
+	return writeCSV(w, records, opts.Comma)
+}
+
+// ExportTotals записує суми спожитої енергії поточної організації по
+// місяцях з from по to та загальну суму.
+func ExportTotals(w io.Writer, stor storage.Store, from, to time.Time, opts Options) error {
+	if from.After(to) {
+		return errBadPeriod
+	}
+	period := newJSONPeriod(stor, from, to)
+	if opts.Format == FormatJSON {
+		return writeJSON(w, period)
+	}
+
+	records := [][]string{header(totalHeader)}
+	for _, month := range period.Months {
+		records = append(records,
+			[]string{month.Date, itoa(month.Total)})
+	}
+	records = append(records,
+		[]string{i18n.T("Всього"), itoa(period.Total)})
+	return writeCSV(w, records, opts.Comma)
+}
+
+// ExportMeters записує діючі лічильники поточної організації.
+func ExportMeters(w io.Writer, stor storage.Store, opts Options) error {
+	meters := stor.GetActiveMeters()
+	if opts.Format == FormatJSON {
+		data := jsonMeters{
+			Site:   stor.GetSite().Name,
+			Meters: make([]jsonMeter, 0),
+		}
+		for _, m := range meters {
+			data.Meters = append(data.Meters, newJSONMeter(m))
+		}
+		return writeJSON(w, data)
+	}
+
+	records := [][]string{header(meterHeader)}
+	for _, m := range meters {
+		records = append(records, meterRecord(m))
+	}
+	return writeCSV(w, records, opts.Comma)
+}
+
+// ExportNext записує форму введення показників reports наступного
+// звіту поточної організації з сумою спожитої енергії.
+func ExportNext(w io.Writer, stor storage.Store, reports []*storage.Report, opts Options) error {
+	date := stor.GetNextDate()
+	if opts.Format == FormatJSON {
+		month := jsonMonth{
+			Date:    monthString(date),
+			Reports: make([]jsonReport, 0),
+			Total:   stor.GetNextTotal(reports),
+		}
+		for _, r := range reports {
+			month.Reports = append(month.Reports, newJSONReport(r))
+		}
+		return writeJSON(w, month)
+	}
+
+	records := [][]string{header(reportHeader)}
+	for _, r := range reports {
+		record := append([]string{monthString(date)},
+			meterRecord(r.Meter)...)
+		record = append(record, itoa(r.Zone), itoa(r.CurKwh),
+			itoa(r.PreKwh), itoa(r.Diff), itoa(r.Energy), r.Annotation)
+		records = append(records, record)
+	}
+	return writeCSV(w, records, opts.Comma)
+}
+
+// newJSONPeriod створює період з сумами спожитої енергії по місяцях.
+func newJSONPeriod(stor storage.Store, from, to time.Time) jsonPeriod {
+	period := jsonPeriod{
+		Site:   stor.GetSite().Name,
+		From:   monthString(from),
+		To:     monthString(to),
+		Months: make([]jsonMonth, 0),
+		Total:  stor.GetTotal(from, to),
+	}
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		period.Months = append(period.Months, jsonMonth{
+			Date:  monthString(date),
+			Total: stor.GetTotal(date, date),
+		})
+	}
+	return period
+}
+
+func newJSONMeter(m *storage.Meter) jsonMeter {
+	return jsonMeter{m.Substation, m.Eic, m.Name, m.Model, m.Year,
+		m.Serial, m.Digits, m.Ratio}
+}
+
+func newJSONReport(r *storage.Report) jsonReport {
+	return jsonReport{newJSONMeter(r.Meter), r.Zone, r.CurKwh,
+		r.PreKwh, r.Diff, r.Energy, r.Annotation}
+}
+
+// meterRecord повертає колонки CSV з даними лічильника.
+func meterRecord(m *storage.Meter) []string {
+	return []string{itoa(m.Substation), m.Eic, m.Name, m.Model,
+		itoa(m.Year), m.Serial, itoa(m.Digits), itoa(m.Ratio)}
+}
+
+// writeCSV записує CSV з міткою порядку байтів, щоб Excel розпізнав
+// кодування UTF-8.
+func writeCSV(w io.Writer, records [][]string, comma rune) error {
+	if comma == 0 {
+		comma = DefaultComma
+	}
+	_, err := io.WriteString(w, bom)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	writer.UseCRLF = true
+	records[0] = translate(records[0])
+	return writer.WriteAll(records)
+}
+
+// writeJSON записує дані в JSON з відступами.
+func writeJSON(w io.Writer, data any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+// translate повертає переклад заголовків колонок.
+func translate(header []string) []string {
+	names := make([]string, len(header))
+	for i, name := range header {
+		names[i] = i18n.T(name)
+	}
+	return names
+}
+
+// monthString повертає місяць в форматі YYYY-MM.
+func monthString(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,175 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// closeMonth закриває місяць, додавши до кожного показника kwh.
+func closeMonth(t *testing.T, stor storage.Store, kwh int) {
+	reports := stor.GetNextReports()
+	for _, r := range reports {
+		r.CurKwh += kwh
+	}
+	err := stor.SaveReports(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportReportsCSV(t *testing.T) {
+	stor := createStore(t)
+	closeMonth(t, stor, 5)
+	var buf bytes.Buffer
+	date := storage.MakeDate(2022, 3)
+	err := ExportReports(&buf, stor, date, date, Options{Format: FormatCSV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bom +
+		"Місяць;Підстанція;EIC;Назва;Модель;Рік;Номер;Розрядність;" +
+		"Коефіцієнт;Зона;Теперешні;Попередні;Різниця;Всього;Примітка\r\n" +
+		"2022-03;0;;Госпдвір;;0;344848;4;40;1;69;64;5;200;\r\n" +
+		"2022-03;0;;Контора;;0;001930;5;1;1;13750;13745;5;5;\r\n" +
+		"2022-03;0;;Контора;;0;001930;5;1;2;13705;13700;5;5;\r\n" +
+		"2022-03;0;;Склад;;0;A1;4;1;1;15;10;5;5;\r\n" +
+		"2022-03;0;;Склад;;0;A2;4;1;1;25;20;5;5;\r\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportTotals(t *testing.T) {
+	stor := createStore(t)
+	closeMonth(t, stor, 5)
+	closeMonth(t, stor, 1)
+	from, to := storage.MakeDate(2022, 3), storage.MakeDate(2022, 4)
+
+	var buf bytes.Buffer
+	err := ExportTotals(&buf, stor, from, to, Options{FormatCSV, ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bom + "Місяць,Всього\r\n2022-03,220\r\n2022-04,44\r\n" +
+		"Всього,264\r\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	err = ExportTotals(&buf, stor, to, from, Options{Format: FormatCSV})
+	if err == nil {
+		t.Error("exported wrong period")
+	}
+}
+
+func TestExportTotalsEnglish(t *testing.T) {
+	if err := i18n.SetLocale(i18n.English); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { i18n.SetLocale(i18n.Ukrainian) })
+	stor := createStore(t)
+	closeMonth(t, stor, 5)
+	from := storage.MakeDate(2022, 3)
+
+	var buf bytes.Buffer
+	err := ExportTotals(&buf, stor, from, from, Options{FormatCSV, ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bom + "Month,Total\r\n2022-03,220\r\nTotal,220\r\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	stor := createStore(t)
+	closeMonth(t, stor, 5)
+	date := storage.MakeDate(2022, 3)
+
+	var buf bytes.Buffer
+	err := ExportReports(&buf, stor, date, date, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var period jsonPeriod
+	err = json.Unmarshal(buf.Bytes(), &period)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if period.Site != storage.DefaultSiteName || period.Total != 220 ||
+		len(period.Months) != 1 || len(period.Months[0].Reports) != 5 {
+		t.Errorf("wrong reports: %+v", period)
+	}
+	report := period.Months[0].Reports[0]
+	if report.Serial != "344848" || report.Energy != 200 {
+		t.Errorf("wrong report: %+v", report)
+	}
+
+	buf.Reset()
+	err = ExportMeters(&buf, stor, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meters jsonMeters
+	err = json.Unmarshal(buf.Bytes(), &meters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meters.Meters) != 4 || meters.Meters[1].Name != "Контора" {
+		t.Errorf("wrong meters: %+v", meters)
+	}
+}
+
+func TestExportNext(t *testing.T) {
+	stor := createStore(t)
+	reports := stor.GetNextReports()
+	_, errs := Apply(reports, []*Row{{Key: "344848", Zone: 1, Kwh: 69}})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	var buf bytes.Buffer
+	err := ExportNext(&buf, stor, reports, Options{Format: FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var month jsonMonth
+	err = json.Unmarshal(buf.Bytes(), &month)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if month.Date != "2022-03" || month.Total != 200 ||
+		len(month.Reports) != 5 || month.Reports[0].CurKwh != 69 ||
+		month.Reports[0].Energy != 200 {
+		t.Errorf("wrong next reports: %+v", month)
+	}
+
+	buf.Reset()
+	err = ExportNext(&buf, stor, reports[:1], Options{FormatCSV, ','})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bom +
+		"Місяць,Підстанція,EIC,Назва,Модель,Рік,Номер,Розрядність," +
+		"Коефіцієнт,Зона,Теперешні,Попередні,Різниця,Всього,Примітка\r\n" +
+		"2022-03,0,,Госпдвір,,0,344848,4,40,1,69,64,5,200,\r\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormatByPath(t *testing.T) {
+	if FormatByPath("zvit.JSON") != FormatJSON {
+		t.Error("json format not detected")
+	}
+	if FormatByPath("zvit.csv") != FormatCSV {
+		t.Error("csv format not detected")
+	}
+}
@@ -0,0 +1,192 @@
+package exchange
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// HistoryRow є рядком файла з показником лічильника за минулий місяць.
+type HistoryRow struct {
+	Line   int // номер рядка в файлі
+	Date   time.Time
+	Serial string
+	Zone   int
+	Kwh    int
+}
+
+// ReadHistoryCSV читає показники за минулі місяці з CSV файла з
+// колонками: місяць YYYY-MM, номер лічильника, зона, показник. Пуста
+// зона означає першу зону. Перший рядок пропускається, якщо це
+// заголовок.
+func ReadHistoryCSV(r io.Reader) ([]*HistoryRow, error) {
+	records, err := readRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*HistoryRow, 0)
+	for i, record := range records {
+		row, err := parseHistoryRecord(record.fields)
+		if err != nil {
+			// Заголовок
+			if i == 0 && record.line == 1 && isHeader(record.fields, 3) {
+				continue
+			}
+			return nil, i18n.Errorf("рядок %d: %w", record.line, err)
+		}
+		row.Line = record.line
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseHistoryRecord перетворює запис CSV в показник за місяць.
+func parseHistoryRecord(fields []string) (*HistoryRow, error) {
+	if len(fields) != 4 {
+		return nil, i18n.NewError("очікується 4 колонки")
+	}
+	date, err := storage.DateParse(fields[0])
+	if err != nil {
+		return nil, i18n.Errorf("невірний місяць %q", fields[0])
+	}
+	row := &HistoryRow{Date: date, Serial: fields[1], Zone: 1}
+	if row.Serial == "" {
+		return nil, i18n.NewError("не вказано лічильник")
+	}
+	if fields[2] != "" {
+		zone, err := strconv.Atoi(fields[2])
+		if err != nil || zone < 1 {
+			return nil, i18n.Errorf("невірна зона %q", fields[2])
+		}
+		row.Zone = zone
+	}
+	kwh, err := strconv.Atoi(fields[3])
+	if err != nil || kwh < 0 {
+		return nil, i18n.Errorf("невірний показник %q", fields[3])
+	}
+	row.Kwh = kwh
+	return row, nil
+}
+
+// BuildHistory збирає показники лічильників по місяцях. Лічильники
+// визначаються за номером в meters. Показники кожного лічильника мають
+// бути за місяці поспіль і за зони з першої по останню. Повертає
+// історію в порядку meters та всі знайдені помилки.
+func BuildHistory(meters []*MeterRow, rows []*HistoryRow) ([]*storage.History, []error) {
+	var errs []error
+
+	// Лічильники за номером
+	bySerial := make(map[string]int)
+	for i, meter := range meters {
+		serial := meter.Meter.Serial
+		if _, ok := bySerial[serial]; ok {
+			errs = append(errs, i18n.Errorf("лічильники, рядок %d: "+
+				"номер %s вже вказано", meter.Line, serial))
+			continue
+		}
+		bySerial[serial] = i
+	}
+
+	// Показники лічильників по місяцях і зонах
+	kwh := make([]map[time.Time]map[int]int, len(meters))
+	for _, row := range rows {
+		i, ok := bySerial[row.Serial]
+		if !ok {
+			errs = append(errs, i18n.Errorf("показники, рядок %d: "+
+				"лічильник %s не знайдено", row.Line, row.Serial))
+			continue
+		}
+		if kwh[i] == nil {
+			kwh[i] = make(map[time.Time]map[int]int)
+		}
+		if kwh[i][row.Date] == nil {
+			kwh[i][row.Date] = make(map[int]int)
+		}
+		if _, ok := kwh[i][row.Date][row.Zone]; ok {
+			errs = append(errs, i18n.Errorf("показники, рядок %d: "+
+				"%w", row.Line, ErrDuplicate))
+			continue
+		}
+		kwh[i][row.Date][row.Zone] = row.Kwh
+	}
+
+	history := make([]*storage.History, 0, len(meters))
+	for i, meter := range meters {
+		if bySerial[meter.Meter.Serial] != i {
+			continue // повторний номер
+		}
+		h, err := meterHistory(meter.Meter, kwh[i])
+		if err != nil {
+			errs = append(errs, i18n.Errorf("лічильники, рядок %d "+
+				"(%s, %s): %w", meter.Line, meter.Meter.Name,
+				meter.Meter.Serial, err))
+			continue
+		}
+		history = append(history, h)
+	}
+	return history, errs
+}
+
+// meterHistory перетворює показники лічильника по місяцях і зонах в
+// історію, перевіряючи що місяці і зони йдуть поспіль.
+func meterHistory(meter *storage.Meter, kwh map[time.Time]map[int]int) (*storage.History, error) {
+	if len(kwh) == 0 {
+		return nil, i18n.NewError("немає показників")
+	}
+	dates := make([]time.Time, 0, len(kwh))
+	for date := range kwh {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	h := &storage.History{Meter: meter, From: dates[0]}
+	last := dates[len(dates)-1]
+	for date := h.From; !date.After(last); date = date.AddDate(0, 1, 0) {
+		zones, ok := kwh[date]
+		if !ok {
+			return nil, i18n.Errorf("немає показників за %s",
+				monthString(date))
+		}
+		month := make([]int, len(zones))
+		for zone, v := range zones {
+			if zone > len(zones) {
+				return nil, i18n.Errorf("немає показників зони "+
+					"%d за %s", len(zones), monthString(date))
+			}
+			month[zone-1] = v
+		}
+		h.Kwh = append(h.Kwh, month)
+	}
+	return h, nil
+}
+
+// ImportHistory додає в поточну організацію лічильники і їх показники
+// за минулі місяці функцією AddHistory. З dryRun лічильники тільки
+// перевіряються. Повертає помилки з номерами рядків файла лічильників.
+func ImportHistory(stor storage.Store, meters []*MeterRow, history []*storage.History, dryRun bool) []error {
+	err := stor.AddHistory(history, dryRun)
+	if err == nil {
+		return nil
+	}
+	var meterErrs storage.MetersError
+	if !errors.As(err, &meterErrs) {
+		return []error{err}
+	}
+	lines := make(map[*storage.Meter]int)
+	for _, meter := range meters {
+		lines[meter.Meter] = meter.Line
+	}
+	errs := make([]error, 0, len(meterErrs))
+	for _, e := range meterErrs {
+		errs = append(errs, i18n.Errorf("лічильники, рядок %d (%s, %s): %w",
+			lines[e.Meter], e.Meter.Name, e.Meter.Serial, e.Err))
+	}
+	return errs
+}
@@ -0,0 +1,111 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+const historyMeters = ";;Склад;;;1;4;10\n;;Гараж;;;2;5;1\n"
+
+func TestReadHistoryCSV(t *testing.T) {
+	text := "Місяць,Лічильник,Зона,Показник\n" +
+		"2021-12,1,,9990\n" +
+		"2022-01,2,2,55\n"
+	rows, err := ReadHistoryCSV(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*HistoryRow{
+		{2, storage.MakeDate(2021, 12), "1", 1, 9990},
+		{3, storage.MakeDate(2022, 1), "2", 2, 55},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	for _, text := range []string{"2021-13,1,1,1\n", "2021-12,,1,1\n",
+		"2021-12,1,1\n", "2021-12,1,1,1\n2021-12,1,1,x\n"} {
+		_, err := ReadHistoryCSV(strings.NewReader(text))
+		if err == nil {
+			t.Errorf("%q: error not found", text)
+		}
+	}
+}
+
+func TestBuildHistory(t *testing.T) {
+	meters, err := ReadMetersCSV(strings.NewReader(historyMeters))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadHistoryCSV(strings.NewReader(
+		"2022-01,2,2,55\n" +
+			"2021-12,1,,9990\n" +
+			"2021-12,2,1,100\n" +
+			"2022-01,1,,5\n" +
+			"2021-12,2,2,50\n" +
+			"2022-01,2,1,110\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, errs := BuildHistory(meters, rows)
+	if len(errs) != 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := []*storage.History{
+		{Meter: meters[0].Meter, From: storage.MakeDate(2021, 12),
+			Kwh: [][]int{{9990}, {5}}},
+		{Meter: meters[1].Meter, From: storage.MakeDate(2021, 12),
+			Kwh: [][]int{{100, 50}, {110, 55}}},
+	}
+	diff := cmp.Diff(want, history, cmpopts.IgnoreUnexported(storage.Meter{}))
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Додавання в пусту організацію
+	stor := storage.NewMemory(storage.MakeDate(2023, 1))
+	errs = ImportHistory(stor, meters, history, false)
+	if len(errs) != 0 {
+		t.Fatalf("import errors: %v", errs)
+	}
+	if !stor.GetNextDate().Equal(storage.MakeDate(2022, 2)) {
+		t.Error("next date not set")
+	}
+}
+
+func TestBuildHistoryErrors(t *testing.T) {
+	meters, err := ReadMetersCSV(strings.NewReader(historyMeters +
+		";;Офіс;;;1;4;1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ReadHistoryCSV(strings.NewReader(
+		"2021-10,1,1,10\n" +
+			"2021-12,1,1,20\n" +
+			"2021-12,2,2,50\n" +
+			"2021-12,3,1,50\n" +
+			"2021-12,1,1,30\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := BuildHistory(meters, rows)
+	want := []string{
+		"лічильники, рядок 3: номер 1 вже вказано",
+		"показники, рядок 4: лічильник 3 не знайдено",
+		"показники, рядок 5: " + ErrDuplicate.Error(),
+		"лічильники, рядок 1 (Склад, 1): немає показників за 2021-11",
+		"лічильники, рядок 2 (Гараж, 2): немає показників зони 1 " +
+			"за 2021-12",
+	}
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Error())
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
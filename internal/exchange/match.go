@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"math"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+var (
+	ErrUnknown   = i18n.NewError("лічильник не знайдено")
+	ErrAmbiguous = i18n.NewError("лічильник визначено неоднозначно")
+	ErrZone      = i18n.NewError("лічильник не має такої зони")
+	ErrDuplicate = i18n.NewError("показник вже вказано в іншому рядку")
+	ErrTooBig    = i18n.NewError("показник перевищує розрядність лічильника")
+)
+
+// RowError описує рядок файла, який не вдалось занести в форму.
+type RowError struct {
+	Row *Row
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return i18n.Sprintf("рядок %d (%s, зона %d): %s",
+		e.Row.Line, e.Row.Key, e.Row.Zone, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// MatchReport шукає в формі введення показників рядок для row. Спочатку
+// шукається лічильник за номером, потім за назвою точки обліку. Назва
+// підходить тільки якщо в точці обліку один лічильник.
+func MatchReport(reports []*storage.Report, row *Row) (*storage.Report, error) {
+	bySerial := func(r *storage.Report) bool {
+		return r.Serial == row.Key
+	}
+	byName := func(r *storage.Report) bool {
+		return strings.EqualFold(r.Name, row.Key)
+	}
+	for _, match := range []func(*storage.Report) bool{bySerial, byName} {
+		var found *storage.Report
+		meters := 0
+		for _, report := range reports {
+			if !match(report) {
+				continue
+			}
+			if report.Zone == row.Zone {
+				found = report
+			}
+			if report.Zone == 1 {
+				meters++
+			}
+		}
+		switch {
+		case meters > 1:
+			return nil, ErrAmbiguous
+		case found != nil:
+			return found, nil
+		case meters == 1:
+			return nil, ErrZone
+		}
+	}
+	return nil, ErrUnknown
+}
+
+// Apply заносить показники з рядків файла в форму введення показників і
+// обчислює спожиту енергію. Повертає змінені рядки форми та рядки
+// файла, які не вдалось занести.
+func Apply(reports []*storage.Report, rows []*Row) ([]*storage.Report, []*RowError) {
+	changed := make([]*storage.Report, 0)
+	errs := make([]*RowError, 0)
+	used := make(map[*storage.Report]bool)
+	for _, row := range rows {
+		report, err := MatchReport(reports, row)
+		switch {
+		case err != nil:
+		case used[report]:
+			err = ErrDuplicate
+		case row.Kwh >= int(math.Pow10(report.Digits)):
+			err = ErrTooBig
+		}
+		if err != nil {
+			errs = append(errs, &RowError{row, err})
+			continue
+		}
+		used[report] = true
+		report.CurKwh = row.Kwh
+		if row.Annotation != "" {
+			report.Annotation = row.Annotation
+		}
+		report.Calculate()
+		changed = append(changed, report)
+	}
+	return changed, errs
+}
@@ -0,0 +1,106 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// createStore створює сховище з лічильниками: Госпдвір (одна зона),
+// Контора (дві зони) та Склад (два лічильники).
+func createStore(t *testing.T) storage.Store {
+	stor := storage.NewMemory(storage.MakeDate(2022, 3))
+	meters := []struct {
+		meter *storage.Meter
+		kwh   []int
+	}{
+		{&storage.Meter{Name: "Госпдвір", Serial: "344848",
+			Digits: 4, Ratio: 40}, []int{64}},
+		{&storage.Meter{Name: "Контора", Serial: "001930",
+			Digits: 5, Ratio: 1}, []int{13745, 13700}},
+		{&storage.Meter{Name: "Склад", Serial: "A1",
+			Digits: 4, Ratio: 1}, []int{10}},
+		{&storage.Meter{Name: "Склад", Serial: "A2",
+			Digits: 4, Ratio: 1}, []int{20}},
+	}
+	for _, m := range meters {
+		err := stor.AddMeter(m.meter, m.kwh)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return stor
+}
+
+func TestMatchReport(t *testing.T) {
+	reports := createStore(t).GetNextReports()
+	tests := []struct {
+		key    string
+		zone   int
+		serial string
+		err    error
+	}{
+		{"344848", 1, "344848", nil},
+		{"госпдвір", 1, "344848", nil},
+		{"Контора", 2, "001930", nil},
+		{"A2", 1, "A2", nil},
+		{"Склад", 1, "", ErrAmbiguous},
+		{"344848", 2, "", ErrZone},
+		{"999", 1, "", ErrUnknown},
+	}
+	for _, test := range tests {
+		row := &Row{Key: test.key, Zone: test.zone}
+		report, err := MatchReport(reports, row)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s/%d: error want %v, got %v",
+				test.key, test.zone, test.err, err)
+			continue
+		}
+		if err == nil && (report.Serial != test.serial ||
+			report.Zone != test.zone) {
+			t.Errorf("%s/%d: matched %s/%d", test.key, test.zone,
+				report.Serial, report.Zone)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	stor := createStore(t)
+	reports := stor.GetNextReports()
+	rows := []*Row{
+		{1, "344848", 1, 74, "Примітка"},
+		{2, "Госпдвір", 1, 75, ""},
+		{3, "Контора", 2, 13710, ""},
+		{4, "A1", 1, 10000, ""},
+		{5, "Склад", 1, 30, ""},
+	}
+	changed, errs := Apply(reports, rows)
+	if len(changed) != 2 {
+		t.Fatalf("changed want 2, got %d", len(changed))
+	}
+	if changed[0].Energy != 400 || changed[0].Annotation != "Примітка" {
+		t.Errorf("wrong report: %+v", changed[0])
+	}
+	if changed[1].Energy != 10 {
+		t.Errorf("wrong report: %+v", changed[1])
+	}
+	wantErrs := []error{ErrDuplicate, ErrTooBig, ErrAmbiguous}
+	if len(errs) != len(wantErrs) {
+		t.Fatalf("errors want %d, got %d", len(wantErrs), len(errs))
+	}
+	for i, err := range errs {
+		if !errors.Is(err, wantErrs[i]) {
+			t.Errorf("error want %v, got %v", wantErrs[i], err)
+		}
+	}
+
+	// Занесені показники зберігаються без закриття місяця
+	err := stor.SaveDrafts(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stor.GetNextTotal(stor.GetNextReports()) != 410 {
+		t.Error("drafts not saved")
+	}
+}
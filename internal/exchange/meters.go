@@ -0,0 +1,120 @@
+package exchange
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// MeterRow є рядком файла з лічильником і його початковими показниками.
+type MeterRow struct {
+	Line  int // номер рядка в файлі
+	Meter *storage.Meter
+	Kwh   []int // початкові показники по тарифних зонах
+}
+
+// ReadMetersCSV читає лічильники з CSV файла з колонками як у
+// ExportMeters: підстанція, EIC, назва точки обліку, модель, рік, номер,
+// розрядність, коефіцієнт, і далі початкові показники по зонах.
+// Підстанція і рік можуть бути пустими, показники не потрібні для
+// BuildHistory. Перший рядок пропускається,
+// якщо це заголовок.
+func ReadMetersCSV(r io.Reader) ([]*MeterRow, error) {
+	records, err := readRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*MeterRow, 0)
+	for i, record := range records {
+		row, err := parseMeterRecord(record.fields)
+		if err != nil {
+			// Заголовок
+			if i == 0 && record.line == 1 && isHeader(record.fields, 6) {
+				continue
+			}
+			return nil, i18n.Errorf("рядок %d: %w", record.line, err)
+		}
+		row.Line = record.line
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseMeterRecord перетворює запис CSV в лічильник.
+func parseMeterRecord(fields []string) (*MeterRow, error) {
+	if len(fields) < len(meterHeader) {
+		return nil, i18n.Errorf("очікується не менше %d колонок",
+			len(meterHeader))
+	}
+	meter := &storage.Meter{
+		Eic:    fields[1],
+		Name:   fields[2],
+		Model:  fields[3],
+		Serial: fields[5],
+	}
+	numbers := []struct {
+		name  string
+		value *int
+		field string
+		empty bool // дозволено пусте значення
+	}{
+		{"підстанція", &meter.Substation, fields[0], true},
+		{"рік", &meter.Year, fields[4], true},
+		{"розрядність", &meter.Digits, fields[6], false},
+		{"коефіцієнт", &meter.Ratio, fields[7], false},
+	}
+	for _, n := range numbers {
+		if n.empty && n.field == "" {
+			continue
+		}
+		v, err := strconv.Atoi(n.field)
+		if err != nil {
+			return nil, i18n.Errorf("невірне значення %s %q",
+				i18n.T(n.name), n.field)
+		}
+		*n.value = v
+	}
+
+	row := &MeterRow{Meter: meter}
+	for _, field := range fields[len(meterHeader):] {
+		if field == "" {
+			continue
+		}
+		kwh, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, i18n.Errorf("невірний показник %q", field)
+		}
+		row.Kwh = append(row.Kwh, kwh)
+	}
+	return row, nil
+}
+
+// ImportMeters додає лічильники в поточну організацію в одній
+// транзакції функцією AddMeters. З dryRun лічильники тільки
+// перевіряються. Повертає помилки з номерами рядків файла.
+func ImportMeters(stor storage.Store, rows []*MeterRow, dryRun bool) []error {
+	meters := make([]*storage.Meter, len(rows))
+	kwh := make([][]int, len(rows))
+	for i, row := range rows {
+		meters[i] = row.Meter
+		kwh[i] = row.Kwh
+	}
+	err := stor.AddMeters(meters, kwh, dryRun)
+	if err == nil {
+		return nil
+	}
+	var meterErrs storage.MetersError
+	if !errors.As(err, &meterErrs) {
+		return []error{err}
+	}
+	errs := make([]error, 0, len(meterErrs))
+	for _, e := range meterErrs {
+		row := rows[e.Index]
+		errs = append(errs, i18n.Errorf("рядок %d (%s, %s): %w",
+			row.Line, row.Meter.Name, row.Meter.Serial, e.Err))
+	}
+	return errs
+}
@@ -0,0 +1,79 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+func TestReadMetersCSV(t *testing.T) {
+	text := "Підстанція;EIC;Назва;Модель;Рік;Номер;Розрядність;" +
+		"Коефіцієнт;Зона 1;Зона 2\n" +
+		"208;1234567890abcdef;Госпдвір;НІК2301АП1;2020;344848;4;40;64\n" +
+		";;Контора;;;001930;5;1;13745;13700\n"
+	rows, err := ReadMetersCSV(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*MeterRow{
+		{2, &storage.Meter{Substation: 208, Eic: "1234567890abcdef",
+			Name: "Госпдвір", Model: "НІК2301АП1", Year: 2020,
+			Serial: "344848", Digits: 4, Ratio: 40}, []int{64}},
+		{3, &storage.Meter{Name: "Контора", Serial: "001930",
+			Digits: 5, Ratio: 1}, []int{13745, 13700}},
+	}
+	diff := cmp.Diff(want, rows, cmpopts.IgnoreUnexported(storage.Meter{}))
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []string{
+		";;Контора;;;001930;5\n",
+		";;Контора;;;001930;x;1;1\n",
+		";;Контора;;;001930;5;1;x\n",
+	}
+	for _, text := range bad {
+		// Перший рядок з помилкою вважається заголовком
+		text = ";;Госпдвір;;;344848;4;40;64\n" + text
+		_, err := ReadMetersCSV(strings.NewReader(text))
+		if err == nil {
+			t.Errorf("%q: error not found", text)
+		}
+	}
+}
+
+func TestImportMeters(t *testing.T) {
+	text := ";;Склад;;;1;4;1;10\n" +
+		";;Склад;;;2;9;1;20\n" +
+		";;Гараж;;;3;4;1;30\n"
+	rows, err := ReadMetersCSV(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stor := storage.NewMemory(storage.MakeDate(2022, 3))
+	errs := ImportMeters(stor, rows, true)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "рядок 2 ") {
+		t.Fatalf("want error in line 2, got %v", errs)
+	}
+
+	// Без помилкового рядка
+	rows = append(rows[:1], rows[2])
+	errs = ImportMeters(stor, rows, true)
+	if len(errs) != 0 {
+		t.Fatalf("dry run errors: %v", errs)
+	}
+	if n := len(stor.GetActiveMeters()); n != 0 {
+		t.Errorf("dry run added %d meters", n)
+	}
+	errs = ImportMeters(stor, rows, false)
+	if len(errs) != 0 {
+		t.Fatalf("import errors: %v", errs)
+	}
+	if n := len(stor.GetActiveMeters()); n != 2 {
+		t.Errorf("active meters want 2, got %d", n)
+	}
+}
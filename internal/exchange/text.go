@@ -0,0 +1,90 @@
+package exchange
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// LineError описує рядок тексту, який не вдалось прочитати.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return i18n.Sprintf("рядок %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LinesError містить помилки всіх рядків, які не вдалось прочитати
+// функцією ReadText.
+type LinesError []*LineError
+
+func (e LinesError) Error() string {
+	return i18n.Sprintf("не прочитано рядків: %d", len(e))
+}
+
+// ReadText читає показники з тексту, рядки якого мають поля через
+// пробіли: номер лічильника, зона, показник і необовʼязкова примітка до
+// кінця рядка. Пусті рядки і рядки, які починаються з '#', пропускаються.
+// Якщо хоч один рядок не прочитано, то показники не повертаються, а
+// повертається LinesError з помилками всіх рядків.
+func ReadText(r io.Reader) ([]*Row, error) {
+	rows := make([]*Row, 0)
+	var errs LinesError
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), bom))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields, annotation := cutFields(text, 3)
+		if len(fields) < 3 {
+			errs = append(errs, &LineError{line, i18n.NewError(
+				"очікується номер, зона і показник")})
+			continue
+		}
+		if annotation != "" {
+			fields = append(fields, annotation)
+		}
+		row, err := parseRecord(fields)
+		if err != nil {
+			errs = append(errs, &LineError{line, err})
+			continue
+		}
+		row.Line = line
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return rows, nil
+}
+
+// cutFields повертає до n перших полів рядка і решту рядка.
+func cutFields(text string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	for len(fields) < n {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		if text == "" {
+			break
+		}
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			end = len(text)
+		}
+		fields = append(fields, text[:end])
+		text = text[end:]
+	}
+	return fields, strings.TrimSpace(text)
+}
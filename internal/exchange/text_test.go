@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadText(t *testing.T) {
+	text := "# Показники за березень\n" +
+		"344848 1 74  Заміна пломби, лічильник  справний\n" +
+		"\n" +
+		"\t001930\t1\t13750\n" +
+		"001930 2 13705 \n"
+	rows, err := ReadText(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Row{
+		{2, "344848", 1, 74, "Заміна пломби, лічильник  справний"},
+		{4, "001930", 1, 13750, ""},
+		{5, "001930", 2, 13705, ""},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadTextErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		errs []string
+	}{
+		{"344848 1\n", []string{"рядок 1: очікується номер"}},
+		{"344848 1 74\n\n344848 2 x\n",
+			[]string{"рядок 3: невірний показник"}},
+		{"344848 1 -1\n", []string{"рядок 1: невірний показник"}},
+		{"# 1\n344848 0 74\n", []string{"рядок 2: невірна зона"}},
+		{"344848 1 x\n001930 1 13750\n001930\n# 3\n001930 0 1\n",
+			[]string{"рядок 1: невірний показник",
+				"рядок 3: очікується номер", "рядок 5: невірна зона"}},
+	}
+	for _, test := range tests {
+		rows, err := ReadText(strings.NewReader(test.text))
+		var errs LinesError
+		if !errors.As(err, &errs) || rows != nil {
+			t.Errorf("%q: want LinesError, got %v", test.text, err)
+			continue
+		}
+		if len(errs) != len(test.errs) {
+			t.Errorf("%q: want %d errors, got %v", test.text,
+				len(test.errs), errs)
+			continue
+		}
+		for i, e := range errs {
+			if !strings.HasPrefix(e.Error(), test.errs[i]) {
+				t.Errorf("%q: want %q, got %q", test.text,
+					test.errs[i], e)
+			}
+		}
+	}
+}
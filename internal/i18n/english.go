@@ -0,0 +1,292 @@
+package i18n
+
+// Англійський каталог перекладів
+var english = map[string]string{
+	// інтерфейс
+	"Нові дані":                "New data",
+	"Звіт":                     "Report",
+	"Лічильники":               "Meters",
+	"Період":                   "Period",
+	"Організація":              "Organization",
+	"Вихід":                    "Quit",
+	"Тільки читання":           "Read only",
+	"НЕ ЗБЕРЕЖЕНО":             "NOT SAVED",
+	"порівняння":               "comparison",
+	"Назва":                    "Name",
+	"Номер":                    "Serial",
+	"Зона":                     "Zone",
+	"Теперешні":                "Current",
+	"Попередні":                "Previous",
+	"Різниця":                  "Difference",
+	"Всього":                   "Total",
+	"Примітка":                 "Note",
+	"Місяць":                   "Month",
+	"Рік":                      "Year",
+	"Модель":                   "Model",
+	"КТП":                      "Subst.",
+	"Розряди":                  "Digits",
+	"Множник":                  "Ratio",
+	"Попер. місяць":            "Prev. month",
+	"Минулий рік":              "Last year",
+	"Зберегти":                 "Save",
+	"Відміна":                  "Cancel",
+	"Імпорт CSV":               "Import CSV",
+	"Оптичний порт":            "Optical port",
+	"Ліміти":                   "Budgets",
+	"Додати":                   "Add",
+	"Видалити":                 "Remove",
+	"Редагувати":               "Edit",
+	"Імпорт":                   "Import",
+	"Експорт":                  "Export",
+	"Квартал":                  "Quarter",
+	"Останній звіт":            "Last report",
+	"Додатково":                "Additional",
+	"Порівняння":               "Compare",
+	"Додаткові команди":        "Additional commands",
+	"%d рік":                   "year %d",
+	"%d, %s квартал":           "%d, quarter %s",
+	"Лімітів нема":             "No budgets",
+	"місяць":                   "month",
+	"рік":                      "year",
+	"ПЕРЕВИЩЕНО":               "EXCEEDED",
+	"%s, %s: %d з %d (%.0f%%)": "%s, %s: %d of %d (%.0f%%)",
+	"Експорт в CSV або JSON":   "Export to CSV or JSON",
+	"Файл (.csv, .json)":       "File (.csv, .json)",
+	"Збережено в файл %s":      "Saved to file %s",
+	"Файл":                     "File",
+	"Імпорт показників з CSV":  "Import readings from CSV",
+	"Імпорт лічильників з CSV":                        "Import meters from CSV",
+	"Показники з оптичного порту":                     "Readings from the optical port",
+	"Занесено показників: %d. Не занесено рядків: %d": "Readings entered: %d. Rows not entered: %d",
+	"Помилок: %d, лічильники не додано":               "Errors: %d, meters not added",
+	"Буде додано лічильників: %d":                     "Meters to be added: %d",
+	"Буде видалено лічильник %s № %s":                 "Meter %s No. %s will be removed",
+	"Додавання лічильника":                            "Add meter",
+	"Редагування %s":                                  "Edit %s",
+	"Номер підстанції":                                "Substation number",
+	"EIC Код":                                         "EIC code",
+	"Назва точки обліку":                              "Metering point name",
+	"Модель лічильника":                               "Meter model",
+	"Рік лічильника":                                  "Meter year",
+	"Серійний номер":                                  "Serial number",
+	"Значучі розряди":                                 "Significant digits",
+	"Коефіцієнт тр-ції":                               "Transformer ratio",
+	"Показники, через пробіл":                         "Readings, space separated",
+	"Показник лічильника":                             "Meter reading",
+	"База даних відкрита тільки для читання":          "The database is open read only",
+	"Не збережені дані в панелі \"%s\"":               "Unsaved data in panel \"%s\"",
+	"невідома дія %s, можливі дії: %s":                "unknown action %s, available actions: %s",
+	"клавіша дії %s зайнята: %q":                      "key of action %s is reserved: %q",
+	"дії %s і %s мають однакову клавішу %q":           "actions %s and %s have the same key %q",
+
+	// назви місяців
+	"січень":   "January",
+	"лютий":    "February",
+	"березень": "March",
+	"квітень":  "April",
+	"травень":  "May",
+	"червень":  "June",
+	"липень":   "July",
+	"серпень":  "August",
+	"вересень": "September",
+	"жовтень":  "October",
+	"листопад": "November",
+	"грудень":  "December",
+
+	// командний рядок
+	"Програма EnergoZvit": "EnergoZvit program",
+	"Версія: %s\n":        "Version: %s\n",
+	"Використання:":       "Usage:",
+	"db_file можна не вказувати, якщо базу даних задано в %s\n":                                   "db_file can be omitted if the database is set in %s\n",
+	"Коди завершення: 0 успіх, 1 помилка, 2 параметри, 3 не знайдено, 4 відхилено, 5 заблоковано": "Exit codes: 0 ok, 1 error, 2 usage, 3 not found, 4 rejected, 5 locked",
+	"Відкрити тільки для читання? [y/N] ":                                                         "Open read only? [y/N] ",
+	"Невірний формат дати, очікується YYYY-MM":                                                    "Bad date format, expect YYYY-MM",
+	"Невірна кількість резервних копій":                                                           "Bad number of backups",
+	"Невірний ліміт, очікується кВт·год або remove":                                               "Bad budget, expect kWh or remove",
+	"Невірний роздільник, очікується один символ":                                                 "Bad delimiter, expect one character",
+	"Невірний період, початок пізніше кінця":                                                      "Bad period, start after end",
+	"Назва\tДата\tЛіміт":                                                                          "Name\tDate\tBudget",
+	"з %d-%02d":                                                                                   "since %d-%02d",
+	"\nВикористання за %d-%02d\n\n":                                                               "\nUsage for %d-%02d\n\n",
+	"Назва\tПеріод\tЕнергія\tЛіміт\t%\t":                                                          "Name\tPeriod\tEnergy\tBudget\t%\t",
+	"перевищено":                   "exceeded",
+	"\nВиправлено проблем: %d\n":   "\nProblems repaired: %d\n",
+	"Проблем не знайдено":          "No problems found",
+	"Знайдено проблем: %d\n\n":     "Problems found: %d\n\n",
+	" (можна виправити: --repair)": " (repairable: --repair)",
+	"невірне значення %s: %q":      "invalid %s: %q",
+	"розрядності":                  "digits",
+	"коефіцієнта":                  "ratio",
+	"року":                         "year",
+	"підстанції":                   "substation",
+	"показника":                    "reading",
+	"зони":                         "zone",
+	"невірний місяць %q, очікується YYYY-MM":            "invalid month %q, expect YYYY-MM",
+	"звіт за %s не знайдено":                            "report for %s not found",
+	"%s, зона %d: %w":                                   "%s, zone %d: %w",
+	"не занесено рядків: %d, нічого не збережено":       "rows not entered: %d, nothing saved",
+	"не прочитано рядків: %d, нічого не збережено":      "lines not read: %d, nothing saved",
+	"не введено показників: %d":                         "readings not entered: %d",
+	"не введено показників: %d, місяць не закрито":      "readings not entered: %d, month not closed",
+	"%s: закрито %s, наступний звіт %s\n":               "%s: closed %s, next report %s\n",
+	"Місяць %s закрито\n":                               "Month %s closed\n",
+	"%d\t%s\t%d\t%d\tпомилка: %s\n":                     "%d\t%s\t%d\t%d\terror: %s\n",
+	"\nВсього: %d\n":                                    "\nTotal: %d\n",
+	"\nВсього по формі: %d\n":                           "\nForm total: %d\n",
+	"\nВсього по формі: %d\nЗбережено показників: %d\n": "\nForm total: %d\nReadings saved: %d\n",
+	"Всього\t%d\t\n":                                    "Total\t%d\t\n",
+	"Місяць\tВсього\t":                                  "Month\tTotal\t",
+	"Назва\tНомер\tРозрядність\tКоефіцієнт\tEIC\tМодель\tРік\tПідстанція":     "Name\tSerial\tDigits\tRatio\tEIC\tModel\tYear\tSubstation",
+	"Назва\tНомер\tЗона\tТеперешні\tПопередні\tРізниця\tВсього\tПримітка":     "Name\tSerial\tZone\tCurrent\tPrevious\tDifference\tTotal\tNote",
+	"Рядок\tНомер\tЗона\tТеперешні\tПопередні\tРізниця\tВсього\tПримітка":     "Row\tSerial\tZone\tCurrent\tPrevious\tDifference\tTotal\tNote",
+	"Перевірено лічильників: %d, показників: %d, помилок нема\n":              "Meters checked: %d, readings: %d, no errors\n",
+	"Додано лічильників: %d, показників: %d. Дата наступного звіту %d-%02d\n": "Meters added: %d, readings: %d. Next report date %d-%02d\n",
+	"\nНе занесено рядків: %d\n":                    "\nRows not entered: %d\n",
+	"\nЗбережено показників: %d\n":                  "\nReadings saved: %d\n",
+	"%s, %d-%02d, занесено показників: %d з %d\n\n": "%s, %d-%02d, readings entered: %d of %d\n\n",
+	"Помилок: %d, лічильники не додано\n":           "Errors: %d, meters not added\n",
+	"Перевірено лічильників: %d, помилок нема\n":    "Meters checked: %d, no errors\n",
+	"Додано лічильників: %d\n":                      "Meters added: %d\n",
+	"Симулятор Modbus TCP, лічильників: %d":         "Modbus TCP simulator, meters: %d",
+	"\nНе опитано лічильників: %d\n":                "\nMeters not polled: %d\n",
+
+	// налаштування
+	"невідома мова: %s":                                        "unknown language: %s",
+	"клавіша дії %s має бути одним символом: %q":               "key of action %s must be one character: %q",
+	"database задається тільки в загальному файлі налаштувань": "database can only be set in the global configuration file",
+
+	// обмін даними
+	"Підстанція":                                    "Substation",
+	"Розрядність":                                   "Digits",
+	"Коефіцієнт":                                    "Ratio",
+	"підстанція":                                    "substation",
+	"розрядність":                                   "digits",
+	"коефіцієнт":                                    "ratio",
+	"очікується 3 або 4 колонки":                    "expect 3 or 4 columns",
+	"очікується 4 колонки":                          "expect 4 columns",
+	"очікується не менше %d колонок":                "expect at least %d columns",
+	"невірне значення %s %q":                        "invalid %s %q",
+	"невірний показник %q":                          "invalid reading %q",
+	"невірна зона %q":                               "invalid zone %q",
+	"невірний місяць %q":                            "invalid month %q",
+	"не вказано лічильник":                          "meter not specified",
+	"рядок %d (%s, %s): %w":                         "row %d (%s, %s): %w",
+	"рядок %d (%s, зона %d): %s":                    "row %d (%s, zone %d): %s",
+	"рядок %d: %s":                                  "line %d: %s",
+	"очікується номер, зона і показник":             "expect serial, zone and reading",
+	"не прочитано рядків: %d":                       "lines not read: %d",
+	"лічильники, рядок %d (%s, %s): %w":             "meters, row %d (%s, %s): %w",
+	"лічильники, рядок %d: номер %s вже вказано":    "meters, row %d: serial %s already given",
+	"показники, рядок %d: %w":                       "readings, row %d: %w",
+	"показники, рядок %d: лічильник %s не знайдено": "readings, row %d: meter %s not found",
+	"немає показників зони %d за %s":                "no zone %d readings for %s",
+	"лічильник не має такої зони":                   "the meter has no such zone",
+	"лічильник визначено неоднозначно":              "the meter is ambiguous",
+	"показник вже вказано в іншому рядку":           "the reading is already given in another row",
+	"показник перевищує розрядність лічильника":     "the reading exceeds the meter digits",
+
+	// лічильники з оптичним портом і Modbus
+	"лічильник не надіслав ідентифікатор": "the meter did not send an identification",
+	"лічильник не підтримує режим C: %q":  "the meter does not support mode C: %q",
+	"лічильник не відповідає":             "the meter does not respond",
+	"лічильник не передав номер":          "the meter did not send its serial",
+	"немає показників енергії 1.8.x":      "no 1.8.x energy readings",
+	"невірний набір даних %q":             "invalid data set %q",
+	"невірна контрольна сума блоку даних": "invalid data block checksum",
+	"невірне значення %s(%s)":             "invalid value %s(%s)",
+	"невідома одиниця %s(%s*%s)":          "unknown unit %s(%s*%s)",
+	"рядок %d: %w": "line %d: %w",
+	"блок даних не завершено символом '!'":          "the data block does not end with '!'",
+	"функція не підтримується":                      "function not supported",
+	"невірна адреса регістра":                       "invalid register address",
+	"невірне значення":                              "invalid value",
+	"пристрій не відповідає":                        "the device does not respond",
+	"помилка пристрою":                              "device failure",
+	"невірна відповідь пристрою":                    "invalid device response",
+	"modbus: %s (функція %d, код %d)":               "modbus: %s (function %d, code %d)",
+	"modbus: невірна кількість регістрів %d":        "modbus: invalid register count %d",
+	"симулятор не запущено":                         "the simulator is not running",
+	"не задано жодного лічильника":                  "no meters configured",
+	"не задано номер лічильника":                    "meter serial not set",
+	"потрібно задати address або device":            "address or device must be set",
+	"не задано регістри":                            "registers not set",
+	"невірна зона %d":                               "invalid zone %d",
+	"зона %d задана двічі":                          "zone %d is set twice",
+	"невірна функція %d":                            "invalid function %d",
+	"невідомий тип %q":                              "unknown type %q",
+	"відʼємний множник":                             "negative scale",
+	"невірне значення регістра %d":                  "invalid value of register %d",
+	"лічильник %d (%s): %w":                         "meter %d (%s): %w",
+	"лічильник %s (%s, пристрій %d): %s":            "meter %s (%s, unit %d): %s",
+	"швидкість порту не підтримується":              "baud rate not supported",
+	"формат символу не підтримується":               "character format not supported",
+	"послідовний порт підтримується тільки в Linux": "the serial port is only supported on Linux",
+
+	// сервер
+	"не знайдено":                            "not found",
+	"метод не підтримується":                 "method not supported",
+	"база даних відкрита тільки для читання": "the database is open read only",
+	"дата має бути в форматі YYYY-MM":        "the date must be in YYYY-MM format",
+	"початок періоду пізніше кінця":          "the period starts after it ends",
+	"неправильний запит":                     "bad request",
+	"не вдалось зберегти показників: %d":     "failed to save readings: %d",
+
+	// захист від запитів з інших сайтів
+	"запит має бути в форматі application/json": "the request must be application/json",
+	"запит з іншого сайту":                      "cross-site request",
+
+	// таблиці
+	"невідомий формат таблиці, очікується .xlsx або .ods":   "unknown spreadsheet format, expect .xlsx or .ods",
+	"книга не містить аркушів":                              "the workbook has no sheets",
+	"%s: звіт про використану електроенергію за %s %d року": "%s: electricity usage report for %s %d",
+	"№ п/п":              "No.",
+	"№ КТП":              "Subst. No.",
+	"Місце встановлення": "Location",
+	"№ лічильника":       "Meter No.",
+	"Коеф. тр-ції":       "Transf. ratio",
+	"Всього, кВт·год":    "Total, kWh",
+
+	// база даних
+	"база даних вже існує":                                        "the database already exists",
+	"база даних відкрита для запису":                              "the database is open for writing",
+	"не підтримувана версія бази даних":                           "unsupported database version",
+	"оновлення до версії %d: %w":                                  "upgrade to version %d: %w",
+	"файл резервної копії вже існує":                              "the backup file already exists",
+	"резервна копія: %w":                                          "backup: %w",
+	"резервна копія поточної бази даних: %w":                      "backup of the current database: %w",
+	"пошкоджена база даних: %s":                                   "corrupted database: %s",
+	"не SQLite зʼєднання":                                         "not an SQLite connection",
+	"організацію не знайдено":                                     "organization not found",
+	"лічильник не знайдено":                                       "meter not found",
+	"точку обліку не знайдено":                                    "metering point not found",
+	"запити не підтримуються":                                     "queries are not supported",
+	"Функціонал поки не реалізований":                             "Not implemented yet",
+	"кількість лічильників і показників різна":                    "the number of meters and readings differs",
+	"Не вказано початкові показники":                              "Initial readings not given",
+	"лічильник %d (%s, %s): %s":                                   "meter %d (%s, %s): %s",
+	"не вдалось додати лічильників: %d":                           "failed to add meters: %d",
+	"організація вже має лічильники":                              "the organization already has meters",
+	"немає показників":                                            "no readings",
+	"немає показників за %s":                                      "no readings for %s",
+	"не вказано показники зон":                                    "zone readings not given",
+	"кількість зон змінилась з %d на %d в %s":                     "the number of zones changed from %d to %d in %s",
+	"показник %d більший за розрядність лічильника в %s, зона %d": "reading %d exceeds the meter digits in %s, zone %d",
+	"неправдоподібний перехід через нуль в %s, зона %d: %d → %d":  "implausible rollover in %s, zone %d: %d → %d",
+	"невідомий власник":                                           "unknown owner",
+	"%s@%s, процес %d":                                            "%s@%s, process %d",
+	", з %s":                                                      ", since %s",
+	"немає звіту":                                                 "no report",
+	"заміна лічильника":                                           "meter replaced",
+	"перехід через нуль":                                          "rollover",
+	"нульове споживання":                                          "zero consumption",
+	"примітка":                                                    "note",
+	"Лічильник %s (%s)":                                           "Meter %s (%s)",
+	"Лічильник %s (%s): немає показників за %s":                   "Meter %s (%s): no readings for %s",
+	"%s: немає показників за %s":                                  "%s: no readings for %s",
+	"%s: кількість тарифних зон змінилась з %d на %d в %s":        "%s: the number of tariff zones changed from %d to %d in %s",
+	"Точка обліку %s (%s) не має лічильників":                     "Metering point %s (%s) has no meters",
+	"Точка обліку %s (%s) не має лічильників, але має ліміти":     "Metering point %s (%s) has no meters, but has budgets",
+	"таблиця %s, рядок %s посилається на відсутній запис в %s":    "table %s, row %s references a missing record in %s",
+	"%s: є показники за %s, після дати наступного звіту %s":       "%s: there are readings for %s, after the next report date %s",
+	"%s: дата наступного звіту %s, але останні показники за %s. Потрібна дата %s": "%s: next report date is %s, but the last readings are for %s. The date must be %s",
+}
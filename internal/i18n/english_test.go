@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Корінь модуля відносно каталога пакета
+const moduleRoot = "../.."
+
+// Каталоги, повідомлення яких не перекладаються: вебсторінки і шаблони
+// звітів українською.
+var skipDirs = map[string]bool{
+	"cmd/energozvit-tmpl": true,
+	"internal/web":        true,
+}
+
+// Рядки з кирилицею, які не є повідомленнями: назва організації в БД і
+// відповіді користувача.
+var notMessages = map[string]bool{
+	"Основна": true,
+	"т":       true,
+	"так":     true,
+}
+
+// sourceMessages повертає рядки з кирилицею з коду модуля і їх
+// розташування. Зʼєднані через + рядки повертаються цілими.
+func sourceMessages(t *testing.T) map[string]string {
+	messages := make(map[string]string)
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(moduleRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(moduleRoot, path)
+		if d.IsDir() && skipDirs[filepath.ToSlash(rel)] {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") ||
+			strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.Field:
+				// теги полів структур не є повідомленнями
+				return n.Tag == nil
+			case *ast.BinaryExpr, *ast.BasicLit:
+				text, ok := constString(n.(ast.Expr))
+				if !ok {
+					return true
+				}
+				if hasCyrillic(text) && !notMessages[text] {
+					messages[text] = fset.Position(n.Pos()).String()
+				}
+				return false
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// constString повертає значення виразу з рядків, зʼєднаних через +.
+func constString(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		text, err := strconv.Unquote(e.Value)
+		return text, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := constString(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := constString(e.Y)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return constString(e.X)
+	}
+	return "", false
+}
+
+func hasCyrillic(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Дієслова форматування
+var verbPattern = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+func TestEnglish(t *testing.T) {
+	messages := sourceMessages(t)
+	if len(messages) == 0 {
+		t.Fatal("no messages found")
+	}
+	for msg, pos := range messages {
+		text, ok := english[msg]
+		if !ok {
+			t.Errorf("%s: no translation for %q", pos, msg)
+			continue
+		}
+		want := verbPattern.FindAllString(msg, -1)
+		got := verbPattern.FindAllString(text, -1)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%q: verbs mismatch (-want +got):\n%s", msg, diff)
+		}
+		if hasCyrillic(text) {
+			t.Errorf("%q: not translated: %q", msg, text)
+		}
+	}
+	for msg := range english {
+		if _, ok := messages[msg]; !ok {
+			t.Errorf("unused translation %q", msg)
+		}
+	}
+}
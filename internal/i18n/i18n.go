@@ -0,0 +1,107 @@
+// Пакет i18n перекладає повідомлення програми. Повідомлення в коді
+// пишуться українською і є ключами каталогу перекладів. Для іншої мови
+// переклад береться з її каталогу, а якщо перекладу немає, залишається
+// український текст.
+package i18n
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Мови інтерфейсу
+const (
+	Ukrainian = "uk"
+	English   = "en"
+)
+
+// Каталоги перекладів з української
+var catalogs = map[string]map[string]string{
+	English: english,
+}
+
+// Поточна мова
+var locale = Ukrainian
+
+// SetLocale встановлює мову інтерфейсу. Якщо мову не задано, вона
+// визначається за змінними середовища LC_ALL, LC_MESSAGES і LANG.
+func SetLocale(name string) error {
+	if name == "" {
+		locale = envLocale()
+		return nil
+	}
+	if name != Ukrainian && catalogs[name] == nil {
+		return Errorf("невідома мова: %s", name)
+	}
+	locale = name
+	return nil
+}
+
+// Locale повертає мову інтерфейсу.
+func Locale() string {
+	return locale
+}
+
+// envLocale повертає мову зі змінних середовища. Англійська
+// вибирається для локалей en, наприклад en_US.UTF-8, інакше українська.
+func envLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		lang, _, _ := strings.Cut(value, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		if catalogs[lang] != nil {
+			return lang
+		}
+		return Ukrainian
+	}
+	return Ukrainian
+}
+
+// T повертає переклад повідомлення msg.
+func T(msg string) string {
+	if text, ok := catalogs[locale][msg]; ok {
+		return text
+	}
+	return msg
+}
+
+// Sprintf форматує переклад format.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Printf виводить переклад format.
+func Printf(format string, args ...any) {
+	fmt.Printf(T(format), args...)
+}
+
+// Fprintf записує переклад format в w.
+func Fprintf(w io.Writer, format string, args ...any) {
+	fmt.Fprintf(w, T(format), args...)
+}
+
+// Errorf повертає помилку з перекладом format. Як і в fmt.Errorf,
+// %w загортає помилку.
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+// message є помилкою, текст якої перекладається при виводі. Тому її
+// можна створити до вибору мови, наприклад в змінній пакета.
+type message struct {
+	msg string
+}
+
+// NewError повертає помилку з повідомленням msg.
+func NewError(msg string) error {
+	return &message{msg}
+}
+
+func (m *message) Error() string {
+	return T(m.msg)
+}
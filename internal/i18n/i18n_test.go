@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"errors"
+	"testing"
+)
+
+// setLocale встановлює мову до кінця тесту.
+func setLocale(t *testing.T, name string) {
+	saved := locale
+	t.Cleanup(func() { locale = saved })
+	if err := SetLocale(name); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	tests := []struct {
+		lcAll, lang string
+		want        string
+	}{
+		{"", "", Ukrainian},
+		{"", "en_US.UTF-8", English},
+		{"", "en", English},
+		{"", "uk_UA.UTF-8", Ukrainian},
+		{"", "C", Ukrainian},
+		{"uk_UA.UTF-8", "en_US.UTF-8", Ukrainian},
+		{"en_GB", "uk_UA.UTF-8", English},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tt.lang)
+		setLocale(t, "")
+		if Locale() != tt.want {
+			t.Errorf("LC_ALL=%q LANG=%q: want %s, got %s", tt.lcAll,
+				tt.lang, tt.want, Locale())
+		}
+	}
+
+	t.Setenv("LANG", "uk_UA.UTF-8")
+	setLocale(t, English)
+	if Locale() != English {
+		t.Errorf("config locale ignored: %s", Locale())
+	}
+	if err := SetLocale("de"); err == nil {
+		t.Error("unknown locale accepted")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	setLocale(t, Ukrainian)
+	if got := T("Звіт"); got != "Звіт" {
+		t.Errorf("uk: want Звіт, got %s", got)
+	}
+
+	setLocale(t, English)
+	if got := T("Звіт"); got != "Report" {
+		t.Errorf("en: want Report, got %s", got)
+	}
+	if got := T("немає перекладу"); got != "немає перекладу" {
+		t.Errorf("untranslated: got %s", got)
+	}
+	got := Sprintf("Редагування %s", "Контора")
+	if got != "Edit Контора" {
+		t.Errorf("sprintf: got %s", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	// помилка в змінній пакета створюється до вибору мови
+	errMissing := NewError("лічильник не знайдено")
+	wrapped := Errorf("рядок %d: %w", 3, errMissing)
+
+	setLocale(t, English)
+	if got := errMissing.Error(); got != "meter not found" {
+		t.Errorf("new error: got %s", got)
+	}
+	if !errors.Is(wrapped, errMissing) {
+		t.Error("wrapped error not found")
+	}
+	if got := Errorf("рядок %d: %w", 3, errMissing).Error(); got !=
+		"line 3: meter not found" {
+		t.Errorf("errorf: got %s", got)
+	}
+}
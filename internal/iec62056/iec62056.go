@@ -0,0 +1,252 @@
+// Пакет iec62056 читає показники лічильників через оптичний порт за
+// протоколом IEC 62056-21 (режим C) і розбирає блоки даних з записаних
+// файлів.
+package iec62056
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Керуючі символи протоколу
+const (
+	stx = 0x02
+	etx = 0x03
+	ack = 0x06
+)
+
+var (
+	ErrBCC     = i18n.NewError("невірна контрольна сума блоку даних")
+	ErrNoEnd   = i18n.NewError("блок даних не завершено символом '!'")
+	ErrNoData  = i18n.NewError("немає показників енергії 1.8.x")
+	ErrNoIdent = i18n.NewError("лічильник не надіслав ідентифікатор")
+	ErrTimeout = i18n.NewError("лічильник не відповідає")
+)
+
+// DataSet є набором даних блоку: адреса (код OBIS), значення і
+// одиниця виміру.
+type DataSet struct {
+	Line    int // номер рядка в блоці даних
+	Address string
+	Value   string
+	Unit    string
+}
+
+// Readout є прочитаним блоком даних лічильника.
+type Readout struct {
+	Ident string // ідентифікатор без символу '/'
+	Sets  []*DataSet
+}
+
+//---------------------------- PARSE FUNCTIONS ---------------------------
+
+// Parse розбирає відповідь лічильника: необовʼязковий рядок
+// ідентифікатора '/XXXZ...', потім блок даних. Блок даних між STX і ETX
+// перевіряється контрольною сумою, блок без STX (записаний вручну)
+// приймається без перевірки. Блок має завершуватись рядком '!'.
+func Parse(r io.Reader) (*Readout, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	readout := &Readout{Sets: make([]*DataSet, 0)}
+
+	if bytes.HasPrefix(data, []byte("/")) {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			return nil, ErrNoEnd
+		}
+		readout.Ident = strings.TrimSpace(string(data[1:end]))
+		data = data[end+1:]
+	}
+
+	if start := bytes.IndexByte(data, stx); start >= 0 {
+		end := bytes.IndexByte(data[start:], etx)
+		if end < 0 || start+end+1 >= len(data) {
+			return nil, ErrNoEnd
+		}
+		end += start
+		if bcc(data[start+1:end+1]) != data[end+1] {
+			return nil, ErrBCC
+		}
+		data = data[start+1 : end]
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "!" {
+			return readout, nil
+		}
+		sets, err := parseLine(line)
+		if err != nil {
+			return nil, i18n.Errorf("рядок %d: %w", i+1, err)
+		}
+		for _, set := range sets {
+			set.Line = i + 1
+			readout.Sets = append(readout.Sets, set)
+		}
+	}
+	return nil, ErrNoEnd
+}
+
+// parseLine розбирає рядок блоку даних з одним або кількома наборами
+// даних виду 'адреса(значення*одиниця)'.
+func parseLine(line string) ([]*DataSet, error) {
+	sets := make([]*DataSet, 0)
+	for line != "" {
+		open := strings.IndexByte(line, '(')
+		end := strings.IndexByte(line, ')')
+		if open < 0 || end < open {
+			return nil, i18n.Errorf("невірний набір даних %q", line)
+		}
+		set := &DataSet{Address: line[:open], Value: line[open+1 : end]}
+		if i := strings.IndexByte(set.Value, '*'); i >= 0 {
+			set.Unit = set.Value[i+1:]
+			set.Value = set.Value[:i]
+		}
+		// значення без адреси продовжують попередній набір
+		if set.Address != "" {
+			sets = append(sets, set)
+		}
+		line = line[end+1:]
+	}
+	return sets, nil
+}
+
+// bcc рахує контрольну суму блоку: XOR всіх байтів після STX до ETX
+// включно.
+func bcc(data []byte) byte {
+	var sum byte
+	for _, b := range data {
+		sum ^= b
+	}
+	return sum
+}
+
+// obis повертає код C.D.E без носія і каналу ('1-0:') і ознаки
+// поточного значення ('*255'). Для значень попередніх розрахункових
+// періодів ('*01', '&01') повертає false.
+func obis(address string) (string, bool) {
+	if i := strings.IndexByte(address, ':'); i >= 0 {
+		address = address[i+1:]
+	}
+	if i := strings.IndexAny(address, "*&"); i >= 0 {
+		if address[i+1:] != "255" {
+			return "", false
+		}
+		address = address[:i]
+	}
+	return strings.TrimSuffix(address, ".255"), true
+}
+
+//---------------------------- READOUT FUNCTIONS -------------------------
+
+// Find повертає значення поточного набору даних з кодом OBIS code.
+func (r *Readout) Find(code string) (*DataSet, bool) {
+	for _, set := range r.Sets {
+		if c, ok := obis(set.Address); ok && c == code {
+			return set, true
+		}
+	}
+	return nil, false
+}
+
+// Serial повертає номер лічильника: серійний номер C.1.0 або 96.1.0,
+// якщо його нема, то адресу лічильника 0.0.0.
+func (r *Readout) Serial() string {
+	for _, code := range []string{"C.1.0", "96.1.0", "0.0.0"} {
+		if set, ok := r.Find(code); ok && set.Value != "" {
+			return set.Value
+		}
+	}
+	return ""
+}
+
+// Reading є показником активної енергії тарифної зони.
+type Reading struct {
+	Line int // номер рядка в блоці даних
+	Zone int
+	Kwh  int
+}
+
+// Energy повертає показники активної енергії по тарифних зонах в кВт·год:
+// 1.8.1 є першою зоною, 1.8.2 другою, 1.8.3 третьою. Дробова частина
+// відкидається, як на табло лічильника. Нульові показники зон, крім
+// першої, пропускаються, бо однотарифні лічильники теж їх передають.
+// Якщо зон нема, сумарний показник 1.8.0 є першою зоною.
+func (r *Readout) Energy() ([]Reading, error) {
+	readings := make([]Reading, 0)
+	for zone := 1; zone <= 3; zone++ {
+		set, ok := r.Find("1.8." + strconv.Itoa(zone))
+		if !ok {
+			continue
+		}
+		kwh, err := parseKwh(set)
+		if err != nil {
+			return nil, i18n.Errorf("рядок %d: %w", set.Line, err)
+		}
+		if zone > 1 && kwh == 0 {
+			continue
+		}
+		readings = append(readings, Reading{set.Line, zone, kwh})
+	}
+	if len(readings) > 0 {
+		return readings, nil
+	}
+
+	set, ok := r.Find("1.8.0")
+	if !ok {
+		return nil, ErrNoData
+	}
+	kwh, err := parseKwh(set)
+	if err != nil {
+		return nil, i18n.Errorf("рядок %d: %w", set.Line, err)
+	}
+	return []Reading{{set.Line, 1, kwh}}, nil
+}
+
+// parseKwh перетворює значення енергії в цілі кВт·год.
+func parseKwh(set *DataSet) (int, error) {
+	value, err := strconv.ParseFloat(set.Value, 64)
+	if err != nil || value < 0 {
+		return 0, i18n.Errorf("невірне значення %s(%s)", set.Address,
+			set.Value)
+	}
+	switch strings.ToLower(set.Unit) {
+	case "kwh", "":
+	case "wh":
+		value /= 1000
+	case "mwh":
+		value *= 1000
+	default:
+		return 0, i18n.Errorf("невідома одиниця %s(%s*%s)", set.Address,
+			set.Value, set.Unit)
+	}
+	return int(math.Floor(value)), nil
+}
+
+// Rows повертає показники лічильника як рядки для занесення в форму
+// введення показників за номером лічильника.
+func (r *Readout) Rows() ([]*exchange.Row, error) {
+	serial := r.Serial()
+	if serial == "" {
+		return nil, i18n.NewError("лічильник не передав номер")
+	}
+	readings, err := r.Energy()
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]*exchange.Row, 0, len(readings))
+	for _, reading := range readings {
+		rows = append(rows, &exchange.Row{Line: reading.Line,
+			Key: serial, Zone: reading.Zone, Kwh: reading.Kwh})
+	}
+	return rows, nil
+}
@@ -0,0 +1,145 @@
+package iec62056
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+)
+
+func TestReadPath(t *testing.T) {
+	tests := []struct {
+		file   string
+		ident  string
+		serial string
+		want   []*exchange.Row
+	}{
+		{"three_zone.dat", "NIK5NIK2303", "00344848", []*exchange.Row{
+			{Line: 5, Key: "00344848", Zone: 1, Kwh: 7410},
+			{Line: 6, Key: "00344848", Zone: 2, Kwh: 3855},
+		}},
+		{"two_zone.dat", "ISk5MT174-0001", "001930", []*exchange.Row{
+			{Line: 4, Key: "001930", Zone: 1, Kwh: 13750},
+			{Line: 5, Key: "001930", Zone: 2, Kwh: 13700},
+		}},
+		{"single_zone.txt", "", "7", []*exchange.Row{
+			{Line: 2, Key: "7", Zone: 1, Kwh: 15},
+		}},
+	}
+	for _, tt := range tests {
+		readout, err := ReadPath(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if readout.Ident != tt.ident || readout.Serial() != tt.serial {
+			t.Errorf("%s: ident %q, serial %q", tt.file, readout.Ident,
+				readout.Serial())
+		}
+		rows, err := readout.Rows()
+		if err != nil {
+			t.Errorf("%s: %v", tt.file, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, rows); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", tt.file, diff)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := ReadPath(filepath.Join("testdata", "bad_bcc.dat"))
+	if !errors.Is(err, ErrBCC) {
+		t.Errorf("bad bcc: %v", err)
+	}
+
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"1.8.1(100*kWh)\r\n", ErrNoEnd.Error()},
+		{"1.8.1(100*kWh\r\n!\r\n", "невірний набір даних"},
+		{"0.0.0(1)\r\n1.8.1(1x*kWh)\r\n!\r\n", "невірне значення"},
+		{"0.0.0(1)\r\n1.8.1(1*kvarh)\r\n!\r\n", "невідома одиниця"},
+		{"0.0.0(1)\r\n1.8.1*01(1*kWh)\r\n!\r\n", ErrNoData.Error()},
+		{"1.8.1(1*kWh)\r\n!\r\n", "не передав номер"},
+	}
+	for _, tt := range tests {
+		readout, err := Parse(strings.NewReader(tt.data))
+		if err == nil {
+			_, err = readout.Rows()
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: want %q, got %v", tt.data, tt.err, err)
+		}
+	}
+}
+
+// Порт з записаною відповіддю лічильника
+type fakePort struct {
+	response *bytes.Reader
+	written  bytes.Buffer
+	bauds    []int
+}
+
+func (p *fakePort) Read(b []byte) (int, error) {
+	return p.response.Read(b)
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	return p.written.Write(b)
+}
+
+func (p *fakePort) SetBaud(baud int) error {
+	p.bauds = append(p.bauds, baud)
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	dump, err := os.ReadFile(filepath.Join("testdata", "two_zone.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := &fakePort{response: bytes.NewReader(dump)}
+	readout, err := Read(port, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := port.written.String(), "/?!\r\n\x06050\r\n"; got != want {
+		t.Errorf("written: want %q, got %q", want, got)
+	}
+	if diff := cmp.Diff([]int{9600}, port.bauds); diff != "" {
+		t.Errorf("bauds mismatch (-want +got):\n%s", diff)
+	}
+	if readout.Serial() != "001930" {
+		t.Errorf("serial %q", readout.Serial())
+	}
+
+	// Обрив відповіді
+	port = &fakePort{response: bytes.NewReader(dump[:40])}
+	if _, err := Read(port, ""); !errors.Is(err, ErrTimeout) {
+		t.Errorf("timeout: %v", err)
+	}
+
+	// Лічильник без режиму C
+	port = &fakePort{response: bytes.NewReader([]byte("/ABCE2\r\n"))}
+	_, err = Read(port, "12")
+	if err == nil || !strings.Contains(err.Error(), "режим C") {
+		t.Errorf("mode B: %v", err)
+	}
+	if !strings.HasPrefix(port.written.String(), "/?12!") {
+		t.Errorf("address: %q", port.written.String())
+	}
+
+	// Відповідь без ідентифікатора
+	port = &fakePort{response: bytes.NewReader([]byte("ABC\r\n"))}
+	if _, err := Read(port, ""); !errors.Is(err, ErrNoIdent) {
+		t.Errorf("no ident: %v", err)
+	}
+}
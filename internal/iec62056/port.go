@@ -0,0 +1,118 @@
+package iec62056
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/serialport"
+)
+
+// Початкова швидкість обміну протоколу
+const initialBaud = 300
+
+// Максимальна довжина відповіді лічильника
+const maxReadout = 64 * 1024
+
+// Швидкості обміну за символом ідентифікатора лічильника в режимі C
+var baudRates = map[byte]int{
+	'0': 300, '1': 600, '2': 1200, '3': 2400,
+	'4': 4800, '5': 9600, '6': 19200,
+}
+
+// Port є послідовним портом з оптичною головкою. Read повертає io.EOF,
+// якщо за час очікування не прийнято жодного байта.
+type Port interface {
+	io.ReadWriter
+	SetBaud(baud int) error
+}
+
+// Read читає блок даних лічильника в режимі C: запит '/?address!',
+// ідентифікатор лічильника, підтвердження з переходом на швидкість
+// лічильника і блок даних. Пуста адреса підходить будь-якому лічильнику.
+func Read(port Port, address string) (*Readout, error) {
+	_, err := io.WriteString(port, "/?"+address+"!\r\n")
+	if err != nil {
+		return nil, err
+	}
+
+	ident, err := readUntil(port, '\n')
+	if err != nil {
+		return nil, err
+	}
+	start := bytes.IndexByte(ident, '/')
+	if start < 0 || len(ident)-start < 6 {
+		return nil, ErrNoIdent
+	}
+	ident = ident[start:]
+	baud, ok := baudRates[ident[4]]
+	if !ok {
+		return nil, i18n.Errorf("лічильник не підтримує режим C: %q",
+			bytes.TrimSpace(ident))
+	}
+
+	// режим протоколу C, звичайна процедура, читання даних
+	_, err = port.Write([]byte{ack, '0', ident[4], '0', '\r', '\n'})
+	if err != nil {
+		return nil, err
+	}
+	if err := port.SetBaud(baud); err != nil {
+		return nil, err
+	}
+
+	block, err := readUntil(port, etx)
+	if err != nil {
+		return nil, err
+	}
+	sum := make([]byte, 1)
+	if _, err := io.ReadFull(port, sum); err != nil {
+		return nil, ErrTimeout
+	}
+	return Parse(io.MultiReader(bytes.NewReader(ident),
+		bytes.NewReader(block), bytes.NewReader(sum)))
+}
+
+// readUntil читає з порту до символу end включно.
+func readUntil(port Port, end byte) ([]byte, error) {
+	data := make([]byte, 0, 256)
+	b := make([]byte, 1)
+	for len(data) < maxReadout {
+		n, err := port.Read(b)
+		if err == io.EOF || (err == nil && n == 0) {
+			return nil, ErrTimeout
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b[0])
+		if b[0] == end {
+			return data, nil
+		}
+	}
+	return nil, ErrNoEnd
+}
+
+// ReadPath читає лічильник через послідовний порт, якщо path є
+// пристроєм, або розбирає записану відповідь лічильника з файла.
+func ReadPath(path string) (*Readout, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return Parse(file)
+	}
+
+	port, err := serialport.Open(path, initialBaud, serialport.Format7E1)
+	if err != nil {
+		return nil, err
+	}
+	defer port.Close()
+	return Read(port, "")
+}
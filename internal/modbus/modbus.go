@@ -0,0 +1,108 @@
+// Пакет modbus читає регістри лічильників за протоколом Modbus TCP і
+// Modbus RTU (RS-485) і містить простий симулятор Modbus TCP для
+// перевірки опитування без обладнання.
+package modbus
+
+import (
+	"encoding/binary"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Функції читання регістрів
+const (
+	ReadHolding byte = 3
+	ReadInput   byte = 4
+)
+
+// Максимальна кількість регістрів в одному запиті
+const maxCount = 125
+
+// Коди винятків
+const (
+	IllegalFunction byte = 1
+	IllegalAddress  byte = 2
+	IllegalValue    byte = 3
+	GatewayTarget   byte = 0x0B
+)
+
+var (
+	ErrResponse = i18n.NewError("невірна відповідь пристрою")
+	ErrTimeout  = i18n.NewError("пристрій не відповідає")
+)
+
+// Exception є відповіддю пристрою з кодом винятку.
+type Exception struct {
+	Function byte
+	Code     byte
+}
+
+func (e *Exception) Error() string {
+	text := map[byte]string{
+		IllegalFunction: "функція не підтримується",
+		IllegalAddress:  "невірна адреса регістра",
+		IllegalValue:    "невірне значення",
+		GatewayTarget:   "пристрій не відповідає",
+	}[e.Code]
+	if text == "" {
+		text = "помилка пристрою"
+	}
+	return i18n.Sprintf("modbus: %s (функція %d, код %d)", i18n.T(text),
+		e.Function, e.Code)
+}
+
+// transport передає запит PDU пристрою unit і повертає PDU відповіді.
+type transport interface {
+	send(unit byte, pdu []byte) ([]byte, error)
+	Close() error
+}
+
+// Client читає регістри пристроїв через Modbus TCP або RTU.
+type Client struct {
+	transport
+}
+
+// ReadRegisters читає count регістрів з адреси address пристрою unit
+// функцією function (ReadHolding або ReadInput).
+func (c *Client) ReadRegisters(function, unit byte, address,
+	count uint16) ([]uint16, error) {
+
+	if count == 0 || count > maxCount {
+		return nil, i18n.Errorf("modbus: невірна кількість регістрів %d",
+			count)
+	}
+	if function != ReadHolding && function != ReadInput {
+		return nil, &Exception{function, IllegalFunction}
+	}
+	pdu := make([]byte, 5)
+	pdu[0] = function
+	binary.BigEndian.PutUint16(pdu[1:], address)
+	binary.BigEndian.PutUint16(pdu[3:], count)
+
+	resp, err := c.send(unit, pdu)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(function, resp); err != nil {
+		return nil, err
+	}
+	if len(resp) != 2+2*int(count) || int(resp[1]) != 2*int(count) {
+		return nil, ErrResponse
+	}
+	values := make([]uint16, count)
+	for i := range values {
+		values[i] = binary.BigEndian.Uint16(resp[2+2*i:])
+	}
+	return values, nil
+}
+
+// checkResponse перевіряє функцію відповіді і повертає виняток.
+func checkResponse(function byte, resp []byte) error {
+	switch {
+	case len(resp) == 2 && resp[0] == function|0x80:
+		return &Exception{function, resp[1]}
+	case len(resp) < 2 || resp[0] != function:
+		return ErrResponse
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package modbus
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// startSimulator запускає симулятор з регістрами пристрою 1.
+func startSimulator(t *testing.T) (*Simulator, string) {
+	t.Helper()
+	sim := NewSimulator()
+	sim.SetRegisters(ReadHolding, 1, 0x100, 0x0001, 0xE240, 7)
+	sim.SetRegisters(ReadInput, 1, 0, 42)
+	address, err := sim.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sim.Close() })
+	return sim, address
+}
+
+func TestTCP(t *testing.T) {
+	_, address := startSimulator(t)
+	client, err := DialTCP(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	values, err := client.ReadRegisters(ReadHolding, 1, 0x100, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint16{0x0001, 0xE240, 7}, values); diff != "" {
+		t.Errorf("holding mismatch (-want +got):\n%s", diff)
+	}
+	values, err = client.ReadRegisters(ReadInput, 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint16{42}, values); diff != "" {
+		t.Errorf("input mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		function, unit byte
+		address, count uint16
+		code           byte
+	}{
+		{ReadHolding, 1, 0x102, 2, IllegalAddress},
+		{ReadInput, 1, 0x100, 1, IllegalAddress},
+		{ReadHolding, 2, 0x100, 1, GatewayTarget},
+		{6, 1, 0x100, 1, IllegalFunction},
+	}
+	for _, tt := range tests {
+		_, err := client.ReadRegisters(tt.function, tt.unit, tt.address,
+			tt.count)
+		var exception *Exception
+		if !errors.As(err, &exception) || exception.Code != tt.code {
+			t.Errorf("%v: want code %d, got %v", tt, tt.code, err)
+		}
+	}
+}
+
+func TestCRC(t *testing.T) {
+	frame := encodeRTU(1, []byte{ReadHolding, 0, 0, 0, 0x0A})
+	want := []byte{1, 3, 0, 0, 0, 0x0A, 0xC5, 0xCD}
+	if !bytes.Equal(want, frame) {
+		t.Errorf("want % X, got % X", want, frame)
+	}
+	if crc16(frame) != 0 {
+		t.Errorf("crc of frame %04X", crc16(frame))
+	}
+}
+
+// Порт RS-485 з записаною відповіддю пристрою
+type fakePort struct {
+	bytes.Buffer
+	written []byte
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.written = append(p.written, b...)
+	return len(b), nil
+}
+
+func (p *fakePort) Close() error {
+	return nil
+}
+
+func TestRTU(t *testing.T) {
+	port := &fakePort{}
+	port.Buffer.Write(encodeRTU(5, []byte{ReadInput, 4, 0x00, 0x01, 0xE2, 0x40}))
+	client := NewRTU(port)
+	values, err := client.ReadRegisters(ReadInput, 5, 0x0C, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]uint16{1, 0xE240}, values); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if want := encodeRTU(5, []byte{ReadInput, 0, 0x0C, 0, 2}); !bytes.Equal(
+		want, port.written) {
+		t.Errorf("request: want % X, got % X", want, port.written)
+	}
+
+	// Виняток, пошкоджений кадр і відсутність відповіді
+	port.Buffer.Write(encodeRTU(5, []byte{ReadInput | 0x80, IllegalAddress}))
+	_, err = client.ReadRegisters(ReadInput, 5, 0x0C, 2)
+	var exception *Exception
+	if !errors.As(err, &exception) || exception.Code != IllegalAddress {
+		t.Errorf("exception: %v", err)
+	}
+	frame := encodeRTU(5, []byte{ReadInput, 2, 0, 1})
+	frame[3] ^= 1
+	port.Buffer.Write(frame)
+	if _, err := client.ReadRegisters(ReadInput, 5, 0, 1); err != ErrResponse {
+		t.Errorf("crc: %v", err)
+	}
+	if _, err := client.ReadRegisters(ReadInput, 5, 0, 1); err != ErrTimeout {
+		t.Errorf("timeout: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/kraserh/energozvit/internal/serialport"
+)
+
+// Транспорт Modbus RTU. Порт має повертати помилку або 0 байт, якщо
+// відповіді нема.
+type rtuTransport struct {
+	port io.ReadWriteCloser
+}
+
+// OpenRTU відкриває послідовний порт path з інтерфейсом RS-485 для
+// Modbus RTU: 8 біт, без парності, 1 стоп-біт.
+func OpenRTU(path string, baud int) (*Client, error) {
+	port, err := serialport.Open(path, baud, serialport.Format8N1)
+	if err != nil {
+		return nil, err
+	}
+	return NewRTU(port), nil
+}
+
+// NewRTU повертає клієнт Modbus RTU через відкритий порт.
+func NewRTU(port io.ReadWriteCloser) *Client {
+	return &Client{&rtuTransport{port}}
+}
+
+func (t *rtuTransport) send(unit byte, pdu []byte) ([]byte, error) {
+	if _, err := t.port.Write(encodeRTU(unit, pdu)); err != nil {
+		return nil, err
+	}
+
+	// пристрій, функція і код винятку або кількість байтів
+	frame := make([]byte, 3, 3+maxPDU)
+	if err := readFull(t.port, frame); err != nil {
+		return nil, err
+	}
+	rest := 2 // CRC
+	if frame[1]&0x80 == 0 {
+		rest += int(frame[2])
+	}
+	frame = frame[:3+rest]
+	if err := readFull(t.port, frame[3:]); err != nil {
+		return nil, err
+	}
+
+	if crc16(frame) != 0 || frame[0] != unit {
+		return nil, ErrResponse
+	}
+	return frame[1 : len(frame)-2], nil
+}
+
+func (t *rtuTransport) Close() error {
+	return t.port.Close()
+}
+
+// readFull читає буфер повністю, відсутність даних є помилкою.
+func readFull(r io.Reader, buf []byte) error {
+	_, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTimeout
+	}
+	return err
+}
+
+// encodeRTU повертає кадр Modbus RTU з контрольною сумою.
+func encodeRTU(unit byte, pdu []byte) []byte {
+	frame := make([]byte, 0, len(pdu)+3)
+	frame = append(frame, unit)
+	frame = append(frame, pdu...)
+	return binary.LittleEndian.AppendUint16(frame, crc16(frame))
+}
+
+// crc16 рахує контрольну суму CRC-16/MODBUS. Для кадру з контрольною
+// сумою повертає 0.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,166 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Simulator є сервером Modbus TCP з регістрами кількох пристроїв. На
+// запит до невідомого пристрою відповідає винятком шлюзу.
+type Simulator struct {
+	mu       sync.Mutex
+	units    map[byte]*simUnit
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// Регістри пристрою за адресами
+type simUnit struct {
+	holding map[uint16]uint16
+	input   map[uint16]uint16
+}
+
+// NewSimulator повертає симулятор без пристроїв.
+func NewSimulator() *Simulator {
+	return &Simulator{
+		units: make(map[byte]*simUnit),
+		conns: make(map[net.Conn]bool),
+	}
+}
+
+// SetRegisters записує значення values в регістри пристрою unit, які
+// читаються функцією function, починаючи з адреси address.
+func (s *Simulator) SetRegisters(function, unit byte, address uint16,
+	values ...uint16) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.units[unit]
+	if !ok {
+		u = &simUnit{make(map[uint16]uint16), make(map[uint16]uint16)}
+		s.units[unit] = u
+	}
+	registers := u.holding
+	if function == ReadInput {
+		registers = u.input
+	}
+	for i, value := range values {
+		registers[address+uint16(i)] = value
+	}
+}
+
+// Listen починає приймати зʼєднання на адресі address і повертає
+// фактичну адресу (для порту 0).
+func (s *Simulator) Listen(address string) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = true
+			s.wg.Add(1)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+// Close зупиняє симулятор і закриває всі зʼєднання.
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return i18n.NewError("симулятор не запущено")
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serve відповідає на запити одного зʼєднання.
+func (s *Simulator) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		id, unit, pdu, err := readMBAP(conn)
+		if err != nil {
+			return
+		}
+		resp := s.respond(unit, pdu)
+		if _, err := conn.Write(encodeMBAP(id, unit, resp)); err != nil {
+			return
+		}
+	}
+}
+
+// respond повертає PDU відповіді на запит читання регістрів.
+func (s *Simulator) respond(unit byte, pdu []byte) []byte {
+	function := pdu[0]
+	exception := func(code byte) []byte {
+		return []byte{function | 0x80, code}
+	}
+	if function != ReadHolding && function != ReadInput {
+		return exception(IllegalFunction)
+	}
+	if len(pdu) != 5 {
+		return exception(IllegalValue)
+	}
+	address := binary.BigEndian.Uint16(pdu[1:])
+	count := binary.BigEndian.Uint16(pdu[3:])
+	if count == 0 || count > maxCount {
+		return exception(IllegalValue)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.units[unit]
+	if !ok {
+		return exception(GatewayTarget)
+	}
+	registers := u.holding
+	if function == ReadInput {
+		registers = u.input
+	}
+	resp := []byte{function, byte(2 * count)}
+	for i := uint16(0); i < count; i++ {
+		value, ok := registers[address+i]
+		if !ok {
+			return exception(IllegalAddress)
+		}
+		resp = binary.BigEndian.AppendUint16(resp, value)
+	}
+	return resp
+}
@@ -0,0 +1,84 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+)
+
+// Час очікування зʼєднання і відповіді
+const Timeout = 3 * time.Second
+
+// Розмір заголовка MBAP: ідентифікатор транзакції, протокол, довжина,
+// пристрій
+const mbapSize = 7
+
+// Максимальна довжина PDU
+const maxPDU = 253
+
+// Транспорт Modbus TCP
+type tcpTransport struct {
+	conn net.Conn
+	id   uint16
+}
+
+// DialTCP зʼєднується з пристроєм або шлюзом Modbus TCP.
+func DialTCP(address string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", address, Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{&tcpTransport{conn: conn}}, nil
+}
+
+func (t *tcpTransport) send(unit byte, pdu []byte) ([]byte, error) {
+	t.id++
+	err := t.conn.SetDeadline(time.Now().Add(Timeout))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.conn.Write(encodeMBAP(t.id, unit, pdu)); err != nil {
+		return nil, err
+	}
+
+	id, respUnit, resp, err := readMBAP(t.conn)
+	if err != nil {
+		return nil, err
+	}
+	if id != t.id || respUnit != unit {
+		return nil, ErrResponse
+	}
+	return resp, nil
+}
+
+func (t *tcpTransport) Close() error {
+	return t.conn.Close()
+}
+
+// encodeMBAP повертає кадр Modbus TCP.
+func encodeMBAP(id uint16, unit byte, pdu []byte) []byte {
+	frame := make([]byte, mbapSize, mbapSize+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:], id)
+	binary.BigEndian.PutUint16(frame[4:], uint16(len(pdu)+1))
+	frame[6] = unit
+	return append(frame, pdu...)
+}
+
+// readMBAP читає кадр Modbus TCP.
+func readMBAP(r io.Reader) (id uint16, unit byte, pdu []byte, err error) {
+	header := make([]byte, mbapSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, nil, err
+	}
+	length := int(binary.BigEndian.Uint16(header[4:]))
+	if binary.BigEndian.Uint16(header[2:]) != 0 || length < 2 ||
+		length > maxPDU+1 {
+		return 0, 0, nil, ErrResponse
+	}
+	pdu = make([]byte, length-1)
+	if _, err := io.ReadFull(r, pdu); err != nil {
+		return 0, 0, nil, err
+	}
+	return binary.BigEndian.Uint16(header[0:]), header[6], pdu, nil
+}
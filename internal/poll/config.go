@@ -0,0 +1,184 @@
+// Пакет poll опитує лічильники з інтерфейсом RS-485 через Modbus RTU
+// або Modbus TCP і повертає показники для занесення в форму введення
+// показників. Регістри енергії кожного лічильника задаються в файлі
+// налаштувань. Протокол DLMS/COSEM не підтримується, лічильники з
+// оптичним портом читаються пакетом iec62056.
+package poll
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/modbus"
+)
+
+// Config є налаштуваннями опитування лічильників.
+type Config struct {
+	Meters []*Meter `json:"meters"`
+}
+
+// Meter описує підключення лічильника і регістри енергії по зонах.
+// Задається або адреса Modbus TCP, або послідовний порт Modbus RTU.
+type Meter struct {
+	Serial    string      `json:"serial"`
+	Address   string      `json:"address,omitempty"` // host:port
+	Device    string      `json:"device,omitempty"`  // /dev/ttyUSB0
+	Baud      int         `json:"baud,omitempty"`    // 9600, якщо не задано
+	Unit      byte        `json:"unit"`              // адреса пристрою
+	Registers []*Register `json:"registers"`
+}
+
+// Register описує регістр активної енергії тарифної зони.
+type Register struct {
+	Zone     int     `json:"zone"`
+	Function byte    `json:"function,omitempty"` // 3 (holding) або 4 (input)
+	Address  uint16  `json:"address"`
+	Type     string  `json:"type,omitempty"`  // uint32, якщо не задано
+	Scale    float64 `json:"scale,omitempty"` // множник до кВт·год
+}
+
+// Типи значень регістрів і кількість 16-бітних регістрів. Суфікс sw
+// означає, що молодше слово передається першим.
+var typeSizes = map[string]uint16{
+	"uint16":    1,
+	"uint32":    2,
+	"uint32sw":  2,
+	"float32":   2,
+	"float32sw": 2,
+	"uint64":    4,
+}
+
+// Швидкість порту за замовчуванням для Modbus RTU
+const defaultBaud = 9600
+
+// ReadConfig читає налаштування опитування з JSON і перевіряє їх.
+// Незадані функція, тип, множник і швидкість порту отримують значення
+// за замовчуванням.
+func ReadConfig(r io.Reader) (*Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	config := &Config{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+	if len(config.Meters) == 0 {
+		return nil, i18n.NewError("не задано жодного лічильника")
+	}
+	for i, m := range config.Meters {
+		if err := m.check(); err != nil {
+			return nil, i18n.Errorf("лічильник %d (%s): %w", i+1,
+				m.Serial, err)
+		}
+	}
+	return config, nil
+}
+
+// check перевіряє налаштування лічильника і задає значення за
+// замовчуванням.
+func (m *Meter) check() error {
+	switch {
+	case m.Serial == "":
+		return i18n.NewError("не задано номер лічильника")
+	case (m.Address == "") == (m.Device == ""):
+		return i18n.NewError("потрібно задати address або device")
+	case len(m.Registers) == 0:
+		return i18n.NewError("не задано регістри")
+	}
+	if m.Baud == 0 {
+		m.Baud = defaultBaud
+	}
+	zones := make(map[int]bool)
+	for _, r := range m.Registers {
+		if r.Function == 0 {
+			r.Function = modbus.ReadHolding
+		}
+		if r.Type == "" {
+			r.Type = "uint32"
+		}
+		if r.Scale == 0 {
+			r.Scale = 1
+		}
+		switch {
+		case r.Zone < 1 || r.Zone > 3:
+			return i18n.Errorf("невірна зона %d", r.Zone)
+		case zones[r.Zone]:
+			return i18n.Errorf("зона %d задана двічі", r.Zone)
+		case r.Function != modbus.ReadHolding &&
+			r.Function != modbus.ReadInput:
+			return i18n.Errorf("невірна функція %d", r.Function)
+		case typeSizes[r.Type] == 0:
+			return i18n.Errorf("невідомий тип %q", r.Type)
+		case r.Scale < 0:
+			return i18n.NewError("відʼємний множник")
+		}
+		zones[r.Zone] = true
+	}
+	return nil
+}
+
+// Decode перетворює значення регістрів в цілі кВт·год. Дробова частина
+// відкидається, як на табло лічильника.
+func (r *Register) Decode(values []uint16) (int, error) {
+	if len(values) != int(typeSizes[r.Type]) {
+		return 0, modbus.ErrResponse
+	}
+	words := append([]uint16{}, values...)
+	if r.Type == "uint32sw" || r.Type == "float32sw" {
+		words[0], words[1] = words[1], words[0]
+	}
+	var raw uint64
+	for _, w := range words {
+		raw = raw<<16 | uint64(w)
+	}
+
+	value := float64(raw)
+	if r.Type == "float32" || r.Type == "float32sw" {
+		value = float64(math.Float32frombits(uint32(raw)))
+	}
+	value *= r.Scale
+	if math.IsNaN(value) || value < 0 || value > math.MaxInt32 {
+		return 0, i18n.Errorf("невірне значення регістра %d", r.Address)
+	}
+	return int(value), nil
+}
+
+// Encode повертає значення регістрів для показника kwh. Використовується
+// симулятором.
+func (r *Register) Encode(kwh int) []uint16 {
+	value := math.Round(float64(kwh) / r.Scale)
+	words := r.encode(value)
+	// похибка множника не має зменшити показник
+	if decoded, err := r.Decode(words); err == nil && decoded < kwh {
+		if r.Type == "float32" || r.Type == "float32sw" {
+			value = float64(math.Nextafter32(float32(value),
+				math.MaxFloat32))
+		} else {
+			value++
+		}
+		words = r.encode(value)
+	}
+	return words
+}
+
+// encode повертає значення регістрів для value в одиницях лічильника.
+func (r *Register) encode(value float64) []uint16 {
+	raw := uint64(value)
+	if r.Type == "float32" || r.Type == "float32sw" {
+		raw = uint64(math.Float32bits(float32(value)))
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], raw)
+	size := int(typeSizes[r.Type])
+	words := make([]uint16, size)
+	for i := range words {
+		words[i] = binary.BigEndian.Uint16(buf[8-2*(size-i):])
+	}
+	if r.Type == "uint32sw" || r.Type == "float32sw" {
+		words[0], words[1] = words[1], words[0]
+	}
+	return words
+}
@@ -0,0 +1,125 @@
+package poll
+
+import (
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/modbus"
+)
+
+// MeterError описує лічильник, який не вдалось опитати.
+type MeterError struct {
+	Meter *Meter
+	Err   error
+}
+
+func (e *MeterError) Error() string {
+	connection := e.Meter.Address
+	if connection == "" {
+		connection = e.Meter.Device
+	}
+	return i18n.Sprintf("лічильник %s (%s, пристрій %d): %s",
+		e.Meter.Serial, connection, e.Meter.Unit, e.Err)
+}
+
+func (e *MeterError) Unwrap() error {
+	return e.Err
+}
+
+// Poll опитує всі лічильники з налаштувань і повертає їх показники як
+// рядки для занесення в форму введення показників. Номер рядка є номером
+// лічильника в налаштуваннях. Лічильники на одній шині або за одним
+// шлюзом опитуються через одне зʼєднання. Лічильники, які не вдалось
+// опитати, повертаються як помилки, інші опитуються далі.
+func Poll(config *Config) ([]*exchange.Row, []*MeterError) {
+	rows := make([]*exchange.Row, 0)
+	errs := make([]*MeterError, 0)
+	clients := make(map[string]*modbus.Client)
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+
+	for i, m := range config.Meters {
+		key := "tcp:" + m.Address
+		if m.Device != "" {
+			key = "rtu:" + m.Device
+		}
+		client, ok := clients[key]
+		if !ok {
+			var err error
+			if m.Device != "" {
+				client, err = modbus.OpenRTU(m.Device, m.Baud)
+			} else {
+				client, err = modbus.DialTCP(m.Address)
+			}
+			if err != nil {
+				errs = append(errs, &MeterError{m, err})
+				continue
+			}
+			clients[key] = client
+		}
+
+		meterRows, err := readMeter(client, m)
+		if err != nil {
+			errs = append(errs, &MeterError{m, err})
+			continue
+		}
+		for _, row := range meterRows {
+			row.Line = i + 1
+			rows = append(rows, row)
+		}
+	}
+	return rows, errs
+}
+
+// readMeter читає регістри енергії лічильника по зонах.
+func readMeter(client *modbus.Client, m *Meter) ([]*exchange.Row, error) {
+	rows := make([]*exchange.Row, 0, len(m.Registers))
+	for _, r := range m.Registers {
+		values, err := client.ReadRegisters(r.Function, m.Unit, r.Address,
+			typeSizes[r.Type])
+		if err != nil {
+			return nil, err
+		}
+		kwh, err := r.Decode(values)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, &exchange.Row{Key: m.Serial, Zone: r.Zone,
+			Kwh: kwh})
+	}
+	return rows, nil
+}
+
+// Simulate запускає симулятори Modbus TCP на адресах лічильників з
+// налаштувань і записує в регістри показники kwh(serial, zone).
+// Лічильники Modbus RTU пропускаються.
+func Simulate(config *Config, kwh func(serial string, zone int) int) (
+	[]*modbus.Simulator, error) {
+
+	simulators := make(map[string]*modbus.Simulator)
+	list := make([]*modbus.Simulator, 0)
+	for _, m := range config.Meters {
+		if m.Address == "" {
+			continue
+		}
+		sim, ok := simulators[m.Address]
+		if !ok {
+			sim = modbus.NewSimulator()
+			if _, err := sim.Listen(m.Address); err != nil {
+				for _, s := range list {
+					s.Close()
+				}
+				return nil, err
+			}
+			simulators[m.Address] = sim
+			list = append(list, sim)
+		}
+		for _, r := range m.Registers {
+			sim.SetRegisters(r.Function, m.Unit, r.Address,
+				r.Encode(kwh(m.Serial, r.Zone))...)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,164 @@
+package poll
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/modbus"
+)
+
+func TestReadConfig(t *testing.T) {
+	config, err := ReadConfig(strings.NewReader(`{"meters": [
+		{"serial": "001930", "device": "/dev/ttyUSB0", "unit": 3,
+		 "registers": [{"zone": 1, "address": 256},
+			{"zone": 2, "function": 4, "address": 258,
+			 "type": "float32", "scale": 0.001}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Config{Meters: []*Meter{{Serial: "001930",
+		Device: "/dev/ttyUSB0", Baud: 9600, Unit: 3,
+		Registers: []*Register{
+			{Zone: 1, Function: 3, Address: 256, Type: "uint32", Scale: 1},
+			{Zone: 2, Function: 4, Address: 258, Type: "float32",
+				Scale: 0.001},
+		}}}}
+	if diff := cmp.Diff(want, config); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		meter string
+		err   string
+	}{
+		{`"registers": [{"zone": 1, "address": 1}]`, "не задано номер"},
+		{`"serial": "1", "registers": [{"zone": 1}]`, "address або device"},
+		{`"serial": "1", "address": "a:1"`, "не задано регістри"},
+		{`"serial": "1", "address": "a:1", "registers": [{"zone": 4}]`,
+			"невірна зона"},
+		{`"serial": "1", "address": "a:1", "registers": [{"zone": 1},
+			{"zone": 1}]`, "задана двічі"},
+		{`"serial": "1", "address": "a:1", "registers": [{"zone": 1,
+			"function": 6}]`, "невірна функція"},
+		{`"serial": "1", "address": "a:1", "registers": [{"zone": 1,
+			"type": "int8"}]`, "невідомий тип"},
+		{`"serial": "1", "address": "a:1", "registers": [{"zone": 1,
+			"offset": 1}]`, "unknown field"},
+	}
+	for _, tt := range tests {
+		_, err := ReadConfig(strings.NewReader(`{"meters": [{` +
+			tt.meter + `}]}`))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: want %q, got %v", tt.meter, tt.err, err)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		register Register
+		kwh      int
+		words    []uint16
+	}{
+		{Register{Type: "uint16", Scale: 1}, 9999, []uint16{9999}},
+		{Register{Type: "uint32", Scale: 0.01}, 123456,
+			[]uint16{0x00BC, 0x6100}},
+		{Register{Type: "uint32sw", Scale: 0.01}, 123456,
+			[]uint16{0x6100, 0x00BC}},
+		{Register{Type: "uint64", Scale: 0.001}, 13750,
+			[]uint16{0, 0, 0x00D1, 0xCEF0}},
+		{Register{Type: "float32", Scale: 1}, 13750,
+			[]uint16{0x4656, 0xD800}},
+		{Register{Type: "float32sw", Scale: 1}, 13750,
+			[]uint16{0xD800, 0x4656}},
+	}
+	for _, tt := range tests {
+		words := tt.register.Encode(tt.kwh)
+		if diff := cmp.Diff(tt.words, words); diff != "" {
+			t.Errorf("%s: encode mismatch (-want +got):\n%s",
+				tt.register.Type, diff)
+		}
+		kwh, err := tt.register.Decode(words)
+		if err != nil || kwh != tt.kwh {
+			t.Errorf("%s: decode %d, %v", tt.register.Type, kwh, err)
+		}
+	}
+
+	// Дробова частина відкидається
+	r := &Register{Type: "uint32", Scale: 0.1}
+	if kwh, _ := r.Decode([]uint16{0, 1239}); kwh != 123 {
+		t.Errorf("fraction: %d", kwh)
+	}
+	if _, err := r.Decode([]uint16{1}); err == nil {
+		t.Errorf("short value: no error")
+	}
+}
+
+// freeAddress повертає вільну адресу для симулятора.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestPoll(t *testing.T) {
+	gateway := freeAddress(t)
+	config, err := ReadConfig(strings.NewReader(`{"meters": [
+		{"serial": "344848", "address": "` + gateway + `", "unit": 1,
+		 "registers": [{"zone": 1, "address": 256, "scale": 0.01}]},
+		{"serial": "001930", "address": "` + gateway + `", "unit": 2,
+		 "registers": [{"zone": 1, "function": 4, "address": 0,
+			"type": "float32"},
+			{"zone": 2, "function": 4, "address": 2,
+			"type": "float32"}]},
+		{"serial": "7", "address": "` + gateway + `", "unit": 3,
+		 "registers": [{"zone": 1, "address": 0}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kwh := map[string]int{"344848": 64, "001930": 13750}
+	simulators, err := Simulate(config, func(serial string, zone int) int {
+		return kwh[serial] - zone + 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(simulators) != 1 {
+		t.Fatalf("want 1 simulator, got %d", len(simulators))
+	}
+	defer simulators[0].Close()
+
+	// Лічильник 7 не відповідає за тим самим шлюзом
+	config.Meters[2].Unit = 4
+	rows, errs := Poll(config)
+	want := []*exchange.Row{
+		{Line: 1, Key: "344848", Zone: 1, Kwh: 64},
+		{Line: 2, Key: "001930", Zone: 1, Kwh: 13750},
+		{Line: 2, Key: "001930", Zone: 2, Kwh: 13749},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	var exception *modbus.Exception
+	if len(errs) != 1 || !errors.As(errs[0], &exception) ||
+		exception.Code != modbus.GatewayTarget {
+		t.Errorf("errors: %v", errs)
+	}
+
+	// Недоступний шлюз
+	config.Meters[0].Address = freeAddress(t)
+	_, errs = Poll(config)
+	if len(errs) != 2 || errs[0].Meter.Serial != "344848" {
+		t.Errorf("unreachable: %v", errs)
+	}
+}
@@ -0,0 +1,146 @@
+// Пакет report групує рядки місячного звіту по точках обліку,
+// лічильниках і тарифних зонах для шаблонів і таблиць.
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Точка обліку
+type Place struct {
+	Substation int
+	Eic        string
+	Name       string
+	Meters     []*Meter
+	Lines      int // Кількість тарифних зон всіх лічильників
+}
+
+// Лічильник
+type Meter struct {
+	Model  string
+	Year   int
+	Serial string
+	Digits int
+	Ratio  int
+	Zones  []*Zone
+	Lines  int // Кількість тарифних зон
+}
+
+// Тарифна зона
+type Zone struct {
+	Number     int
+	CurKwh     int
+	PrevKwh    int
+	Diff       int
+	Energy     int
+	Annotation string
+}
+
+// Group групує рядки звіту, впорядковані за точкою обліку і
+// лічильником, в точки обліку з лічильниками і тарифними зонами.
+func Group(reports []*storage.Report) []*Place {
+	places := make([]*Place, 0)
+	var place *Place
+	var meter *Meter
+	for _, report := range reports {
+
+		// точки обліку
+		if place == nil || place.Name != report.Name {
+			place = &Place{
+				Substation: report.Substation,
+				Eic:        report.Eic,
+				Name:       report.Name,
+				Meters:     []*Meter{},
+			}
+			places = append(places, place)
+			meter = nil
+		}
+
+		// лічильники
+		if meter == nil || meter.Serial != report.Serial {
+			meter = &Meter{
+				Model:  report.Model,
+				Year:   report.Year,
+				Serial: report.Serial,
+				Digits: report.Digits,
+				Ratio:  report.Ratio,
+				Zones:  []*Zone{},
+			}
+			place.Meters = append(place.Meters, meter)
+		}
+
+		// тарифні зони
+		zone := &Zone{
+			Number:     report.Zone,
+			CurKwh:     report.CurKwh,
+			PrevKwh:    report.PreKwh,
+			Diff:       report.Diff,
+			Energy:     report.Energy,
+			Annotation: report.Annotation,
+		}
+		meter.Zones = append(meter.Zones, zone)
+		place.Lines++
+		meter.Lines++
+	}
+	return places
+}
+
+// Sort впорядковує точки обліку: спочатку точки з names в вказаному
+// порядку, потім решта в попередньому порядку.
+func Sort(places []*Place, names []string) []*Place {
+	order := sortOrder(names)
+	sort.SliceStable(places, func(i, j int) bool {
+		return order[places[i].Name] > order[places[j].Name]
+	})
+	return places
+}
+
+// SortComparison впорядковує порівняння точок обліку так само як Sort.
+func SortComparison(places []*storage.PlaceComparison,
+	names []string) []*storage.PlaceComparison {
+
+	order := sortOrder(names)
+	sort.SliceStable(places, func(i, j int) bool {
+		return order[places[i].Name] > order[places[j].Name]
+	})
+	return places
+}
+
+// sortOrder повертає вагу назв точок обліку для сортування: перша назва
+// має найбільшу вагу, решта точок нульову.
+func sortOrder(names []string) map[string]int {
+	order := make(map[string]int)
+	maxIdx := len(names)
+	for i, name := range names {
+		order[name] = maxIdx - i
+	}
+	return order
+}
+
+// Energy повертає суму спожитої енергії точок обліку.
+func Energy(places []*Place) int {
+	var total int
+	for _, place := range places {
+		for _, meter := range place.Meters {
+			for _, zone := range meter.Zones {
+				total += zone.Energy
+			}
+		}
+	}
+	return total
+}
+
+// Назви місяців
+var monthNames = [13]string{
+	"", "січень", "лютий", "березень", "квітень", "травень",
+	"червень", "липень", "серпень", "вересень", "жовтень",
+	"листопад", "грудень",
+}
+
+// MonthName повертає назву місяця.
+func MonthName(month time.Month) string {
+	return monthNames[month]
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+func newReport(name, serial string, zone, cur, pre, ratio int) *storage.Report {
+	r := &storage.Report{
+		Meter: &storage.Meter{Name: name, Serial: serial, Digits: 5,
+			Ratio: ratio},
+		Zone:   zone,
+		CurKwh: cur,
+		PreKwh: pre,
+	}
+	r.Calculate()
+	return r
+}
+
+func TestGroup(t *testing.T) {
+	reports := []*storage.Report{
+		newReport("АВМ", "1", 1, 20, 10, 40),
+		newReport("Контора", "2", 1, 15, 10, 1),
+		newReport("Контора", "2", 2, 12, 10, 1),
+		newReport("Контора", "3", 1, 99999, 99990, 1),
+	}
+	want := []*Place{
+		{Name: "АВМ", Lines: 1, Meters: []*Meter{
+			{Serial: "1", Digits: 5, Ratio: 40, Lines: 1,
+				Zones: []*Zone{{1, 20, 10, 10, 400, ""}}},
+		}},
+		{Name: "Контора", Lines: 3, Meters: []*Meter{
+			{Serial: "2", Digits: 5, Ratio: 1, Lines: 2,
+				Zones: []*Zone{
+					{1, 15, 10, 5, 5, ""},
+					{2, 12, 10, 2, 2, ""},
+				}},
+			{Serial: "3", Digits: 5, Ratio: 1, Lines: 1,
+				Zones: []*Zone{{1, 99999, 99990, 9, 9, ""}}},
+		}},
+	}
+	places := Group(reports)
+	if diff := cmp.Diff(want, places); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if total := Energy(places); total != 416 {
+		t.Errorf("Energy() want 416, got %d", total)
+	}
+}
+
+func TestSort(t *testing.T) {
+	places := []*Place{{Name: "А"}, {Name: "Б"}, {Name: "В"},
+		{Name: "Г"}}
+	places = Sort(places, []string{"В", "Нема", "А"})
+	var got []string
+	for _, place := range places {
+		got = append(got, place.Name)
+	}
+	want := []string{"В", "А", "Б", "Г"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSortComparison(t *testing.T) {
+	places := []*storage.PlaceComparison{{Name: "А"}, {Name: "Б"},
+		{Name: "В"}}
+	places = SortComparison(places, []string{"Б"})
+	var got []string
+	for _, place := range places {
+		got = append(got, place.Name)
+	}
+	want := []string{"Б", "А", "В"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
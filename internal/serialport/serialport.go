@@ -0,0 +1,19 @@
+// Пакет serialport відкриває послідовний порт для обміну з лічильниками:
+// оптична головка IEC 62056-21 або інтерфейс RS-485 Modbus RTU.
+package serialport
+
+import "github.com/kraserh/energozvit/internal/i18n"
+
+// Час очікування байта при читанні, десяті частини секунди
+const readTimeout = 15
+
+var ErrBaud = i18n.NewError("швидкість порту не підтримується")
+
+// Format є форматом символу: біти даних, парність і стоп-біти.
+type Format int
+
+const (
+	Format7E1 Format = iota // 7 біт, even, 1 стоп-біт (IEC 62056-21)
+	Format8N1               // 8 біт, без парності, 1 стоп-біт
+	Format8E1               // 8 біт, even, 1 стоп-біт
+)
@@ -0,0 +1,91 @@
+//go:build linux
+
+package serialport
+
+import (
+	"golang.org/x/sys/unix"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Швидкості порту
+var speeds = map[int]uint32{
+	300: unix.B300, 600: unix.B600, 1200: unix.B1200, 2400: unix.B2400,
+	4800: unix.B4800, 9600: unix.B9600, 19200: unix.B19200,
+	38400: unix.B38400, 57600: unix.B57600, 115200: unix.B115200,
+}
+
+// Прапорці формату символу
+var formats = map[Format]uint32{
+	Format7E1: unix.CS7 | unix.PARENB,
+	Format8N1: unix.CS8,
+	Format8E1: unix.CS8 | unix.PARENB,
+}
+
+// Port є відкритим послідовним портом.
+type Port struct {
+	fd     int
+	format Format
+}
+
+// Open відкриває послідовний порт path зі швидкістю baud і форматом
+// символу format.
+func Open(path string, baud int, format Format) (*Port, error) {
+	if _, ok := formats[format]; !ok {
+		return nil, i18n.NewError("формат символу не підтримується")
+	}
+	fd, err := unix.Open(path, unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &Port{fd: fd, format: format}
+	if err := p.SetBaud(baud); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	unix.IoctlSetInt(fd, unix.TCFLSH, unix.TCIOFLUSH)
+	return p, nil
+}
+
+// SetBaud встановлює швидкість порту після передачі записаних даних.
+func (p *Port) SetBaud(baud int) error {
+	speed, ok := speeds[baud]
+	if !ok {
+		return ErrBaud
+	}
+	t := &unix.Termios{
+		Iflag:  unix.IGNPAR,
+		Cflag:  formats[p.format] | unix.CREAD | unix.CLOCAL | speed,
+		Ispeed: speed,
+		Ospeed: speed,
+	}
+	t.Cc[unix.VMIN] = 0
+	t.Cc[unix.VTIME] = readTimeout
+	return unix.IoctlSetTermios(p.fd, unix.TCSETSW, t)
+}
+
+// Read читає дані з порту. Повертає 0 байт, якщо за час очікування
+// нічого не прийнято.
+func (p *Port) Read(b []byte) (int, error) {
+	n, err := unix.Read(p.fd, b)
+	if n < 0 {
+		n = 0
+	}
+	return n, err
+}
+
+func (p *Port) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		n, err := unix.Write(p.fd, b[written:])
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func (p *Port) Close() error {
+	return unix.Close(p.fd)
+}
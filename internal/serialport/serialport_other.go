@@ -0,0 +1,30 @@
+//go:build !linux
+
+package serialport
+
+import "github.com/kraserh/energozvit/internal/i18n"
+
+// Port є послідовним портом, який на цій платформі не підтримується.
+type Port struct{}
+
+// Open повертає помилку, бо послідовний порт підтримується тільки в
+// Linux.
+func Open(path string, baud int, format Format) (*Port, error) {
+	return nil, i18n.NewError("послідовний порт підтримується тільки в Linux")
+}
+
+func (p *Port) SetBaud(baud int) error {
+	return ErrBaud
+}
+
+func (p *Port) Read(b []byte) (int, error) {
+	return 0, ErrBaud
+}
+
+func (p *Port) Write(b []byte) (int, error) {
+	return 0, ErrBaud
+}
+
+func (p *Port) Close() error {
+	return nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// MetricsPath є шляхом метрик в текстовому форматі Prometheus.
+const MetricsPath = "/metrics"
+
+// Метрика з описом і значеннями для різних міток. Опис англійською не
+// перекладається, щоб не залежати від мови сервера.
+type metric struct {
+	name    string
+	help    string
+	samples []string
+}
+
+// add додає значення з мітками labels: пари назва, значення.
+func (m *metric) add(value int64, labels ...string) {
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i],
+			escapeLabel(labels[i+1])))
+	}
+	m.samples = append(m.samples, fmt.Sprintf("%s{%s} %s", m.name,
+		strings.Join(pairs, ","), strconv.FormatInt(value, 10)))
+}
+
+// write записує метрику з описом і типом.
+func (m *metric) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", m.name)
+	for _, sample := range m.samples {
+		b.WriteString(sample)
+		b.WriteByte('\n')
+	}
+}
+
+// escapeLabel екранує значення мітки.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).
+		Replace(value)
+}
+
+// Метрики всіх організацій
+type metrics struct {
+	placeEnergy *metric
+	reading     *metric
+	siteEnergy  *metric
+	reportMonth *metric
+	closedAt    *metric
+	missing     *metric
+	next        *metric
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		placeEnergy: &metric{name: "energozvit_place_energy_kwh",
+			help: "Energy consumed by the metering point in the " +
+				"last closed month by tariff zone, kWh."},
+		reading: &metric{name: "energozvit_meter_reading_kwh",
+			help: "Last meter reading, kWh."},
+		siteEnergy: &metric{name: "energozvit_site_energy_kwh",
+			help: "Energy consumed by the organization in the " +
+				"last closed month, kWh."},
+		reportMonth: &metric{
+			name: "energozvit_report_month_timestamp_seconds",
+			help: "Start of the last closed month."},
+		closedAt: &metric{
+			name: "energozvit_month_closed_timestamp_seconds",
+			help: "Time the last month was closed."},
+		missing: &metric{name: "energozvit_missing_readings",
+			help: "Number of readings not entered in the next " +
+				"report."},
+		next: &metric{name: "energozvit_next_readings",
+			help: "Number of readings in the next report."},
+	}
+}
+
+// collect збирає метрики поточної організації.
+func (m *metrics) collect(stor storage.Store) {
+	site := stor.GetSite()
+	next := stor.GetNextDate()
+	date := next.AddDate(0, -1, 0)
+
+	// енергія точок обліку по зонах в порядку звіту
+	type zoneKey struct {
+		name string
+		zone int
+	}
+	energy := make(map[zoneKey]int)
+	eic := make(map[zoneKey]string)
+	keys := make([]zoneKey, 0)
+	total := 0
+	for _, r := range stor.GetReports(date) {
+		zone := strconv.Itoa(r.Zone)
+		m.reading.add(int64(r.CurKwh), "site", site.Name, "place",
+			r.Name, "eic", r.Eic, "serial", r.Serial, "zone", zone)
+		key := zoneKey{r.Name, r.Zone}
+		if _, ok := energy[key]; !ok {
+			keys = append(keys, key)
+			eic[key] = r.Eic
+		}
+		energy[key] += r.Energy
+		total += r.Energy
+	}
+	for _, key := range keys {
+		m.placeEnergy.add(int64(energy[key]), "site", site.Name,
+			"place", key.name, "eic", eic[key],
+			"zone", strconv.Itoa(key.zone))
+	}
+
+	m.siteEnergy.add(int64(total), "site", site.Name)
+	m.reportMonth.add(date.Unix(), "site", site.Name)
+	if !site.ClosedAt.IsZero() {
+		m.closedAt.add(site.ClosedAt.Unix(), "site", site.Name)
+	}
+	m.missing.add(int64(stor.GetMissingReadings()), "site", site.Name)
+	m.next.add(int64(len(stor.GetNextReports())), "site", site.Name)
+}
+
+// String повертає метрики в текстовому форматі Prometheus.
+func (m *metrics) String() string {
+	var b strings.Builder
+	for _, metric := range []*metric{m.placeEnergy, m.reading,
+		m.siteEnergy, m.reportMonth, m.closedAt, m.missing, m.next} {
+		metric.write(&b)
+	}
+	return b.String()
+}
+
+// getMetrics повертає метрики всіх організацій за останній закритий
+// місяць і введення показників наступного звіту.
+func (s *Server) getMetrics(w http.ResponseWriter, r *http.Request) {
+	m := newMetrics()
+	for _, site := range s.stor.GetSites() {
+		s.mustSetSite(site)
+		m.collect(s.stor)
+	}
+	w.Header().Set("Content-Type",
+		"text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, m)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Рядок значення метрики: назва, мітки в лапках, ціле значення
+var sampleLine = regexp.MustCompile(
+	`^([a-z_]+)\{(?:[a-z_]+="(?:[^"\\]|\\.)*"(?:,|\}))+ -?[0-9]+$`)
+
+// scrape читає метрики як Prometheus і перевіряє формат. Повертає
+// рядки значень, назви метрик мають опис і тип перед значеннями.
+func scrape(t *testing.T, url string) []string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	ct := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+
+	samples := make([]string, 0)
+	typed := make(map[string]bool)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# HELP ") {
+			// опис англійською незалежно від мови сервера
+			if strings.IndexFunc(line, func(r rune) bool {
+				return r > unicode.MaxASCII
+			}) >= 0 {
+				t.Errorf("help not in English %q", line)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[3] != "gauge" ||
+				typed[fields[2]] {
+				t.Errorf("bad type line %q", line)
+			}
+			typed[fields[2]] = true
+			continue
+		}
+		match := sampleLine.FindStringSubmatch(line)
+		if match == nil {
+			t.Errorf("bad sample line %q", line)
+			continue
+		}
+		if !typed[match[1]] {
+			t.Errorf("sample without type %q", line)
+		}
+		samples = append(samples, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	ts, stor := createServer(t)
+
+	// Друга організація з точкою обліку, назва якої екранується, і
+	// закритим місяцем
+	err := stor.AddSite(&storage.Site{Name: "Філія"},
+		storage.MakeDate(2022, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SelectSite(stor, "Філія"); err != nil {
+		t.Fatal(err)
+	}
+	err = stor.AddMeter(&storage.Meter{Name: `Склад "А"`, Serial: "7",
+		Digits: 4, Ratio: 1}, []int{10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := stor.GetNextReports()
+	reports[0].CurKwh = 15
+	if err := stor.SaveReports(reports); err != nil {
+		t.Fatal(err)
+	}
+	branchClosed := stor.GetSite().ClosedAt.Unix()
+	if err := storage.SelectSite(stor, storage.DefaultSiteName); err != nil {
+		t.Fatal(err)
+	}
+
+	// Закриття місяця в основній організації
+	body := `{"readings": [{"key": "344848", "kwh": 74},
+		{"key": "001930", "zone": 1, "kwh": 13750},
+		{"key": "001930", "zone": 2, "kwh": 13705}]}`
+	var next jsonNext
+	status := request(t, "POST", ts.URL+Prefix+"/next/readings", body, &next)
+	if status != 200 {
+		t.Fatalf("readings %d: %v", status, next)
+	}
+	var closed jsonClose
+	status = request(t, "POST", ts.URL+Prefix+"/next/close", "", &closed)
+	if status != 200 {
+		t.Fatalf("close %d: %v", status, closed)
+	}
+	mainClosed := stor.GetSite().ClosedAt.Unix()
+
+	samples := scrape(t, ts.URL+MetricsPath)
+	main, branch := `site="Основна"`, `site="Філія"`
+	want := []string{
+		`energozvit_place_energy_kwh{` + main + `,place="Госпдвір",eic="",zone="1"} 400`,
+		`energozvit_place_energy_kwh{` + main + `,place="Контора",eic="",zone="1"} 5`,
+		`energozvit_place_energy_kwh{` + main + `,place="Контора",eic="",zone="2"} 5`,
+		`energozvit_place_energy_kwh{` + branch + `,place="Склад \"А\"",eic="",zone="1"} 5`,
+		`energozvit_meter_reading_kwh{` + main + `,place="Госпдвір",eic="",serial="344848",zone="1"} 74`,
+		`energozvit_meter_reading_kwh{` + main + `,place="Контора",eic="",serial="001930",zone="1"} 13750`,
+		`energozvit_meter_reading_kwh{` + main + `,place="Контора",eic="",serial="001930",zone="2"} 13705`,
+		`energozvit_meter_reading_kwh{` + branch + `,place="Склад \"А\"",eic="",serial="7",zone="1"} 15`,
+		`energozvit_site_energy_kwh{` + main + `} 410`,
+		`energozvit_site_energy_kwh{` + branch + `} 5`,
+		`energozvit_report_month_timestamp_seconds{` + main + `} 1646092800`,
+		`energozvit_report_month_timestamp_seconds{` + branch + `} 1646092800`,
+		`energozvit_month_closed_timestamp_seconds{` + main + `} ` +
+			strconv.FormatInt(mainClosed, 10),
+		`energozvit_month_closed_timestamp_seconds{` + branch + `} ` +
+			strconv.FormatInt(branchClosed, 10),
+		`energozvit_missing_readings{` + main + `} 3`,
+		`energozvit_missing_readings{` + branch + `} 1`,
+		`energozvit_next_readings{` + main + `} 3`,
+		`energozvit_next_readings{` + branch + `} 1`,
+	}
+	if diff := cmp.Diff(want, samples); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	got := escapeLabel("a\\b\"c\"\nd")
+	if want := `a\\b\"c\"\nd`; got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,447 @@
+// Пакет server надає доступ до даних обліку через JSON REST API, щоб
+// інші програми могли читати звіти і передавати показники, не
+// працюючи з файлом бази даних.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Prefix є початком шляху всіх запитів API. Номер версії змінюється,
+// якщо API змінюється несумісно.
+const Prefix = "/api/v1"
+
+// Кількість місяців в сумах спожитої енергії за замовчуванням
+const defaultMonths = 12
+
+var (
+	errNotFound   = i18n.NewError("не знайдено")
+	errMethod     = i18n.NewError("метод не підтримується")
+	errReadOnly   = i18n.NewError("база даних відкрита тільки для читання")
+	errBadDate    = i18n.NewError("дата має бути в форматі YYYY-MM")
+	errBadPeriod  = i18n.NewError("початок періоду пізніше кінця")
+	errBadRequest = i18n.NewError("неправильний запит")
+	errMediaType  = i18n.NewError("запит має бути в форматі application/json")
+	errOrigin     = i18n.NewError("запит з іншого сайту")
+)
+
+// Server обробляє запити API. Запити виконуються по черзі, бо сховище
+// зберігає поточну організацію. Організація вибирається параметром
+// запиту site, без нього використовується організація, яка була
+// поточною при створенні сервера.
+type Server struct {
+	mu   sync.Mutex
+	stor storage.Store
+	site *storage.Site // організація за замовчуванням
+	mux  *http.ServeMux
+}
+
+// New створює сервер для сховища stor.
+func New(stor storage.Store) *Server {
+	s := &Server{
+		stor: stor,
+		site: stor.GetSite(),
+		mux:  http.NewServeMux(),
+	}
+	s.handle("/sites", http.MethodGet, s.getSites)
+	s.handle("/meters", http.MethodGet, s.getMeters)
+	s.handle("/reports/", http.MethodGet, s.getReports)
+	s.handle("/totals", http.MethodGet, s.getTotals)
+	s.handle("/next", http.MethodGet, s.getNext)
+	s.handle("/next/preview", http.MethodPost, s.postPreview)
+	s.handle("/next/readings", http.MethodPost, s.postReadings)
+	s.handle("/next/close", http.MethodPost, s.postClose)
+	s.handlePath(MetricsPath, http.MethodGet, s.getMetrics)
+	s.mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusNotFound, errNotFound)
+	})
+	return s
+}
+
+// Handle додає обробник запитів поза API, наприклад вебінтерфейс.
+// Обробник виконується так само по черзі з вибраною організацією.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP виконує запит. Паніка сховища повертається як внутрішня
+// помилка сервера.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			writeError(w, http.StatusInternalServerError,
+				fmt.Errorf("%v", err))
+		}
+	}()
+
+	err := s.selectSite(r.URL.Query().Get("site"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle додає обробник запитів API з методом method.
+func (s *Server) handle(path, method string, h http.HandlerFunc) {
+	s.handlePath(Prefix+path, method, h)
+}
+
+// handlePath додає обробник запитів з шляхом pattern і методом method.
+func (s *Server) handlePath(pattern, method string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, errMethod)
+			return
+		}
+		if method != http.MethodGet && s.stor.ReadOnly() {
+			writeError(w, http.StatusForbidden, errReadOnly)
+			return
+		}
+		if method != http.MethodGet {
+			status, err := checkWrite(r)
+			if err != nil {
+				writeError(w, status, err)
+				return
+			}
+		}
+		h(w, r)
+	})
+}
+
+// checkWrite перевіряє, що запит на зміну даних не надіслано з іншого
+// сайту (CSRF). Форма чи сторінка з іншого сайту не може надіслати
+// application/json без дозволу CORS, а Origin, якщо браузер його
+// вказав, має збігатися з адресою сервера.
+func checkWrite(r *http.Request) (int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return http.StatusUnsupportedMediaType, errMediaType
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return http.StatusForbidden, errOrigin
+		}
+	}
+	return 0, nil
+}
+
+// selectSite робить поточною організацію name або організацію за
+// замовчуванням.
+func (s *Server) selectSite(name string) error {
+	if name == "" {
+		return s.stor.SetSite(s.site)
+	}
+	return storage.SelectSite(s.stor, name)
+}
+
+//-------------------------- REPORT FUNCTIONS --------------------------
+
+// Організація в JSON
+type jsonSite struct {
+	Name     string `json:"name"`
+	NextDate string `json:"next_date"`
+}
+
+// getSites повертає організації і дати їх наступних звітів.
+func (s *Server) getSites(w http.ResponseWriter, r *http.Request) {
+	sites := make([]jsonSite, 0)
+	for _, site := range s.stor.GetSites() {
+		s.mustSetSite(site)
+		sites = append(sites, jsonSite{site.Name,
+			monthString(s.stor.GetNextDate())})
+	}
+	writeJSON(w, http.StatusOK, sites)
+}
+
+// getMeters повертає діючі лічильники.
+func (s *Server) getMeters(w http.ResponseWriter, r *http.Request) {
+	s.export(w, func(opts exchange.Options) error {
+		return exchange.ExportMeters(w, s.stor, opts)
+	})
+}
+
+// getReports повертає звіт за місяць з шляху /reports/YYYY-MM.
+func (s *Server) getReports(w http.ResponseWriter, r *http.Request) {
+	month := strings.TrimPrefix(r.URL.Path, Prefix+"/reports/")
+	date, err := parseMonth(month)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.export(w, func(opts exchange.Options) error {
+		return exchange.ExportReports(w, s.stor, date, date, opts)
+	})
+}
+
+// getTotals повертає суми спожитої енергії по місяцях з параметра from
+// по to. За замовчуванням останні 12 закритих місяців.
+func (s *Server) getTotals(w http.ResponseWriter, r *http.Request) {
+	from, to := storage.LastMonths(s.stor, defaultMonths)
+	query := r.URL.Query()
+	var err error
+	if value := query.Get("from"); value != "" {
+		from, err = parseMonth(value)
+	}
+	if value := query.Get("to"); value != "" && err == nil {
+		to, err = parseMonth(value)
+	}
+	if err == nil && from.After(to) {
+		err = errBadPeriod
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	s.export(w, func(opts exchange.Options) error {
+		return exchange.ExportTotals(w, s.stor, from, to, opts)
+	})
+}
+
+// export записує дані функцією експорту в JSON.
+func (s *Server) export(w http.ResponseWriter, write func(exchange.Options) error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	err := write(exchange.Options{Format: exchange.FormatJSON})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+//----------------------- NEXT REPORT FUNCTIONS ------------------------
+
+// Показник наступного звіту в JSON
+type jsonReading struct {
+	Serial     string `json:"serial"`
+	Name       string `json:"name"`
+	Zone       int    `json:"zone"`
+	Digits     int    `json:"digits"`
+	CurKwh     int    `json:"cur_kwh"`
+	PreKwh     int    `json:"pre_kwh"`
+	Energy     int    `json:"energy"`
+	Annotation string `json:"annotation"`
+}
+
+// Наступний звіт в JSON
+type jsonNext struct {
+	Site     string        `json:"site"`
+	Date     string        `json:"date"`
+	ReadOnly bool          `json:"read_only"`
+	Reports  []jsonReading `json:"reports"`
+	Total    int           `json:"total"`
+}
+
+// getNext повертає наступний звіт з введеними показниками.
+func (s *Server) getNext(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.next(s.stor.GetNextReports()))
+}
+
+// Показник в запиті: номер лічильника або назва точки обліку, зона
+// (пуста зона означає першу), показник і примітка.
+type jsonRow struct {
+	Key        string `json:"key"`
+	Zone       int    `json:"zone"`
+	Kwh        int    `json:"kwh"`
+	Annotation string `json:"annotation"`
+}
+
+// Запит з показниками
+type jsonReadings struct {
+	Readings []jsonRow `json:"readings"`
+}
+
+// postPreview заносить показники в наступний звіт і рахує спожиту
+// енергію, нічого не зберігаючи.
+func (s *Server) postPreview(w http.ResponseWriter, r *http.Request) {
+	reports, _, ok := s.apply(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.next(reports))
+}
+
+// postReadings зберігає показники наступного звіту без закриття місяця.
+// Якщо хоч один показник не підходить, не зберігається жоден.
+func (s *Server) postReadings(w http.ResponseWriter, r *http.Request) {
+	_, changed, ok := s.apply(w, r)
+	if !ok {
+		return
+	}
+	err := s.stor.SaveDrafts(changed)
+	if err != nil {
+		writeStoreError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.next(s.stor.GetNextReports()))
+}
+
+// apply заносить показники з запиту в наступний звіт. Повертає весь
+// звіт і змінені рядки. Якщо запит неправильний або хоч один показник
+// не підходить, записує помилку у відповідь.
+func (s *Server) apply(w http.ResponseWriter, r *http.Request) (reports, changed []*storage.Report, ok bool) {
+	var request jsonReadings
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest,
+			fmt.Errorf("%w: %s", errBadRequest, err))
+		return nil, nil, false
+	}
+	rows := make([]*exchange.Row, 0)
+	for i, reading := range request.Readings {
+		if reading.Zone == 0 {
+			reading.Zone = 1
+		}
+		rows = append(rows, &exchange.Row{
+			Line:       i + 1,
+			Key:        reading.Key,
+			Zone:       reading.Zone,
+			Kwh:        reading.Kwh,
+			Annotation: reading.Annotation,
+		})
+	}
+
+	reports = s.stor.GetNextReports()
+	changed, errs := exchange.Apply(reports, rows)
+	if len(errs) > 0 {
+		writeRowErrors(w, errs)
+		return nil, nil, false
+	}
+	return reports, changed, true
+}
+
+// Результат закриття місяця
+type jsonClose struct {
+	Site     string `json:"site"`
+	Closed   string `json:"closed"`
+	NextDate string `json:"next_date"`
+}
+
+// postClose закриває місяць зі збереженими показниками. Нові показники
+// не зберігаються, бо форма GetNextReports заповнена попередніми
+// показниками там, де їх не введено. Якщо введені не всі показники,
+// місяць не закривається.
+func (s *Server) postClose(w http.ResponseWriter, r *http.Request) {
+	date := s.stor.GetNextDate()
+	err := s.stor.SaveReports([]*storage.Report{})
+	if err != nil {
+		writeStoreError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, jsonClose{
+		Site:     s.stor.GetSite().Name,
+		Closed:   monthString(date),
+		NextDate: monthString(s.stor.GetNextDate()),
+	})
+}
+
+// next повертає наступний звіт поточної організації з рядками reports.
+func (s *Server) next(reports []*storage.Report) jsonNext {
+	next := jsonNext{
+		Site:     s.stor.GetSite().Name,
+		Date:     monthString(s.stor.GetNextDate()),
+		ReadOnly: s.stor.ReadOnly(),
+		Reports:  make([]jsonReading, 0),
+		Total:    s.stor.GetNextTotal(reports),
+	}
+	for _, r := range reports {
+		next.Reports = append(next.Reports, jsonReading{r.Serial,
+			r.Name, r.Zone, r.Digits, r.CurKwh, r.PreKwh, r.Energy,
+			r.Annotation})
+	}
+	return next
+}
+
+// mustSetSite робить поточною організацію зі списку GetSites.
+func (s *Server) mustSetSite(site *storage.Site) {
+	if err := s.stor.SetSite(site); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------- RESPONSE FUNCTIONS -------------------------
+
+// Помилка в JSON
+type jsonError struct {
+	Error string         `json:"error"`
+	Rows  []jsonRowError `json:"rows,omitempty"`
+}
+
+// Показник з запиту, який не вдалось зберегти
+type jsonRowError struct {
+	Index int    `json:"index"` // номер показника в запиті, з нуля
+	Key   string `json:"key"`
+	Zone  int    `json:"zone"`
+	Error string `json:"error"`
+}
+
+// writeJSON записує відповідь в JSON з відступами.
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		log.Print(err)
+	}
+}
+
+// writeError записує помилку з кодом status.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, jsonError{Error: err.Error()})
+}
+
+// writeStoreError записує помилку сховища. Порушення обмежень схеми
+// бази даних повертається з кодом status, інші помилки як внутрішня
+// помилка сервера.
+func writeStoreError(w http.ResponseWriter, status int, err error) {
+	if !storage.IsConstraint(err) {
+		status = http.StatusInternalServerError
+	}
+	writeError(w, status, err)
+}
+
+// writeRowErrors записує показники, які не підходять до наступного
+// звіту.
+func writeRowErrors(w http.ResponseWriter, errs []*exchange.RowError) {
+	data := jsonError{
+		Error: i18n.Sprintf("не вдалось зберегти показників: %d",
+			len(errs)),
+		Rows: make([]jsonRowError, 0),
+	}
+	for _, e := range errs {
+		data.Rows = append(data.Rows, jsonRowError{e.Row.Line - 1,
+			e.Row.Key, e.Row.Zone, e.Err.Error()})
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, data)
+}
+
+// parseMonth розбирає дату в форматі YYYY-MM.
+func parseMonth(value string) (time.Time, error) {
+	date, err := storage.DateParse(value)
+	if err != nil {
+		return date, errBadDate
+	}
+	return date, nil
+}
+
+// monthString повертає місяць в форматі YYYY-MM.
+func monthString(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
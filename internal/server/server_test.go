@@ -0,0 +1,280 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// createServer створює сервер зі сховищем в памʼяті з двома
+// лічильниками, наступний звіт за 2022-03.
+func createServer(t *testing.T) (*httptest.Server, storage.Store) {
+	stor := storage.NewMemory(storage.MakeDate(2022, 3))
+	err := stor.AddMeter(&storage.Meter{Name: "Госпдвір",
+		Serial: "344848", Digits: 4, Ratio: 40}, []int{64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stor.AddMeter(&storage.Meter{Name: "Контора", Serial: "001930",
+		Digits: 5, Ratio: 1}, []int{13745, 13700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(New(stor))
+	t.Cleanup(ts.Close)
+	return ts, stor
+}
+
+// request виконує запит і розбирає відповідь в JSON в data.
+func request(t *testing.T, method, url, body string, data any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method != "GET" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct,
+		"application/json") {
+		t.Errorf("%s %s: content type %q", method, url, ct)
+	}
+	err = json.NewDecoder(resp.Body).Decode(data)
+	if err != nil {
+		t.Fatalf("%s %s: %s", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestGet(t *testing.T) {
+	ts, _ := createServer(t)
+
+	var sites []jsonSite
+	status := request(t, "GET", ts.URL+Prefix+"/sites", "", &sites)
+	want := []jsonSite{{storage.DefaultSiteName, "2022-03"}}
+	if diff := cmp.Diff(want, sites); status != 200 || diff != "" {
+		t.Errorf("sites %d mismatch (-want +got):\n%s", status, diff)
+	}
+
+	var meters struct {
+		Meters []struct{ Serial string }
+	}
+	status = request(t, "GET", ts.URL+Prefix+"/meters", "", &meters)
+	if status != 200 || len(meters.Meters) != 2 ||
+		meters.Meters[1].Serial != "001930" {
+		t.Errorf("meters %d: %v", status, meters)
+	}
+
+	var next jsonNext
+	status = request(t, "GET", ts.URL+Prefix+"/next", "", &next)
+	if status != 200 || next.Date != "2022-03" || len(next.Reports) != 3 {
+		t.Errorf("next %d: %v", status, next)
+	}
+}
+
+func TestPostReadings(t *testing.T) {
+	ts, stor := createServer(t)
+	url := ts.URL + Prefix + "/next/readings"
+
+	// Один показник не підходить, не зберігається жоден
+	var e jsonError
+	body := `{"readings": [{"key": "344848", "kwh": 74},
+		{"key": "Контора", "zone": 3, "kwh": 13750}]}`
+	status := request(t, "POST", url, body, &e)
+	wantErr := jsonError{"не вдалось зберегти показників: 1",
+		[]jsonRowError{{1, "Контора", 3, "лічильник не має такої зони"}}}
+	if diff := cmp.Diff(wantErr, e); status != 422 || diff != "" {
+		t.Errorf("status %d mismatch (-want +got):\n%s", status, diff)
+	}
+	if stor.GetNextReports()[0].CurKwh != 64 {
+		t.Error("readings saved with errors")
+	}
+
+	// Порушення обмеження схеми
+	e = jsonError{}
+	body = `{"readings": [{"key": "344848", "kwh": 74,
+		"annotation": "` + strings.Repeat("я", 33) + `"}]}`
+	status = request(t, "POST", url, body, &e)
+	if status != 422 || !strings.Contains(e.Error, "constraint") {
+		t.Errorf("constraint %d: %v", status, e)
+	}
+
+	// Неправильний запит
+	status = request(t, "POST", url, `{"kwh": 1}`, &e)
+	if status != 400 {
+		t.Errorf("bad request %d: %v", status, e)
+	}
+
+	// Енергія порахована, показники не збережено
+	var next jsonNext
+	body = `{"readings": [{"key": "Госпдвір", "kwh": 74}]}`
+	status = request(t, "POST", ts.URL+Prefix+"/next/preview", body,
+		&next)
+	if status != 200 || next.Total != 400 || next.Reports[0].Energy != 400 {
+		t.Errorf("preview %d: %v", status, next)
+	}
+	if stor.GetNextReports()[0].CurKwh != 64 {
+		t.Error("preview saved readings")
+	}
+
+	// Збережено без закриття місяця
+	body = `{"readings": [{"key": "344848", "kwh": 74,
+		"annotation": "Примітка"}]}`
+	status = request(t, "POST", url, body, &next)
+	want := jsonReading{"344848", "Госпдвір", 1, 4, 74, 64, 400,
+		"Примітка"}
+	if status != 200 || len(next.Reports) != 3 {
+		t.Fatalf("status %d: %v", status, next)
+	}
+	if diff := cmp.Diff(want, next.Reports[0]); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if !stor.GetNextDate().Equal(storage.MakeDate(2022, 3)) {
+		t.Error("month closed")
+	}
+}
+
+func TestPostClose(t *testing.T) {
+	ts, stor := createServer(t)
+	url := ts.URL + Prefix + "/next/close"
+
+	// Не всі показники введені
+	var e jsonError
+	status := request(t, "POST", url, "", &e)
+	if status != 409 {
+		t.Errorf("close with missing readings %d: %v", status, e)
+	}
+
+	body := `{"readings": [{"key": "344848", "kwh": 74},
+		{"key": "001930", "zone": 1, "kwh": 13750},
+		{"key": "001930", "zone": 2, "kwh": 13705}]}`
+	var next jsonNext
+	status = request(t, "POST", ts.URL+Prefix+"/next/readings", body, &next)
+	if status != 200 || next.Total != 410 {
+		t.Fatalf("readings %d: %v", status, next)
+	}
+
+	var closed jsonClose
+	status = request(t, "POST", url, "", &closed)
+	want := jsonClose{storage.DefaultSiteName, "2022-03", "2022-04"}
+	if diff := cmp.Diff(want, closed); status != 200 || diff != "" {
+		t.Errorf("close %d mismatch (-want +got):\n%s", status, diff)
+	}
+	if stor.GetTotal(storage.MakeDate(2022, 3),
+		storage.MakeDate(2022, 3)) != 410 {
+		t.Error("reports not saved")
+	}
+
+	var totals struct {
+		Months []struct {
+			Date  string
+			Total int
+		}
+		Total int
+	}
+	status = request(t, "GET", ts.URL+Prefix+"/totals?from=2022-03",
+		"", &totals)
+	if status != 200 || totals.Total != 410 || len(totals.Months) != 1 {
+		t.Errorf("totals %d: %v", status, totals)
+	}
+	var reports struct {
+		Months []struct {
+			Reports []struct{ Energy int }
+		}
+	}
+	status = request(t, "GET", ts.URL+Prefix+"/reports/2022-03", "",
+		&reports)
+	if status != 200 || len(reports.Months) != 1 ||
+		len(reports.Months[0].Reports) != 3 {
+		t.Errorf("reports %d: %v", status, reports)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ts, _ := createServer(t)
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/reports/2022", 400},
+		{"GET", "/totals?from=2022-05&to=2022-03", 400},
+		{"GET", "/meters?site=Філія", 404},
+		{"GET", "/unknown", 404},
+		{"POST", "/meters", 405},
+		{"GET", "/next/close", 405},
+	}
+	for _, test := range tests {
+		var e jsonError
+		status := request(t, test.method, ts.URL+Prefix+test.path, "",
+			&e)
+		if status != test.status || e.Error == "" {
+			t.Errorf("%s %s: want %d, got %d %v", test.method,
+				test.path, test.status, status, e)
+		}
+	}
+}
+
+func TestCrossSite(t *testing.T) {
+	ts, stor := createServer(t)
+	tests := []struct {
+		contentType, origin string
+		status              int
+	}{
+		{"text/plain", "", 415},
+		{"", "", 415},
+		{"application/x-www-form-urlencoded", "", 415},
+		{"application/json", "http://example.com", 403},
+		{"application/json; charset=utf-8", ts.URL, 200},
+	}
+	body := `{"readings": [{"key": "344848", "kwh": 70}]}`
+	for _, test := range tests {
+		req, err := http.NewRequest("POST", ts.URL+Prefix+
+			"/next/preview", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%q %q: want %d, got %d", test.contentType,
+				test.origin, test.status, resp.StatusCode)
+		}
+	}
+
+	// відхилений запит не закриває місяць
+	req, err := http.NewRequest("POST", ts.URL+Prefix+"/next/close", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 415 || !stor.GetNextDate().Equal(
+		storage.MakeDate(2022, 3)) {
+		t.Errorf("text/plain close: %d, next date %v", resp.StatusCode,
+			stor.GetNextDate())
+	}
+}
@@ -0,0 +1,182 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+const odsMimetype = "application/vnd.oasis.opendocument.spreadsheet"
+
+// Простори імен OpenDocument
+const odsNamespaces = ` xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+	` xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+	` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"` +
+	` xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+	` xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"` +
+	` xmlns:of="urn:oasis:names:tc:opendocument:xmlns:of:1.2"`
+
+// WriteODS записує книгу в форматі ODS.
+func (wb *Workbook) WriteODS(w io.Writer) error {
+	if len(wb.Sheets) == 0 {
+		return errEmptyWorkbook
+	}
+	archive := zip.NewWriter(w)
+
+	// mimetype має бути першим і не стиснутим
+	f, err := archive.CreateHeader(&zip.FileHeader{
+		Name:   "mimetype",
+		Method: zip.Store,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, odsMimetype)
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"META-INF/manifest.xml", odsManifest()},
+		{"content.xml", odsContent(wb.grids())},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(file.data)
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func odsManifest() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<manifest:manifest xmlns:manifest="urn:oasis:names:` +
+		`tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">`)
+	fmt.Fprintf(&b, `<manifest:file-entry manifest:full-path="/" `+
+		`manifest:version="1.2" manifest:media-type="%s"/>`, odsMimetype)
+	b.WriteString(`<manifest:file-entry manifest:full-path="content.xml" ` +
+		`manifest:media-type="text/xml"/>`)
+	b.WriteString(`</manifest:manifest>`)
+	return b.Bytes()
+}
+
+// odsStyles повертає автоматичні стилі комірок в порядку констант
+// style*, та стилі колонок.
+func odsStyles(grids []*grid) string {
+	styles := []string{
+		``,
+		`<style:text-properties fo:font-weight="bold"/>`,
+		`<style:table-cell-properties style:vertical-align="middle"/>`,
+		`<style:table-cell-properties style:vertical-align="middle" ` +
+			`fo:wrap-option="wrap"/><style:paragraph-properties ` +
+			`fo:text-align="center"/><style:text-properties ` +
+			`fo:font-weight="bold"/>`,
+	}
+	var b bytes.Buffer
+	b.WriteString(`<office:automatic-styles>`)
+	for i, props := range styles {
+		fmt.Fprintf(&b, `<style:style style:name="ce%d" `+
+			`style:family="table-cell">%s</style:style>`, i, props)
+	}
+	for i, g := range grids {
+		for j, width := range g.widths {
+			// Ширина символу приблизно 0.2 см
+			fmt.Fprintf(&b, `<style:style style:name="co%d_%d" `+
+				`style:family="table-column"><style:table-column-`+
+				`properties style:column-width="%.2fcm"/>`+
+				`</style:style>`, i, j, width*0.2)
+		}
+	}
+	b.WriteString(`</office:automatic-styles>`)
+	return b.String()
+}
+
+func odsContent(grids []*grid) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<office:document-content%s office:version="1.2">`,
+		odsNamespaces)
+	b.WriteString(odsStyles(grids))
+	b.WriteString(`<office:body><office:spreadsheet>`)
+	for i, g := range grids {
+		odsTable(&b, i, g)
+	}
+	b.WriteString(`</office:spreadsheet></office:body>`)
+	b.WriteString(`</office:document-content>`)
+	return b.Bytes()
+}
+
+func odsTable(b *bytes.Buffer, index int, g *grid) {
+	fmt.Fprintf(b, `<table:table table:name="%s">`, escape(g.name))
+	for j := range g.widths {
+		fmt.Fprintf(b, `<table:table-column table:style-name="co%d_%d"/>`,
+			index, j)
+	}
+	covered := g.covered()
+	for r, row := range g.cells {
+		b.WriteString(`<table:table-row>`)
+		for c, cell := range row {
+			switch {
+			case covered[[2]int{r, c}]:
+				b.WriteString(`<table:covered-table-cell/>`)
+				continue
+			case cell == nil:
+				b.WriteString(`<table:table-cell/>`)
+				continue
+			}
+			fmt.Fprintf(b, `<table:table-cell table:style-name="ce%d"`,
+				cell.style)
+			if cell.rows > 1 || cell.cols > 1 {
+				fmt.Fprintf(b, ` table:number-rows-spanned="%d"`+
+					` table:number-columns-spanned="%d"`,
+					cell.rows, cell.cols)
+			}
+			if cell.formula != "" {
+				fmt.Fprintf(b, ` table:formula="%s"`,
+					escape(odsFormula(cell.formula)))
+			}
+			if cell.isNumber {
+				fmt.Fprintf(b, ` office:value-type="float"`+
+					` office:value="%d"><text:p>%d</text:p>`,
+					cell.value, cell.value)
+			} else {
+				fmt.Fprintf(b, ` office:value-type="string">`+
+					`<text:p>%s</text:p>`, escape(cell.text))
+			}
+			b.WriteString(`</table:table-cell>`)
+		}
+		b.WriteString(`</table:table-row>`)
+	}
+	b.WriteString(`</table:table>`)
+}
+
+// Посилання на комірку або діапазон в нотації A1, можливо з аркушем
+var refRegexp = regexp.MustCompile(
+	`(?:'([^']+)'!)?([A-Z]+[0-9]+)(?::([A-Z]+[0-9]+))?`)
+
+// odsFormula перетворює формулу з нотації A1 в нотацію OpenFormula.
+func odsFormula(formula string) string {
+	converted := refRegexp.ReplaceAllStringFunc(formula, func(ref string) string {
+		m := refRegexp.FindStringSubmatch(ref)
+		sheet := ""
+		if m[1] != "" {
+			sheet = "'" + m[1] + "'"
+		}
+		if m[3] != "" {
+			return fmt.Sprintf("[%s.%s:.%s]", sheet, m[2], m[3])
+		}
+		return fmt.Sprintf("[%s.%s]", sheet, m[2])
+	})
+	return "of:=" + converted
+}
@@ -0,0 +1,290 @@
+// Пакет spreadsheet створює звіт про спожиту енергію в форматах XLSX
+// (Office Open XML) та ODS (OpenDocument) без зовнішніх програм.
+package spreadsheet
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/report"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Sheet є аркушем з місячним звітом.
+type Sheet struct {
+	Date   time.Time
+	Places []*report.Place
+}
+
+// Workbook є книгою зі звітами організації за кілька місяців. Кожен
+// місяць розміщується на окремому аркуші, а для кількох місяців
+// додається аркуш з сумами по місяцях.
+type Workbook struct {
+	Site   string
+	Sheets []*Sheet
+}
+
+var (
+	ErrUnknownFormat = i18n.NewError("невідомий формат таблиці, " +
+		"очікується .xlsx або .ods")
+	errEmptyWorkbook = i18n.NewError("книга не містить аркушів")
+)
+
+// New створює книгу зі звітами поточної організації за місяці з from по
+// to. Точки обліку впорядковуються функцією report.Sort за order.
+func New(stor storage.Store, from, to time.Time, order []string) *Workbook {
+	wb := &Workbook{Site: stor.GetSite().Name}
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		places := report.Group(stor.GetReports(date))
+		wb.Sheets = append(wb.Sheets, &Sheet{
+			Date:   date,
+			Places: report.Sort(places, order),
+		})
+	}
+	return wb
+}
+
+// Write записує книгу в файл. Формат визначається розширенням файла.
+func (wb *Workbook) Write(path string) error {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		write = wb.WriteXLSX
+	case ".ods":
+		write = wb.WriteODS
+	default:
+		return ErrUnknownFormat
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+//--------------------------- SHEET LAYOUT -----------------------------
+
+// Стилі комірок
+const (
+	styleNormal = iota
+	styleBold   // жирний шрифт
+	styleMiddle // вирівнювання обʼєднаних комірок по центру висоти
+	styleHeader // заголовок колонки
+	styleCount
+)
+
+// Комірка таблиці
+type cell struct {
+	text     string
+	value    int  // число або обчислене значення формули
+	isNumber bool // комірка містить число
+	formula  string
+	style    int
+	rows     int // кількість обʼєднаних рядків
+	cols     int // кількість обʼєднаних колонок
+}
+
+// Таблиця одного аркуша. Формули записуються в нотації A1.
+type grid struct {
+	name   string
+	widths []float64 // ширина колонок в символах
+	cells  [][]*cell
+}
+
+func (g *grid) set(row, col int, c *cell) {
+	for len(g.cells) <= row {
+		g.cells = append(g.cells, make([]*cell, len(g.widths)))
+	}
+	if c.rows == 0 {
+		c.rows = 1
+	}
+	if c.cols == 0 {
+		c.cols = 1
+	}
+	g.cells[row][col] = c
+}
+
+// covered повертає комірки, закриті обʼєднаними комірками.
+func (g *grid) covered() map[[2]int]bool {
+	covered := make(map[[2]int]bool)
+	for r, row := range g.cells {
+		for c, cell := range row {
+			if cell == nil {
+				continue
+			}
+			for i := 0; i < cell.rows; i++ {
+				for j := 0; j < cell.cols; j++ {
+					if i > 0 || j > 0 {
+						covered[[2]int{r + i, c + j}] = true
+					}
+				}
+			}
+		}
+	}
+	return covered
+}
+
+// cellName повертає адресу комірки в нотації A1.
+func cellName(row, col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return fmt.Sprintf("%s%d", name, row+1)
+}
+
+func text(s string, style int) *cell {
+	return &cell{text: s, style: style}
+}
+
+func number(v int) *cell {
+	return &cell{value: v, isNumber: true}
+}
+
+func formula(f string, v int) *cell {
+	return &cell{formula: f, value: v, isNumber: true}
+}
+
+// Колонки місячного звіту
+const (
+	colNum = iota
+	colSubstation
+	colPlace
+	colSerial
+	colZone
+	colCurKwh
+	colPrevKwh
+	colDiff
+	colRatio
+	colEnergy
+	colAnnotation
+	colCount
+)
+
+var (
+	monthHeader = []string{"№ п/п", "№ КТП", "Місце встановлення",
+		"№ лічильника", "Зона", "Теперешні", "Попередні", "Різниця",
+		"Коеф. тр-ції", "Всього, кВт·год", "Примітка"}
+	monthWidths = []float64{6, 7, 24, 16, 6, 11, 11, 10, 8, 12, 20}
+)
+
+// monthGrid розміщує місячний звіт на аркуші. Повертає аркуш і адресу
+// комірки з сумою за місяць.
+func (wb *Workbook) monthGrid(sheet *Sheet) (*grid, string) {
+	g := &grid{
+		name:   sheetName(sheet.Date),
+		widths: monthWidths,
+	}
+	title := i18n.Sprintf("%s: звіт про використану електроенергію "+
+		"за %s %d року", wb.Site,
+		i18n.T(report.MonthName(sheet.Date.Month())),
+		sheet.Date.Year())
+	g.set(0, 0, &cell{text: title, style: styleBold, cols: colCount})
+	for col, name := range monthHeader {
+		g.set(1, col, text(i18n.T(name), styleHeader))
+	}
+
+	// Точки обліку, лічильники і тарифні зони
+	first := 2
+	row := first
+	for i, place := range sheet.Places {
+		merged := func(c *cell, rows int) *cell {
+			c.rows = rows
+			c.style = styleMiddle
+			return c
+		}
+		g.set(row, colNum, merged(number(i+1), place.Lines))
+		g.set(row, colSubstation,
+			merged(number(place.Substation), place.Lines))
+		g.set(row, colPlace, merged(text(place.Name, 0), place.Lines))
+		for _, meter := range place.Meters {
+			ratio := cellName(row, colRatio)
+			g.set(row, colSerial,
+				merged(text(meter.Serial, 0), meter.Lines))
+			g.set(row, colRatio,
+				merged(number(meter.Ratio), meter.Lines))
+			for _, zone := range meter.Zones {
+				g.set(row, colZone, number(zone.Number))
+				g.set(row, colCurKwh, number(zone.CurKwh))
+				g.set(row, colPrevKwh, number(zone.PrevKwh))
+				g.set(row, colDiff, number(zone.Diff))
+				g.set(row, colEnergy, formula(
+					cellName(row, colDiff)+"*"+ratio,
+					zone.Energy))
+				g.set(row, colAnnotation,
+					text(zone.Annotation, 0))
+				row++
+			}
+		}
+	}
+
+	// Сума за місяць
+	total := report.Energy(sheet.Places)
+	g.set(row, 0, &cell{text: i18n.T("Всього"), style: styleBold,
+		cols: colEnergy})
+	sum := number(total)
+	if row > first {
+		sum = formula(fmt.Sprintf("SUM(%s:%s)",
+			cellName(first, colEnergy), cellName(row-1, colEnergy)),
+			total)
+	}
+	sum.style = styleBold
+	g.set(row, colEnergy, sum)
+	return g, cellName(row, colEnergy)
+}
+
+// summaryGrid розміщує суми по місяцях з посиланнями на аркуші
+// місячних звітів.
+func (wb *Workbook) summaryGrid(totals []string) *grid {
+	g := &grid{
+		name:   i18n.T("Всього"),
+		widths: []float64{12, 16},
+	}
+	g.set(0, 0, text(i18n.T("Місяць"), styleHeader))
+	g.set(0, 1, text(i18n.T("Всього, кВт·год"), styleHeader))
+	var total int
+	for i, sheet := range wb.Sheets {
+		energy := report.Energy(sheet.Places)
+		total += energy
+		ref := fmt.Sprintf("'%s'!%s", sheetName(sheet.Date), totals[i])
+		g.set(i+1, 0, text(sheetName(sheet.Date), 0))
+		g.set(i+1, 1, formula(ref, energy))
+	}
+	last := len(wb.Sheets)
+	g.set(last+1, 0, text(i18n.T("Всього"), styleBold))
+	sum := formula(fmt.Sprintf("SUM(%s:%s)", cellName(1, 1),
+		cellName(last, 1)), total)
+	sum.style = styleBold
+	g.set(last+1, 1, sum)
+	return g
+}
+
+// grids розміщує всі аркуші книги.
+func (wb *Workbook) grids() []*grid {
+	grids := make([]*grid, 0, len(wb.Sheets)+1)
+	totals := make([]string, 0, len(wb.Sheets))
+	for _, sheet := range wb.Sheets {
+		g, total := wb.monthGrid(sheet)
+		grids = append(grids, g)
+		totals = append(totals, total)
+	}
+	if len(wb.Sheets) > 1 {
+		grids = append(grids, wb.summaryGrid(totals))
+	}
+	return grids
+}
+
+// sheetName повертає назву аркуша в форматі YYYY-MM.
+func sheetName(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
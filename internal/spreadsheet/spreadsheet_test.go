@@ -0,0 +1,183 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// createWorkbook створює книгу за два місяці з лічильниками Госпдвір
+// (одна зона) та Контора (дві зони).
+func createWorkbook(t *testing.T) *Workbook {
+	stor := storage.NewMemory(storage.MakeDate(2022, 3))
+	err := stor.AddMeter(&storage.Meter{Name: "Госпдвір",
+		Substation: 208, Serial: "344848", Digits: 4, Ratio: 40},
+		[]int{64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stor.AddMeter(&storage.Meter{Name: "Контора", Serial: "001930",
+		Digits: 5, Ratio: 1}, []int{13745, 13700})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for month := 0; month < 2; month++ {
+		reports := stor.GetNextReports()
+		for _, r := range reports {
+			r.CurKwh += 10
+		}
+		err := stor.SaveReports(reports)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(stor, storage.MakeDate(2022, 3), storage.MakeDate(2022, 4),
+		[]string{"Контора"})
+}
+
+// readZip читає файли архіва і перевіряє що XML файли коректні.
+func readZip(t *testing.T, data []byte) (names []string, files map[string]string) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = make(map[string]string)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, file.Name)
+		files[file.Name] = string(content)
+		if !strings.HasSuffix(file.Name, ".xml") &&
+			!strings.HasSuffix(file.Name, ".rels") {
+			continue
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %s", file.Name, err)
+			}
+		}
+	}
+	return names, files
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	err := createWorkbook(t).WriteXLSX(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, buf.Bytes())
+	want := []string{"[Content_Types].xml", "_rels/.rels",
+		"xl/workbook.xml", "xl/_rels/workbook.xml.rels",
+		"xl/styles.xml", "xl/worksheets/sheet1.xml",
+		"xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml"}
+	if diff := cmp.Diff(want, names); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Контора першою, точка обліку обʼєднана на дві зони
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, part := range []string{
+		`<t>Контора</t>`,
+		`<mergeCell ref="A1:K1"/>`,
+		`<mergeCell ref="C3:C4"/>`,
+		`<mergeCell ref="A6:I6"/>`,
+		`<c r="J3" s="0"><f>H3*I3</f><v>10</v></c>`,
+		`<c r="J5" s="0"><f>H5*I5</f><v>400</v></c>`,
+		`<f>SUM(J3:J5)</f><v>420</v>`,
+	} {
+		if !strings.Contains(sheet, part) {
+			t.Errorf("sheet1.xml does not contain %s", part)
+		}
+	}
+	if strings.Index(sheet, "Контора") > strings.Index(sheet, "Госпдвір") {
+		t.Error("places not sorted")
+	}
+	summary := files["xl/worksheets/sheet3.xml"]
+	for _, part := range []string{
+		`<f>&#39;2022-04&#39;!J6</f><v>420</v>`,
+		`<f>SUM(B2:B3)</f><v>840</v>`,
+	} {
+		if !strings.Contains(summary, part) {
+			t.Errorf("sheet3.xml does not contain %s", part)
+		}
+	}
+}
+
+func TestWriteODS(t *testing.T) {
+	var buf bytes.Buffer
+	err := createWorkbook(t).WriteODS(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, files := readZip(t, buf.Bytes())
+	if names[0] != "mimetype" || files["mimetype"] != odsMimetype {
+		t.Error("mimetype is not first file")
+	}
+	content := files["content.xml"]
+	for _, part := range []string{
+		`<table:table table:name="2022-03">`,
+		`<table:table table:name="Всього">`,
+		`table:number-rows-spanned="2" table:number-columns-spanned="1"` +
+			` office:value-type="string"><text:p>Контора</text:p>`,
+		`table:formula="of:=[.H3]*[.I3]"`,
+		`table:formula="of:=SUM([.J3:.J5])"`,
+		`table:formula="of:=[&#39;2022-03&#39;.J6]"`,
+		`<table:covered-table-cell/>`,
+	} {
+		if !strings.Contains(content, part) {
+			t.Errorf("content.xml does not contain %s", part)
+		}
+	}
+}
+
+func TestOdsFormula(t *testing.T) {
+	tests := map[string]string{
+		"H3*I3":         "of:=[.H3]*[.I3]",
+		"SUM(J3:J10)":   "of:=SUM([.J3:.J10])",
+		"'2022-01'!J10": "of:=['2022-01'.J10]",
+	}
+	for formula, want := range tests {
+		if got := odsFormula(formula); got != want {
+			t.Errorf("%s: want %s, got %s", formula, want, got)
+		}
+	}
+}
+
+func TestCellName(t *testing.T) {
+	tests := map[[2]int]string{
+		{0, 0}: "A1", {2, 9}: "J3", {9, 25}: "Z10", {0, 26}: "AA1",
+		{0, 701}: "ZZ1", {0, 702}: "AAA1",
+	}
+	for pos, want := range tests {
+		if got := cellName(pos[0], pos[1]); got != want {
+			t.Errorf("%v: want %s, got %s", pos, want, got)
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	err := createWorkbook(t).Write(t.TempDir() + "/report.pdf")
+	if err != ErrUnknownFormat {
+		t.Errorf("want ErrUnknownFormat, got %v", err)
+	}
+}
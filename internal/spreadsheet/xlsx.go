@@ -0,0 +1,209 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// Простори імен Office Open XML
+const (
+	nsMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRel  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsPkg  = "http://schemas.openxmlformats.org/package/2006/relationships"
+	nsType = "http://schemas.openxmlformats.org/package/2006/content-types"
+)
+
+// WriteXLSX записує книгу в форматі XLSX.
+func (wb *Workbook) WriteXLSX(w io.Writer) error {
+	if len(wb.Sheets) == 0 {
+		return errEmptyWorkbook
+	}
+	grids := wb.grids()
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", xlsxContentTypes(len(grids))},
+		{"_rels/.rels", xlsxRootRels()},
+		{"xl/workbook.xml", xlsxWorkbook(grids)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(grids))},
+		{"xl/styles.xml", xlsxStyles()},
+	}
+	for i, g := range grids {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxSheet(g)})
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(file.data)
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func xlsxContentTypes(sheets int) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<Types xmlns="%s">`, nsType)
+	b.WriteString(`<Default Extension="rels" ContentType="` +
+		`application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="` +
+		`application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="` +
+		`application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-`+
+			`officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.Bytes()
+}
+
+func xlsxRootRels() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<Relationships xmlns="%s">`, nsPkg)
+	fmt.Fprintf(&b, `<Relationship Id="rId1" Type="%s/officeDocument" `+
+		`Target="xl/workbook.xml"/>`, nsRel)
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+func xlsxWorkbook(grids []*grid) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<workbook xmlns="%s" xmlns:r="%s"><sheets>`,
+		nsMain, nsRel)
+	for i, g := range grids {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`,
+			escape(g.name), i+1, i+1)
+	}
+	// Формули перераховуються при відкритті
+	b.WriteString(`</sheets><calcPr fullCalcOnLoad="1"/></workbook>`)
+	return b.Bytes()
+}
+
+func xlsxWorkbookRels(sheets int) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<Relationships xmlns="%s">`, nsPkg)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`, i, nsRel, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s/styles" `+
+		`Target="styles.xml"/>`, sheets+1, nsRel)
+	b.WriteString(`</Relationships>`)
+	return b.Bytes()
+}
+
+// xlsxStyles повертає стилі в порядку констант style*.
+func xlsxStyles() []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<styleSheet xmlns="%s">`, nsMain)
+	b.WriteString(`<fonts count="2">` +
+		`<font><sz val="11"/><name val="Calibri"/></font>` +
+		`<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>`)
+	b.WriteString(`<fills count="2">` +
+		`<fill><patternFill patternType="none"/></fill>` +
+		`<fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/>` +
+		`<bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" ` +
+		`fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(&b, `<cellXfs count="%d">`, styleCount)
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" ` +
+		`xfId="0"/>`)
+	b.WriteString(`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" ` +
+		`xfId="0" applyFont="1"/>`)
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" ` +
+		`xfId="0" applyAlignment="1"><alignment vertical="center"/></xf>`)
+	b.WriteString(`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" ` +
+		`xfId="0" applyFont="1" applyAlignment="1"><alignment ` +
+		`horizontal="center" vertical="center" wrapText="1"/></xf>`)
+	b.WriteString(`</cellXfs><cellStyles count="1"><cellStyle ` +
+		`name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.Bytes()
+}
+
+func xlsxSheet(g *grid) []byte {
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<worksheet xmlns="%s">`, nsMain)
+
+	// Ширина колонок
+	b.WriteString(`<cols>`)
+	for i, width := range g.widths {
+		fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" `+
+			`customWidth="1"/>`, i+1, i+1, width)
+	}
+	b.WriteString(`</cols>`)
+
+	// Комірки
+	var merges []string
+	b.WriteString(`<sheetData>`)
+	for r, row := range g.cells {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			if cell == nil {
+				continue
+			}
+			name := cellName(r, c)
+			if cell.rows > 1 || cell.cols > 1 {
+				merges = append(merges, name+":"+
+					cellName(r+cell.rows-1, c+cell.cols-1))
+			}
+			switch {
+			case cell.formula != "":
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><f>%s</f>`+
+					`<v>%d</v></c>`, name, cell.style,
+					escape(cell.formula), cell.value)
+			case cell.isNumber:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`,
+					name, cell.style, cell.value)
+			default:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr">`+
+					`<is><t>%s</t></is></c>`, name, cell.style,
+					escape(cell.text))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+
+	// Обʼєднані комірки
+	if len(merges) > 0 {
+		fmt.Fprintf(&b, `<mergeCells count="%d">`, len(merges))
+		for _, ref := range merges {
+			fmt.Fprintf(&b, `<mergeCell ref="%s"/>`, ref)
+		}
+		b.WriteString(`</mergeCells>`)
+	}
+	b.WriteString(`</worksheet>`)
+	return b.Bytes()
+}
+
+// escape екранує спеціальні символи XML.
+func escape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Кількість резервних копій, які залишаються після ротації.
+const BackupKeep = 12
+
+// Каталог автоматичних резервних копій, відносно каталогу бази даних.
+const BackupDir = "backup"
+
+// Формат мітки часу в імені резервної копії.
+const backupTimeLayout = "20060102-150405"
+
+// SetAutoBackup встановлює каталог і кількість резервних копій, які
+// створюються перед закриттям місяця в SaveReports. Пустий каталог
+// вимикає автоматичне резервне копіювання.
+func (stor *Storage) SetAutoBackup(dir string, keep int) {
+	stor.backupDir = dir
+	stor.backupKeep = keep
+}
+
+// Backup створює резервну копію бази даних у файлі dest. Копіювання
+// виконується онлайн, база даних може бути відкрита іншими програмами.
+func (stor *Storage) Backup(dest string) error {
+	_, err := os.Stat(dest)
+	if err == nil {
+		return i18n.NewError("файл резервної копії вже існує")
+	}
+	err = copyDatabase(dest, stor.DB)
+	if err == nil {
+		err = singleFile(dest)
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return Verify(dest)
+}
+
+// BackupRotate створює резервну копію з міткою часу в каталозі dir і
+// видаляє найстаріші копії цієї бази даних, залишаючи keep останніх.
+// Копія спочатку записується в тимчасовий файл, тому копія, створена в
+// цю ж секунду, замінюється тільки новою перевіреною копією. Повертає
+// шлях до створеної копії.
+func (stor *Storage) BackupRotate(dir string, keep int) (string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// Резервна копія
+	prefix, ext := backupName(stor.filepath)
+	stamp := time.Now().Format(backupTimeLayout)
+	dest := filepath.Join(dir, prefix+stamp+ext)
+	temp := dest + ".tmp"
+	os.Remove(temp) // залишився від перерваного копіювання
+	err = stor.Backup(temp)
+	if err != nil {
+		os.Remove(temp)
+		return "", err
+	}
+	err = os.Rename(temp, dest)
+	if err != nil {
+		os.Remove(temp)
+		return "", err
+	}
+
+	// Ротація
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"*"+ext))
+	if err != nil {
+		return "", err
+	}
+	var backups []string
+	for _, file := range files {
+		stamp := strings.TrimSuffix(
+			strings.TrimPrefix(filepath.Base(file), prefix), ext)
+		_, err := time.Parse(backupTimeLayout, stamp)
+		if err == nil {
+			backups = append(backups, file)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > keep && keep > 0 {
+		err = os.Remove(backups[0])
+		if err != nil {
+			return "", err
+		}
+		backups = backups[1:]
+	}
+	return dest, nil
+}
+
+// autoBackup створює резервну копію перед закриттям місяця.
+func (stor *Storage) autoBackup() error {
+	if stor.backupDir == "" {
+		return nil
+	}
+	_, err := stor.BackupRotate(stor.backupDir, stor.backupKeep)
+	if err != nil {
+		return i18n.Errorf("резервна копія: %w", err)
+	}
+	return nil
+}
+
+// defaultBackupDir повертає каталог автоматичних резервних копій бази
+// даних dbPath.
+func defaultBackupDir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), BackupDir)
+}
+
+// backupName повертає префікс і розширення імен резервних копій бази
+// даних.
+func backupName(dbPath string) (string, string) {
+	base := filepath.Base(dbPath)
+	ext := filepath.Ext(base)
+	if ext == "" {
+		ext = ".sqlite"
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-", ext
+}
+
+// Restore відновлює базу даних filepath з резервної копії src. Копія
+// попередньо перевіряється функцією Verify, а поточна база даних
+// зберігається в каталог автоматичних резервних копій. Базу даних,
+// відкриту для запису іншою програмою, відновити не можна.
+func Restore(filepath, src string) error {
+	err := Verify(src)
+	if err != nil {
+		return err
+	}
+	lock, err := lockDatabase(filepath)
+	var lockErr *LockError
+	switch {
+	case errors.As(err, &lockErr) && lockErr.own:
+		// відкрита цією програмою
+	case err != nil:
+		return err
+	default:
+		defer lock.release()
+	}
+	err = backupCurrent(filepath)
+	if err != nil {
+		return i18n.Errorf("резервна копія поточної бази даних: %w", err)
+	}
+	db, err := sql.Open("sqlite3", fileURI(src, "ro"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	err = copyDatabase(filepath, db)
+	if err != nil {
+		return err
+	}
+	return Verify(filepath)
+}
+
+// backupCurrent зберігає базу даних filepath, якщо вона є, в каталог
+// автоматичних резервних копій перед відновленням.
+func backupCurrent(filepath string) error {
+	_, err := os.Stat(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", fileURI(filepath, "ro"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	current := &Storage{DB: db, filepath: filepath}
+	_, err = current.BackupRotate(defaultBackupDir(filepath), BackupKeep)
+	return err
+}
+
+// Verify перевіряє цілісність і версію бази даних у файлі filepath.
+// Попередні версії допустимі, вони оновлюються при відкритті.
+func Verify(filepath string) error {
+	_, err := os.Stat(filepath)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", fileURI(filepath, "ro"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Цілісність
+	var result string
+	err = db.QueryRow("PRAGMA integrity_check").Scan(&result)
+	if err != nil {
+		return err
+	}
+	if result != "ok" {
+		return i18n.Errorf("пошкоджена база даних: %s", result)
+	}
+
+	// Версія
+	var version int
+	err = db.QueryRow("PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version < 1 || version > DBVERSION {
+		return i18n.NewError("не підтримувана версія бази даних")
+	}
+	return nil
+}
+
+// copyDatabase копіює базу даних src у файл dest за допомогою online
+// backup API SQLite.
+func copyDatabase(dest string, src *sql.DB) error {
+	ctx := context.Background()
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destSQLite, ok1 := destRaw.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return i18n.NewError("не SQLite зʼєднання")
+			}
+			backup, err := destSQLite.Backup("main",
+				srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			_, err = backup.Step(-1)
+			if err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// singleFile вимикає режим журналу WAL в копії бази даних dest, щоб
+// копія була одним файлом.
+func singleFile(dest string) error {
+	db, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec("PRAGMA journal_mode = DELETE")
+	return err
+}
+
+// fileURI повертає URI файла бази даних з вказаним режимом доступу.
+func fileURI(path, mode string) string {
+	u := url.URL{
+		Scheme:   "file",
+		Opaque:   url.PathEscape(path),
+		RawQuery: "mode=" + mode,
+	}
+	return u.String()
+}
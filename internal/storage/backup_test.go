@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBackupRestore(t *testing.T) {
+	stor := createDatabase(t)
+	dest := filepath.Join(t.TempDir(), "backup.sqlite")
+
+	// Резервна копія
+	err := stor.Backup(dest)
+	if err != nil {
+		t.Fatalf("backup error: %s", err)
+	}
+	err = stor.Backup(dest)
+	if err == nil {
+		t.Error("backup overwrote existing file")
+	}
+
+	// Зміна бази даних після резервного копіювання
+	meters := stor.GetActiveMeters()
+	err = stor.RemoveMeter(meters[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Відновлення з резервної копії
+	err = Restore(stor.GetFilepath(), dest)
+	if err != nil {
+		t.Fatalf("restore error: %s", err)
+	}
+	diff := cmp.Diff(meters, stor.GetActiveMeters(),
+		cmp.AllowUnexported(Meter{}),
+		cmp.FilterPath(func(p cmp.Path) bool {
+			return p.Last().String() == ".id"
+		}, cmp.Ignore()))
+	if diff != "" {
+		t.Errorf("mismatch after restore (-want +got):\n%s", diff)
+	}
+
+	// Копія бази даних перед відновленням
+	saved, err := filepath.Glob(filepath.Join(
+		defaultBackupDir(stor.GetFilepath()), "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("backups before restore want 1, got %v", saved)
+	}
+	before, err := OpenReadOnly(saved[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer before.Close()
+	if n := len(before.GetActiveMeters()); n != len(meters)-1 {
+		t.Errorf("meters before restore want %d, got %d",
+			len(meters)-1, n)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.sqlite")
+	err := os.WriteFile(bad, []byte("not a database"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(bad) == nil {
+		t.Error("verify accepted a broken file")
+	}
+	if Verify(filepath.Join(dir, "missing.sqlite")) == nil {
+		t.Error("verify accepted a missing file")
+	}
+	if Restore(filepath.Join(dir, "restored.sqlite"), bad) == nil {
+		t.Error("restore accepted a broken file")
+	}
+}
+
+func TestBackupRotate(t *testing.T) {
+	stor := createDatabase(t)
+	dir := t.TempDir()
+	prefix, ext := backupName(stor.GetFilepath())
+
+	// Старі резервні копії і сторонній файл
+	old := []string{"20200101-000000", "20200102-000000",
+		"20200103-000000"}
+	for _, stamp := range old {
+		err := stor.Backup(filepath.Join(dir, prefix+stamp+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := filepath.Join(dir, prefix+"other"+ext)
+	err := os.WriteFile(other, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := stor.BackupRotate(dir, 2)
+	if err != nil {
+		t.Fatalf("rotate error: %s", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, prefix+old[2]+ext),
+		dest,
+		other,
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAutoBackup(t *testing.T) {
+	stor := createDatabase(t)
+	dir := filepath.Join(t.TempDir(), BackupDir)
+	stor.SetAutoBackup(dir, 1)
+
+	for i := 0; i < 2; i++ {
+		err := stor.SaveReports(stor.GetNextReports())
+		if err != nil {
+			t.Fatalf("save reports error: %s", err)
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("auto backups want 1, got %d", len(files))
+	}
+
+	// відхилене закриття місяця не створює копію
+	os.RemoveAll(dir)
+	err = stor.SaveReports(nil)
+	if err == nil {
+		t.Fatal("month closed with missing readings")
+	}
+	_, err = os.Stat(dir)
+	if !os.IsNotExist(err) {
+		t.Errorf("backup made for rejected close: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+//-------------------------- BUDGET FUNCTIONS --------------------------
+
+var ErrMissingPlace = i18n.NewError("точку обліку не знайдено")
+
+// Budget є лімітом споживання енергії точки обліку або всієї
+// організації. Місячний ліміт діє з місяця Date до наступного
+// місячного ліміту цієї точки обліку, ліміт 0 скасовує попередній.
+// Річний ліміт діє тільки на рік Date. Ліміт організації порівнюється
+// з енергією всіх точок обліку.
+type Budget struct {
+	Name   string    // точка обліку, пуста для всієї організації
+	Date   time.Time // початок дії ліміту
+	Yearly bool      // річний ліміт
+	Kwh    int
+}
+
+// normalize встановлює дату ліміту на перший місяць його дії.
+func (budget *Budget) normalize() {
+	month := int(budget.Date.Month())
+	if budget.Yearly {
+		month = 1
+	}
+	budget.Date = MakeDate(budget.Date.Year(), month)
+}
+
+// GetBudgets повертає ліміти поточної організації: спочатку ліміти
+// організації, потім точок обліку за назвою, за датою.
+func (stor *Storage) GetBudgets() []*Budget {
+	queryBudgets := `
+	SELECT ifnull(places.name, ''), bdate, yearly, kwh
+	  FROM budgets LEFT JOIN places USING(place_id)
+	 WHERE budgets.site_id = ?
+	 ORDER BY places.name, yearly, bdate
+	`
+	rows, err := stor.Query(queryBudgets, stor.site)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	budgets := make([]*Budget, 0)
+	for rows.Next() {
+		budget := new(Budget)
+		var date string
+		err := rows.Scan(&budget.Name, &date, &budget.Yearly,
+			&budget.Kwh)
+		if err != nil {
+			panic(err)
+		}
+		budget.Date, err = stringToDate(date)
+		if err != nil {
+			panic(err)
+		}
+		budgets = append(budgets, budget)
+	}
+	if err := rows.Err(); err != nil {
+		panic(err)
+	}
+	return budgets
+}
+
+// SetBudget додає ліміт або замінює існуючий ліміт точки обліку з тим
+// самим початком дії. Дата ліміту встановлюється на перший місяць
+// його дії.
+func (stor *Storage) SetBudget(budget *Budget) error {
+	place, err := stor.budgetPlace(budget.Name)
+	if err != nil {
+		return err
+	}
+	budget.normalize()
+	stmtSetBudget := `
+	INSERT OR REPLACE INTO budgets (site_id, place_id, bdate, yearly, kwh)
+	VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = stor.Exec(stmtSetBudget, stor.site, place, budget.Date,
+		budget.Yearly, budget.Kwh)
+	return err
+}
+
+// RemoveBudget видаляє ліміт. Відсутній ліміт не є помилкою.
+func (stor *Storage) RemoveBudget(budget *Budget) error {
+	place, err := stor.budgetPlace(budget.Name)
+	if err != nil {
+		return err
+	}
+	budget.normalize()
+	stmtRemoveBudget := `
+	DELETE FROM budgets
+	 WHERE site_id = ? AND ifnull(place_id, 0) = ifnull(?, 0)
+	   AND bdate = ? AND yearly = ?
+	`
+	_, err = stor.Exec(stmtRemoveBudget, stor.site, place, budget.Date,
+		budget.Yearly)
+	return err
+}
+
+// budgetPlace повертає ID точки обліку name поточної організації або
+// NULL для пустої назви.
+func (stor *Storage) budgetPlace(name string) (sql.NullInt64, error) {
+	var place sql.NullInt64
+	if name == "" {
+		return place, nil
+	}
+	queryPlace := `
+	SELECT place_id
+	  FROM places
+	 WHERE site_id = ? AND name = ?
+	`
+	err := stor.QueryRow(queryPlace, stor.site, name).Scan(&place)
+	if err == sql.ErrNoRows {
+		return place, ErrMissingPlace
+	}
+	if err != nil {
+		panic(err)
+	}
+	return place, nil
+}
+
+// Usage показує використання ліміту точкою обліку або організацією.
+type Usage struct {
+	Name    string // точка обліку, пуста для всієї організації
+	Yearly  bool   // річний ліміт, Energy з початку року
+	Budget  int
+	Energy  int
+	Percent float64 // Energy у відсотках від Budget
+}
+
+// Over перевіряє чи перевищено ліміт.
+func (usage *Usage) Over() bool {
+	return usage.Energy > usage.Budget
+}
+
+// GetUsage рахує використання лімітів, які діють в місяці date, за
+// рядками звіту reports цього місяця. Рядки можуть бути ще не
+// збереженими показниками з GetNextReports. Для річних лімітів
+// додається енергія з початку року до date.
+func GetUsage(stor Store, date time.Time, reports []*Report) []*Usage {
+	// місячна енергія
+	energy := make(map[string]int)
+	for _, report := range reports {
+		report.Calculate()
+		energy[report.Name] += report.Energy
+		energy[""] += report.Energy
+	}
+
+	// ліміти, які діють в місяці date
+	monthly := make(map[string]*Budget)
+	yearly := make(map[string]*Budget)
+	for _, budget := range stor.GetBudgets() {
+		switch {
+		case budget.Yearly && budget.Date.Year() == date.Year():
+			yearly[budget.Name] = budget
+		case !budget.Yearly && !budget.Date.After(date):
+			if monthly[budget.Name] == nil ||
+				monthly[budget.Name].Date.Before(budget.Date) {
+				monthly[budget.Name] = budget
+			}
+		}
+	}
+
+	usages := make([]*Usage, 0)
+	for name, budget := range monthly {
+		if budget.Kwh == 0 {
+			continue
+		}
+		usages = append(usages, newUsage(budget, energy[name]))
+	}
+	for name, budget := range yearly {
+		if budget.Kwh == 0 {
+			continue
+		}
+		total := energy[name]
+		if date.Month() > 1 {
+			from := MakeDate(date.Year(), 1)
+			to := date.AddDate(0, -1, 0)
+			if name == "" {
+				total += stor.GetTotal(from, to)
+			} else {
+				total += stor.GetTotal(from, to, name)
+			}
+		}
+		usages = append(usages, newUsage(budget, total))
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Name != usages[j].Name {
+			return usages[i].Name < usages[j].Name
+		}
+		return !usages[i].Yearly && usages[j].Yearly
+	})
+	return usages
+}
+
+// GetMonthUsage рахує використання лімітів за закритий місяць date.
+func GetMonthUsage(stor Store, date time.Time) []*Usage {
+	return GetUsage(stor, date, stor.GetReports(date))
+}
+
+// newUsage створює використання ненульового ліміту budget.
+func newUsage(budget *Budget, energy int) *Usage {
+	return &Usage{
+		Name:    budget.Name,
+		Yearly:  budget.Yearly,
+		Budget:  budget.Kwh,
+		Energy:  energy,
+		Percent: float64(energy) * 100 / float64(budget.Kwh),
+	}
+}
@@ -0,0 +1,374 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Вид проблеми в базі даних
+type ProblemKind string
+
+const (
+	ProblemIntegrity  ProblemKind = "integrity"   // пошкоджена БД
+	ProblemForeignKey ProblemKind = "foreign_key" // порушення посилань
+	ProblemGap        ProblemKind = "gap"         // пропущені показники
+	ProblemZones      ProblemKind = "zones"       // змінились тарифні зони
+	ProblemPlace      ProblemKind = "place"       // точка без лічильників
+	ProblemNextDate   ProblemKind = "next_date"   // невірна дата звіту
+)
+
+// Problem описує проблему, знайдену перевіркою бази даних.
+type Problem struct {
+	Kind       ProblemKind
+	Message    string
+	Repairable bool // може бути виправлена функцією Repair
+
+	// Запит, який виправляє проблему
+	repairStmt string
+	repairArgs []any
+}
+
+// Check перевіряє базу даних на проблеми, які не виявляються
+// обмеженнями схеми: пропущені місяці в показниках діючих лічильників,
+// зміну кількості тарифних зон, точки обліку без лічильників,
+// невідповідність дати наступного звіту показникам, а також цілісність
+// файла і посилань.
+func (stor *Storage) Check() ([]*Problem, error) {
+	checks := []func() ([]*Problem, error){
+		stor.checkIntegrity,
+		stor.checkForeignKeys,
+		stor.checkReadings,
+		stor.checkPlaces,
+		stor.checkNextDate,
+	}
+	problems := make([]*Problem, 0)
+	for _, check := range checks {
+		found, err := check()
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, found...)
+	}
+	return problems, nil
+}
+
+// Repair виправляє проблеми, які можна безпечно виправити, в одній
+// транзакції. Повертає кількість виправлених проблем.
+func (stor *Storage) Repair(problems []*Problem) (int, error) {
+	tx, err := stor.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var repaired int
+	for _, problem := range problems {
+		if !problem.Repairable {
+			continue
+		}
+		_, err := tx.Exec(problem.repairStmt,
+			problem.repairArgs...)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		repaired++
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return repaired, nil
+}
+
+// checkIntegrity перевіряє цілісність файла бази даних.
+func (stor *Storage) checkIntegrity() ([]*Problem, error) {
+	rows, err := stor.QueryLines("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	for _, row := range rows {
+		if len(row) == 1 && row[0] == "ok" {
+			continue
+		}
+		problems = append(problems, &Problem{
+			Kind:    ProblemIntegrity,
+			Message: strings.Join(row, " "),
+		})
+	}
+	return problems, nil
+}
+
+// checkForeignKeys перевіряє посилання між таблицями.
+func (stor *Storage) checkForeignKeys() ([]*Problem, error) {
+	rows, err := stor.QueryLines("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	for _, row := range rows {
+		problems = append(problems, &Problem{
+			Kind: ProblemForeignKey,
+			Message: i18n.Sprintf(
+				"таблиця %s, рядок %s посилається на "+
+					"відсутній запис в %s",
+				row[0], row[1], row[2]),
+		})
+	}
+	return problems, nil
+}
+
+// Кількість тарифних зон в показниках лічильника за місяць
+type meterMonth struct {
+	siteID   int64
+	site     string
+	nextDate time.Time // дата наступного звіту організації
+	meterID  int64
+	active   bool
+	serial   string
+	name     string
+	date     time.Time
+	zones    int
+}
+
+// meterMonths повертає кількість тарифних зон в показниках кожного
+// лічильника по місяцях, впорядковано за лічильником і датою.
+func (stor *Storage) meterMonths() ([]*meterMonth, error) {
+	queryMeterMonths := `
+	SELECT site_id, sites.name, next_date,
+	       meter_id, active, serial, places.name, rdate, count(zone)
+	  FROM readings JOIN meters USING(meter_id)
+	  JOIN places USING(place_id)
+	  JOIN sites USING(site_id)
+	 GROUP BY meter_id, rdate
+	 ORDER BY site_id, meter_id, rdate
+	`
+	rows, err := stor.Query(queryMeterMonths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	months := make([]*meterMonth, 0)
+	for rows.Next() {
+		month := new(meterMonth)
+		var nextDate, date string
+		err := rows.Scan(&month.siteID, &month.site, &nextDate,
+			&month.meterID, &month.active, &month.serial,
+			&month.name, &date, &month.zones)
+		if err != nil {
+			return nil, err
+		}
+		month.nextDate, err = stringToDate(nextDate)
+		if err != nil {
+			return nil, err
+		}
+		month.date, err = stringToDate(date)
+		if err != nil {
+			return nil, err
+		}
+		months = append(months, month)
+	}
+	return months, rows.Err()
+}
+
+// checkReadings шукає пропущені місяці в показниках діючих лічильників
+// та зміну кількості тарифних зон лічильника.
+func (stor *Storage) checkReadings() ([]*Problem, error) {
+	months, err := stor.meterMonths()
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	for i, cur := range months {
+		// Перший місяць лічильника
+		if i == 0 || months[i-1].meterID != cur.meterID {
+			continue
+		}
+		pre := months[i-1]
+		meter := i18n.Sprintf("Лічильник %s (%s)",
+			cur.serial, cur.name)
+
+		// Пропущені місяці
+		gapFrom := pre.date.AddDate(0, 1, 0)
+		gapTo := cur.date.AddDate(0, -1, 0)
+		if cur.active && !gapFrom.After(gapTo) {
+			problems = append(problems, &Problem{
+				Kind: ProblemGap,
+				Message: i18n.Sprintf(
+					"%s: немає показників за %s",
+					meter, monthRange(gapFrom, gapTo)),
+			})
+		}
+
+		// Зміна тарифних зон, крім ще не закритого місяця
+		if cur.zones != pre.zones && cur.date.Before(cur.nextDate) {
+			problems = append(problems, &Problem{
+				Kind: ProblemZones,
+				Message: i18n.Sprintf(
+					"%s: кількість тарифних зон змінилась "+
+						"з %d на %d в %s",
+					meter, pre.zones, cur.zones,
+					monthRange(cur.date, cur.date)),
+			})
+		}
+	}
+
+	// Пропущені місяці в кінці, до дати наступного звіту
+	for i, cur := range months {
+		lastDate := cur.nextDate.AddDate(0, -1, 0)
+		isLast := i+1 == len(months) ||
+			months[i+1].meterID != cur.meterID
+		if !isLast || !cur.active || !cur.date.Before(lastDate) {
+			continue
+		}
+		problems = append(problems, &Problem{
+			Kind: ProblemGap,
+			Message: i18n.Sprintf(
+				"Лічильник %s (%s): немає показників за %s",
+				cur.serial, cur.name,
+				monthRange(cur.date.AddDate(0, 1, 0),
+					lastDate)),
+		})
+	}
+	return problems, nil
+}
+
+// checkPlaces шукає точки обліку без лічильників. Точки обліку з
+// лімітами не видаляються, бо разом з ними видаляються ліміти.
+func (stor *Storage) checkPlaces() ([]*Problem, error) {
+	queryEmptyPlaces := `
+	SELECT place_id, places.name, sites.name,
+	       place_id IN (SELECT place_id FROM budgets)
+	  FROM places JOIN sites USING(site_id)
+	 WHERE place_id NOT IN (SELECT place_id FROM meters)
+	 ORDER BY site_id, places.name
+	`
+	rows, err := stor.QueryLines(queryEmptyPlaces)
+	if err != nil {
+		return nil, err
+	}
+	stmtRemovePlace := `
+	DELETE FROM places
+	 WHERE place_id = ?
+	   AND place_id NOT IN (SELECT place_id FROM meters)
+	   AND place_id NOT IN (SELECT place_id FROM budgets
+	                         WHERE place_id IS NOT NULL)
+	`
+	var problems []*Problem
+	for _, row := range rows {
+		if row[3] == "1" {
+			problems = append(problems, &Problem{
+				Kind: ProblemPlace,
+				Message: i18n.Sprintf(
+					"Точка обліку %s (%s) не має лічильників, "+
+						"але має ліміти", row[1], row[2]),
+			})
+			continue
+		}
+		problems = append(problems, &Problem{
+			Kind: ProblemPlace,
+			Message: i18n.Sprintf(
+				"Точка обліку %s (%s) не має лічильників",
+				row[1], row[2]),
+			Repairable: true,
+			repairStmt: stmtRemovePlace,
+			repairArgs: []any{row[0]},
+		})
+	}
+	return problems, nil
+}
+
+// checkNextDate перевіряє дату наступного звіту кожної організації.
+// Останні показники мають бути за попередній місяць (місяць закрито)
+// або за місяць наступного звіту (введені, але не збережені показники).
+func (stor *Storage) checkNextDate() ([]*Problem, error) {
+	months, err := stor.meterMonths()
+	if err != nil {
+		return nil, err
+	}
+	var problems []*Problem
+	for len(months) > 0 {
+		// Показники однієї організації
+		n := 1
+		for n < len(months) && months[n].siteID == months[0].siteID {
+			n++
+		}
+		problem := checkSiteNextDate(months[:n])
+		if problem != nil {
+			problems = append(problems, problem)
+		}
+		months = months[n:]
+	}
+	return problems, nil
+}
+
+// checkSiteNextDate перевіряє дату наступного звіту за показниками
+// лічильників однієї організації.
+func checkSiteNextDate(months []*meterMonth) *Problem {
+	site := months[0].site
+	nextDate := months[0].nextDate
+
+	// Останній місяць з показниками
+	var latest time.Time
+	for _, month := range months {
+		if month.date.After(latest) {
+			latest = month.date
+		}
+	}
+	switch {
+	case latest.After(nextDate):
+		return &Problem{
+			Kind: ProblemNextDate,
+			Message: i18n.Sprintf(
+				"%s: є показники за %s, після дати "+
+					"наступного звіту %s",
+				site, monthRange(latest, latest),
+				monthRange(nextDate, nextDate)),
+		}
+	case latest.Before(nextDate.AddDate(0, -1, 0)):
+		// Місяць закритий, якщо всі діючі лічильники попереднього
+		// місяця мають показники за останній місяць
+		zones := make(map[int64]map[time.Time]int)
+		for _, month := range months {
+			if zones[month.meterID] == nil {
+				zones[month.meterID] = make(map[time.Time]int)
+			}
+			zones[month.meterID][month.date] = month.zones
+		}
+		fixDate := latest.AddDate(0, 1, 0)
+		for _, month := range months {
+			pre := latest.AddDate(0, -1, 0)
+			if month.active && month.date.Equal(pre) &&
+				zones[month.meterID][latest] != month.zones {
+				fixDate = latest
+			}
+		}
+		return &Problem{
+			Kind: ProblemNextDate,
+			Message: i18n.Sprintf(
+				"%s: дата наступного звіту %s, але останні "+
+					"показники за %s. Потрібна дата %s",
+				site, monthRange(nextDate, nextDate),
+				monthRange(latest, latest),
+				monthRange(fixDate, fixDate)),
+			Repairable: true,
+			repairStmt: `
+			UPDATE sites
+			   SET next_date = ?
+			 WHERE site_id = ?`,
+			repairArgs: []any{dateToString(fixDate),
+				months[0].siteID},
+		}
+	}
+	return nil
+}
+
+// monthRange повертає місяць або діапазон місяців в форматі YYYY-MM.
+func monthRange(from, to time.Time) string {
+	const layout = "2006-01"
+	if from.Equal(to) {
+		return from.Format(layout)
+	}
+	return from.Format(layout) + " - " + to.Format(layout)
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	stor := createDatabase(t)
+
+	// Тестові дані без проблем.
+	problems, err := stor.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems[0].Message)
+	}
+
+	// Внесення проблем.
+	stmt := `
+	INSERT INTO places (site_id, name) VALUES (1, 'Склад');
+	DELETE FROM readings WHERE meter_id = 1 AND rdate = '2021-12-01';
+	INSERT INTO readings VALUES ('2022-01-01', 1, 2, 100, NULL);
+	INSERT INTO readings VALUES ('2022-02-01', 1, 2, 200, NULL);
+	UPDATE sites SET next_date = '2022-06-01' WHERE site_id = 1;
+	`
+	_, err = stor.Exec(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err = stor.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []ProblemKind
+	var repairable int
+	for _, problem := range problems {
+		got = append(got, problem.Kind)
+		if problem.Repairable {
+			repairable++
+		}
+	}
+	want := []ProblemKind{
+		ProblemGap,      // 344848 за 2021-12
+		ProblemZones,    // 344848 з 1 на 2 зони
+		ProblemGap,      // 344848 за 2022-03 - 2022-05
+		ProblemGap,      // 001930 за 2022-03 - 2022-05
+		ProblemPlace,    // Склад
+		ProblemNextDate, // потрібна дата 2022-03
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Виправлення безпечних проблем.
+	n, err := stor.Repair(problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != repairable || n != 2 {
+		t.Errorf("repaired want 2, got %d", n)
+	}
+	if date := stor.GetNextDate(); !date.Equal(MakeDate(2022, 3)) {
+		t.Errorf("next date want 2022-03, got %s",
+			date.Format(DateLayout))
+	}
+	problems, err = stor.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 {
+		t.Errorf("problems after repair want 2, got %d",
+			len(problems))
+	}
+}
+
+func TestCheckNextDateBeforeReadings(t *testing.T) {
+	stor := createDatabase(t)
+	stmt := `UPDATE sites SET next_date = '2022-01-01' WHERE site_id = 1`
+	_, err := stor.Exec(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := stor.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Kind != ProblemNextDate ||
+		problems[0].Repairable {
+		t.Errorf("want one not repairable next_date problem, got %v",
+			problems)
+	}
+}
+
+func TestCheckPlaceWithBudget(t *testing.T) {
+	stor := createDatabase(t)
+
+	// Точки обліку без лічильників з лімітом і без
+	stmt := `
+	INSERT INTO places (site_id, name) VALUES (1, 'Склад');
+	INSERT INTO places (site_id, name) VALUES (1, 'Гараж');
+	INSERT INTO budgets (site_id, place_id, bdate, kwh)
+	SELECT 1, place_id, '2022-01-01', 100 FROM places WHERE name = 'Склад';
+	INSERT INTO budgets (site_id, place_id, bdate, kwh)
+	VALUES (1, NULL, '2022-01-01', 1000);
+	`
+	_, err := stor.Exec(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := stor.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var repairable []bool
+	for _, problem := range problems {
+		repairable = append(repairable, problem.Repairable)
+	}
+	if diff := cmp.Diff([]bool{true, false}, repairable); diff != "" {
+		t.Errorf("repairable mismatch (-want +got):\n%s", diff)
+	}
+
+	n, err := stor.Repair(problems)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("repaired want 1, got %d", n)
+	}
+	if budgets := stor.GetBudgets(); len(budgets) != 2 {
+		t.Errorf("budgets want 2, got %d", len(budgets))
+	}
+}
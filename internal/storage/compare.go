@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+//------------------------- COMPARE FUNCTIONS --------------------------
+
+// Comparison порівнює спожиту енергію за місяць з попереднім місяцем і
+// тим самим місяцем минулого року по точках обліку.
+type Comparison struct {
+	Date   time.Time
+	Places []*PlaceComparison
+	Total  *PlaceComparison // всі точки обліку, Name пусте
+}
+
+// PlaceComparison містить спожиту енергію точки обліку за місяць і
+// різницю з місяцями для порівняння.
+type PlaceComparison struct {
+	Substation int
+	Name       string
+	Energy     int
+	PrevMonth  Delta // попередній місяць
+	PrevYear   Delta // той самий місяць минулого року
+}
+
+// Delta містить енергію місяця для порівняння і різницю з поточним
+// місяцем.
+type Delta struct {
+	Energy  int     // енергія місяця для порівняння
+	Diff    int     // поточна енергія мінус Energy
+	Percent float64 // Diff у відсотках від Energy
+	Missing bool    // немає звіту за місяць для порівняння
+}
+
+// HasPercent перевіряє чи можна порахувати різницю у відсотках.
+func (d Delta) HasPercent() bool {
+	return !d.Missing && d.Energy != 0
+}
+
+// GetComparison порівнює спожиту енергію за місяць date з попереднім
+// місяцем і тим самим місяцем минулого року. В порівнянні є точки
+// обліку, які мають звіт хоча б за один з трьох місяців.
+func GetComparison(stor Store, date time.Time) *Comparison {
+	cur := energyByPlace(stor.GetReports(date))
+	prevMonth := energyByPlace(stor.GetReports(date.AddDate(0, -1, 0)))
+	prevYear := energyByPlace(stor.GetReports(date.AddDate(-1, 0, 0)))
+
+	comparison := &Comparison{
+		Date:   date,
+		Places: make([]*PlaceComparison, 0),
+		Total: &PlaceComparison{
+			PrevMonth: Delta{Missing: len(prevMonth) == 0},
+			PrevYear:  Delta{Missing: len(prevYear) == 0},
+		},
+	}
+	places := make(map[string]*PlaceComparison)
+	for _, energy := range []map[string]*placeEnergy{cur, prevMonth,
+		prevYear} {
+		for name, place := range energy {
+			if places[name] != nil {
+				continue
+			}
+			places[name] = &PlaceComparison{
+				Substation: place.substation,
+				Name:       name,
+			}
+			comparison.Places = append(comparison.Places,
+				places[name])
+		}
+	}
+	sort.Slice(comparison.Places, func(i, j int) bool {
+		return comparison.Places[i].Name < comparison.Places[j].Name
+	})
+
+	total := comparison.Total
+	for _, place := range comparison.Places {
+		if energy, ok := cur[place.Name]; ok {
+			place.Energy = energy.energy
+			total.Energy += energy.energy
+		}
+		place.PrevMonth.Missing = true
+		if energy, ok := prevMonth[place.Name]; ok {
+			place.PrevMonth.Missing = false
+			place.PrevMonth.Energy = energy.energy
+			total.PrevMonth.Energy += energy.energy
+		}
+		place.PrevYear.Missing = true
+		if energy, ok := prevYear[place.Name]; ok {
+			place.PrevYear.Missing = false
+			place.PrevYear.Energy = energy.energy
+			total.PrevYear.Energy += energy.energy
+		}
+		place.PrevMonth.calculate(place.Energy)
+		place.PrevYear.calculate(place.Energy)
+	}
+	total.PrevMonth.calculate(total.Energy)
+	total.PrevYear.calculate(total.Energy)
+	return comparison
+}
+
+// calculate рахує різницю енергії energy з енергією місяця для
+// порівняння.
+func (d *Delta) calculate(energy int) {
+	if d.Missing {
+		return
+	}
+	d.Diff = energy - d.Energy
+	if d.Energy != 0 {
+		d.Percent = float64(d.Diff) * 100 / float64(d.Energy)
+	}
+}
+
+// Спожита енергія точки обліку за місяць
+type placeEnergy struct {
+	substation int
+	energy     int
+}
+
+// energyByPlace рахує спожиту енергію по точках обліку.
+func energyByPlace(reports []*Report) map[string]*placeEnergy {
+	places := make(map[string]*placeEnergy)
+	for _, report := range reports {
+		place, ok := places[report.Name]
+		if !ok {
+			place = &placeEnergy{substation: report.Substation}
+			places[report.Name] = place
+		}
+		place.energy += report.Energy
+	}
+	return places
+}
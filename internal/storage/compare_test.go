@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestGetComparison(t *testing.T) {
+	stor := createDatabase(t)
+
+	// За січень 2021 звітів немає.
+	comparison := GetComparison(stor, MakeDate(2022, 1))
+	noYear := Delta{Missing: true}
+	want := &Comparison{
+		Date: MakeDate(2022, 1),
+		Places: []*PlaceComparison{
+			{220, "АВМ", 1160, Delta{2080, -920, -44.23, false},
+				noYear},
+			{208, "Госпдвір", 7440, Delta{7840, -400, -5.1, false},
+				noYear},
+			{205, "Контора", 1575, Delta{1998, -423, -21.17, false},
+				noYear},
+		},
+		Total: &PlaceComparison{0, "", 10175,
+			Delta{11918, -1743, -14.62, false}, noYear},
+	}
+	diff := cmp.Diff(want, comparison, cmpopts.EquateApprox(0, 0.01))
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if !comparison.Total.PrevMonth.HasPercent() ||
+		comparison.Total.PrevYear.HasPercent() {
+		t.Error("HasPercent() error")
+	}
+
+	// Точки обліку без звіту за поточний місяць
+	comparison = GetComparison(stor, MakeDate(2022, 3))
+	want = &Comparison{
+		Date: MakeDate(2022, 3),
+		Places: []*PlaceComparison{
+			{220, "АВМ", 4000, Delta{1040, 2960, 284.62, false},
+				noYear},
+			{208, "Госпдвір", 0, Delta{6280, -6280, -100, false},
+				noYear},
+			{205, "Контора", 0, Delta{795, -795, -100, false},
+				noYear},
+		},
+		Total: &PlaceComparison{0, "", 4000,
+			Delta{8115, -4115, -50.71, false}, noYear},
+	}
+	diff = cmp.Diff(want, comparison, cmpopts.EquateApprox(0, 0.01))
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
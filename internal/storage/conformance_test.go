@@ -0,0 +1,666 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+//-------------------------- Initial memory ----------------------------
+
+// createMemory створює сховище в памʼяті з тими ж даними, що і
+// data_test.sql.
+func createMemory(t *testing.T) *Memory {
+	mem := NewMemory(MakeDate(2022, 3))
+	mem.places = map[memPlaceKey]*memPlace{
+		{1, "Госпдвір"}: {208, "1234567890abcdef"},
+		{1, "АВМ"}:      {220, ""},
+		{1, "Контора"}:  {205, ""},
+	}
+	mem.meters = []*memMeter{
+		{1, 1, "Госпдвір", true, "НІК2301АП1", 2020, "344848", 4, 40},
+		{2, 1, "АВМ", false, "НІК2102-02", 2021, "475434", 4, 40},
+		{3, 1, "Контора", true, "", 0, "001930", 5, 1},
+		{4, 1, "АВМ", false, "НІК2102-02", 2022, "E12345", 4, 40},
+	}
+	mem.lastID = 4
+	readings := []struct {
+		date    string
+		meterID int64
+		zone    int
+		kwh     int
+	}{
+		{"2021-10-01", 1, 1, 9348},
+		{"2021-10-01", 2, 1, 3371},
+		{"2021-10-01", 3, 1, 10736},
+		{"2021-10-01", 3, 2, 10000},
+		{"2021-11-01", 1, 1, 9525},
+		{"2021-11-01", 2, 1, 3400},
+		{"2021-11-01", 4, 1, 7400},
+		{"2021-11-01", 3, 1, 11577},
+		{"2021-11-01", 3, 2, 11500},
+		{"2021-12-01", 1, 1, 9721},
+		{"2021-12-01", 2, 1, 3426},
+		{"2021-12-01", 4, 1, 7426},
+		{"2021-12-01", 3, 1, 12575},
+		{"2021-12-01", 3, 2, 12500},
+		{"2022-01-01", 1, 1, 9907},
+		{"2022-01-01", 4, 1, 7455},
+		{"2022-01-01", 3, 1, 13350},
+		{"2022-01-01", 3, 2, 13300},
+		{"2022-02-01", 1, 1, 64},
+		{"2022-02-01", 4, 1, 7481},
+		{"2022-02-01", 3, 1, 13745},
+		{"2022-02-01", 3, 2, 13700},
+		{"2022-03-01", 4, 1, 7581},
+	}
+	for _, r := range readings {
+		key := memKey{r.date, r.meterID, r.zone}
+		mem.readings[key] = &memReading{kwh: r.kwh}
+	}
+	return mem
+}
+
+//------------------------- Conformance Tests --------------------------
+
+func TestStorageConformance(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return createDatabase(t)
+	})
+}
+
+func TestMemoryConformance(t *testing.T) {
+	testStore(t, func(t *testing.T) Store {
+		return createMemory(t)
+	})
+}
+
+// testStore перевіряє, що реалізація Store поводиться однаково з базою
+// даних. Функція newStore повертає сховище з даними data_test.sql.
+func testStore(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("Sites", func(t *testing.T) {
+		stor := newStore(t)
+		sites := stor.GetSites()
+		if len(sites) != 1 || sites[0].Name != DefaultSiteName {
+			t.Fatalf("want one default site, got %v", sites)
+		}
+		if stor.ReadOnly() {
+			t.Error("new store is read-only")
+		}
+		first := stor.GetSite()
+
+		// Нова організація зі своєю датою і лічильниками.
+		site := &Site{Name: "Філія"}
+		err := stor.AddSite(site, MakeDate(2022, 1))
+		if err != nil {
+			t.Fatalf("site not added: %s", err)
+		}
+		err = stor.AddSite(&Site{Name: "Філія"}, MakeDate(2022, 1))
+		if !IsConstraint(err) {
+			t.Errorf("added site with the same name: %v", err)
+		}
+		if stor.AddSite(&Site{Name: " "}, MakeDate(2022, 1)) == nil {
+			t.Error("added site with empty name")
+		}
+		err = SelectSite(stor, "Філія")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stor.GetSite().Name != "Філія" {
+			t.Error("site not selected")
+		}
+		if n := len(stor.GetActiveMeters()); n != 0 {
+			t.Errorf("active meters of new site want 0, got %d", n)
+		}
+		meter := &Meter{Name: "Госпдвір", Serial: "777", Digits: 4,
+			Ratio: 2}
+		err = stor.AddMeter(meter, []int{100})
+		if err != nil {
+			t.Fatalf("meter not added: %s", err)
+		}
+		reports := stor.GetNextReports()
+		if len(reports) != 1 {
+			t.Fatalf("next reports want 1, got %d", len(reports))
+		}
+		reports[0].CurKwh = 150
+		err = stor.SaveReports(reports)
+		if err != nil {
+			t.Fatalf("save reports error: %s", err)
+		}
+		if !stor.GetNextDate().Equal(MakeDate(2022, 2)) {
+			t.Error("next date of new site not changed")
+		}
+		total := stor.GetTotal(MakeDate(2022, 1), MakeDate(2022, 1))
+		if total != 100 {
+			t.Errorf("total of new site want 100, got %d", total)
+		}
+
+		// Дані першої організації не змінились.
+		err = stor.SetSite(first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(stor.GetActiveMeters()); n != 2 {
+			t.Errorf("active meters want 2, got %d", n)
+		}
+		if !stor.GetNextDate().Equal(MakeDate(2022, 3)) {
+			t.Error("next date of first site changed")
+		}
+		total = stor.GetTotal(MakeDate(2022, 1), MakeDate(2022, 1))
+		if total != 10175 {
+			t.Errorf("total of first site want 10175, got %d",
+				total)
+		}
+
+		// Відсутня організація.
+		if stor.SetSite(&Site{Name: "Філія"}) != ErrMissingSite {
+			t.Error("selected site without id")
+		}
+		if SelectSite(stor, "Інша") != ErrMissingSite {
+			t.Error("selected missing site")
+		}
+	})
+
+	t.Run("GetActiveMeters", func(t *testing.T) {
+		stor := newStore(t)
+		want := []*Meter{
+			{1, 208, "1234567890abcdef", "Госпдвір",
+				"НІК2301АП1", 2020, "344848", 4, 40},
+			{3, 205, "", "Контора",
+				"", 0, "001930", 5, 1},
+		}
+		diff := cmp.Diff(want, stor.GetActiveMeters(),
+			cmp.AllowUnexported(Meter{}))
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("AddMeter", func(t *testing.T) {
+		stor := newStore(t)
+		meter := &Meter{
+			Substation: 208,
+			Name:       "Госпдвір",
+			Serial:     "12345678",
+			Digits:     4,
+			Ratio:      10,
+		}
+		err := stor.AddMeter(meter, []int{9999, 1111})
+		if err != nil {
+			t.Fatalf("meter not added: %s", err)
+		}
+		if meter.id == 0 {
+			t.Error("meter id not set")
+		}
+
+		// Точка обліку вже існує, EIC береться з неї.
+		meter.Eic = "1234567890abcdef"
+		meters := stor.GetActiveMeters()
+		if len(meters) != 3 {
+			t.Fatalf("active meters want 3, got %d", len(meters))
+		}
+		diff := cmp.Diff(meter, meters[1],
+			cmp.AllowUnexported(Meter{}))
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+
+		// Початкові показники в формі вводу.
+		var zones []int
+		for _, report := range stor.GetNextReports() {
+			if report.Serial == meter.Serial {
+				zones = append(zones, report.PreKwh)
+			}
+		}
+		if diff := cmp.Diff([]int{9999, 1111}, zones); diff != "" {
+			t.Errorf("first kwh mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("AddMeterConstraints", func(t *testing.T) {
+		stor := newStore(t)
+		bad := []struct {
+			meter *Meter
+			kwh   []int
+		}{
+			{&Meter{Name: " ", Serial: "1", Digits: 4, Ratio: 1},
+				[]int{1}},
+			{&Meter{Name: "A", Serial: "1", Digits: 9, Ratio: 1},
+				[]int{1}},
+			{&Meter{Name: "A", Serial: "1", Digits: 4, Ratio: 0},
+				[]int{1}},
+			{&Meter{Name: "A", Eic: "123", Serial: "1", Digits: 4,
+				Ratio: 1}, []int{1}},
+			{&Meter{Name: "A", Serial: "1", Digits: 4, Ratio: 1},
+				nil},
+			{&Meter{Name: "A", Serial: "1", Digits: 4, Ratio: 1},
+				[]int{1, 2, 3, 4}},
+		}
+		for i, b := range bad {
+			if err := stor.AddMeter(b.meter, b.kwh); err == nil {
+				t.Errorf("meter %d added with bad values", i)
+			}
+		}
+		if n := len(stor.GetActiveMeters()); n != 2 {
+			t.Errorf("active meters want 2, got %d", n)
+		}
+	})
+
+	t.Run("AddMeters", func(t *testing.T) {
+		stor := newStore(t)
+		meters := []*Meter{
+			{Name: "Склад", Serial: "1", Digits: 4, Ratio: 1},
+			{Name: " ", Serial: "2", Digits: 4, Ratio: 1},
+			{Name: "Склад", Serial: "3", Digits: 4, Ratio: 1},
+			{Name: "Гараж", Serial: "4", Digits: 9, Ratio: 1},
+		}
+		kwh := [][]int{{1}, {2}, {3, 3}, {4}}
+
+		// Всі помилки повертаються, нічого не додано.
+		err := stor.AddMeters(meters, kwh, false)
+		var errs MetersError
+		if !errors.As(err, &errs) {
+			t.Fatalf("want MetersError, got %v", err)
+		}
+		var indexes []int
+		for _, e := range errs {
+			indexes = append(indexes, e.Index)
+		}
+		if diff := cmp.Diff([]int{1, 3}, indexes); diff != "" {
+			t.Errorf("errors mismatch (-want +got):\n%s", diff)
+		}
+		if n := len(stor.GetActiveMeters()); n != 2 {
+			t.Errorf("active meters want 2, got %d", n)
+		}
+
+		// Перевірка без запису.
+		meters, kwh = []*Meter{meters[0], meters[2]}, [][]int{{1}, {3, 3}}
+		err = stor.AddMeters(meters, kwh, true)
+		if err != nil {
+			t.Fatalf("dry run error: %s", err)
+		}
+		if n := len(stor.GetActiveMeters()); n != 2 {
+			t.Errorf("dry run: active meters want 2, got %d", n)
+		}
+
+		// Додавання.
+		err = stor.AddMeters(meters, kwh, false)
+		if err != nil {
+			t.Fatalf("meters not added: %s", err)
+		}
+		if meters[0].id == 0 || meters[1].id == 0 {
+			t.Error("meter id not set")
+		}
+		if n := len(stor.GetActiveMeters()); n != 4 {
+			t.Errorf("active meters want 4, got %d", n)
+		}
+		if n := len(stor.GetNextReports()); n != 6 {
+			t.Errorf("next reports want 6, got %d", n)
+		}
+	})
+
+	t.Run("AddHistory", func(t *testing.T) {
+		stor := newStore(t)
+		site := &Site{Name: "Нова"}
+		err := stor.AddSite(site, MakeDate(2023, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = stor.SetSite(site)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newHistory := func() []*History {
+			return []*History{
+				{&Meter{Name: "Склад", Serial: "1", Digits: 4,
+					Ratio: 10}, MakeDate(2021, 11),
+					[][]int{{9990}, {5}, {20}}},
+				{&Meter{Name: "Гараж", Serial: "2", Digits: 5,
+					Ratio: 1}, MakeDate(2021, 12),
+					[][]int{{100, 50}, {110, 55}}},
+			}
+		}
+
+		// Помилки в показниках.
+		bad := [][][]int{
+			{{5000}, {10}, {20}},  // неправдоподібний перехід
+			{{10000}, {10}, {20}}, // більше розрядності
+			{{10}, {20}},          // немає показників за 2022-01
+			{{10}, {20, 1}, {30}}, // змінились зони
+		}
+		for i, kwh := range bad {
+			history := newHistory()
+			history[0].Kwh = kwh
+			err := stor.AddHistory(history, false)
+			var errs MetersError
+			if !errors.As(err, &errs) || len(errs) != 1 ||
+				errs[0].Index != 0 {
+				t.Errorf("bad history %d: error %v", i, err)
+			}
+		}
+
+		// Перевірка без запису.
+		err = stor.AddHistory(newHistory(), true)
+		if err != nil {
+			t.Fatalf("dry run error: %s", err)
+		}
+		if n := len(stor.GetActiveMeters()); n != 0 {
+			t.Errorf("dry run: active meters want 0, got %d", n)
+		}
+
+		// Додавання.
+		err = stor.AddHistory(newHistory(), false)
+		if err != nil {
+			t.Fatalf("history not added: %s", err)
+		}
+		if !stor.GetNextDate().Equal(MakeDate(2022, 2)) {
+			t.Errorf("next date want 2022-02, got %s",
+				stor.GetNextDate().Format(DateLayout))
+		}
+		total := stor.GetTotal(MakeDate(2021, 12), MakeDate(2022, 1))
+		if total != 315 {
+			t.Errorf("total want 315, got %d", total)
+		}
+		if n := len(stor.GetNextReports()); n != 3 {
+			t.Errorf("next reports want 3, got %d", n)
+		}
+		err = stor.AddHistory(newHistory(), false)
+		if err != ErrSiteNotEmpty {
+			t.Errorf("want ErrSiteNotEmpty, got %v", err)
+		}
+	})
+
+	t.Run("RemoveMeter", func(t *testing.T) {
+		stor := newStore(t)
+		meters := stor.GetActiveMeters()
+		err := stor.RemoveMeter(meters[0])
+		if err != nil {
+			t.Fatalf("remove meter error: %s", err)
+		}
+		err = stor.RemoveMeter(meters[0])
+		if err != ErrMissingMeter {
+			t.Errorf("remove a removed meter error: %v", err)
+		}
+		meters = stor.GetActiveMeters()
+		if len(meters) != 1 || meters[0].Serial != "001930" {
+			t.Error("could not remove the meter")
+		}
+	})
+
+	t.Run("GetReports", func(t *testing.T) {
+		stor := newStore(t)
+		want := []*Report{
+			{&Meter{4, 220, "", "АВМ", "НІК2102-02", 2022,
+				"E12345", 4, 40}, 1, 7481, 7455, 26, 1040, ""},
+			{&Meter{1, 208, "1234567890abcdef", "Госпдвір",
+				"НІК2301АП1", 2020, "344848", 4, 40},
+				1, 64, 9907, 157, 6280, ""},
+			{&Meter{3, 205, "", "Контора", "", 0, "001930",
+				5, 1}, 1, 13745, 13350, 395, 395, ""},
+			{&Meter{3, 205, "", "Контора", "", 0, "001930",
+				5, 1}, 2, 13700, 13300, 400, 400, ""},
+		}
+		got := stor.GetReports(MakeDate(2022, 2))
+		diff := cmp.Diff(want, got, cmp.AllowUnexported(Meter{}))
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		if n := len(stor.GetReports(MakeDate(2021, 10))); n != 0 {
+			t.Errorf("first month reports want 0, got %d", n)
+		}
+	})
+
+	t.Run("NextReports", func(t *testing.T) {
+		stor := newStore(t)
+		date := stor.GetNextDate()
+		if !date.Equal(MakeDate(2022, 3)) {
+			t.Errorf("next date want 2022-03, got %s",
+				date.Format(DateLayout))
+		}
+
+		// Не всі показники введені.
+		reports := stor.GetNextReports()
+		if len(reports) != 3 {
+			t.Fatalf("next reports want 3, got %d", len(reports))
+		}
+		if n := stor.GetMissingReadings(); n != 3 {
+			t.Errorf("missing readings want 3, got %d", n)
+		}
+		reports[0].CurKwh += 10
+		reports[0].Annotation = "Примітка"
+		err := stor.SaveReports(reports[:1])
+		if !IsConstraint(err) {
+			t.Errorf("saved reports with missing readings: %v", err)
+		}
+		if !stor.GetNextDate().Equal(date) {
+			t.Error("next date changed with missing readings")
+		}
+		if n := stor.GetMissingReadings(); n != 2 {
+			t.Errorf("missing readings want 2, got %d", n)
+		}
+		if !stor.GetSite().ClosedAt.IsZero() {
+			t.Error("month closed with missing readings")
+		}
+
+		// Введений показник збережено в формі вводу.
+		want := &Report{
+			&Meter{1, 208, "1234567890abcdef", "Госпдвір",
+				"НІК2301АП1", 2020, "344848", 4, 40},
+			1, 74, 64, 10, 400, "Примітка"}
+		got := stor.GetNextReports()[0]
+		diff := cmp.Diff(want, got, cmp.AllowUnexported(Meter{}))
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+
+		// Всі показники введені.
+		err = stor.SaveReports(stor.GetNextReports())
+		if err != nil {
+			t.Fatalf("save reports error: %s", err)
+		}
+		if !stor.GetNextDate().Equal(MakeDate(2022, 4)) {
+			t.Error("next date not changed")
+		}
+		closedAt := stor.GetSite().ClosedAt
+		if time.Since(closedAt) > time.Minute {
+			t.Errorf("closed at %s", closedAt)
+		}
+		total := stor.GetTotal(MakeDate(2022, 3), MakeDate(2022, 3))
+		if total != 4400 {
+			t.Errorf("total want 4400, got %d", total)
+		}
+	})
+
+	t.Run("SaveDrafts", func(t *testing.T) {
+		stor := newStore(t)
+		date := stor.GetNextDate()
+		reports := stor.GetNextReports()
+		reports[1].CurKwh += 5
+		err := stor.SaveDrafts(reports[1:2])
+		if err != nil {
+			t.Fatalf("save drafts error: %s", err)
+		}
+		if !stor.GetNextDate().Equal(date) {
+			t.Error("next date changed by drafts")
+		}
+		got := stor.GetNextReports()
+		if got[1].CurKwh != reports[1].CurKwh || got[1].Diff != 5 {
+			t.Errorf("draft not saved: %+v", got[1])
+		}
+		if got[0].Diff != 0 {
+			t.Errorf("other reading changed: %+v", got[0])
+		}
+
+		// Помилка в пізнішому показнику: не зберігається жоден
+		want := stor.GetNextReports()
+		missing := stor.GetMissingReadings()
+		for _, bad := range []func(r *Report){
+			func(r *Report) { r.Annotation = strings.Repeat("x", 33) },
+			func(r *Report) { r.CurKwh = -1 },
+		} {
+			reports := stor.GetNextReports()
+			reports[0].CurKwh += 7
+			bad(reports[1])
+			err := stor.SaveDrafts(reports[:2])
+			if !IsConstraint(err) {
+				t.Errorf("bad draft saved: %v", err)
+			}
+			diff := cmp.Diff(want, stor.GetNextReports(),
+				cmp.AllowUnexported(Meter{}))
+			if diff != "" {
+				t.Errorf("partial save (-want +got):\n%s", diff)
+			}
+			if n := stor.GetMissingReadings(); n != missing {
+				t.Errorf("missing readings want %d, got %d",
+					missing, n)
+			}
+		}
+	})
+
+	t.Run("GetTotal", func(t *testing.T) {
+		stor := newStore(t)
+		from, to := MakeDate(2021, 12), MakeDate(2022, 2)
+		if total := stor.GetTotal(from, to); total != 30208 {
+			t.Errorf("GetTotal() want 30208, got %d", total)
+		}
+		if total := stor.GetTotal(from, to, "АВМ"); total != 4280 {
+			t.Errorf("GetTotal(АВМ) want 4280, got %d", total)
+		}
+		total := stor.GetTotal(from, to, "АВМ", "Контора")
+		if total != 8648 {
+			t.Errorf("GetTotal(АВМ, Контора) want 8648, got %d",
+				total)
+		}
+
+		// Апостроф в назві точки обліку
+		meter := &Meter{Name: "Об'єкт", Serial: "777", Digits: 4,
+			Ratio: 2}
+		err := stor.AddMeter(meter, []int{100})
+		if err != nil {
+			t.Fatalf("meter not added: %s", err)
+		}
+		date := stor.GetNextDate()
+		reports := stor.GetNextReports()
+		for _, report := range reports {
+			if report.Name == meter.Name {
+				report.CurKwh = 150
+			}
+		}
+		err = stor.SaveReports(reports)
+		if err != nil {
+			t.Fatalf("save reports error: %s", err)
+		}
+		if total := stor.GetTotal(date, date, "Об'єкт"); total != 100 {
+			t.Errorf("GetTotal(Об'єкт) want 100, got %d", total)
+		}
+		err = stor.SetBudget(&Budget{"Об'єкт", date, true, 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		usage := GetMonthUsage(stor, date)
+		if len(usage) != 1 || usage[0].Energy != 100 {
+			t.Errorf("usage of Об'єкт want 100, got %v", usage)
+		}
+	})
+
+	t.Run("GetNextTotal", func(t *testing.T) {
+		stor := newStore(t)
+		if next := stor.GetNextTotal(nil); next != 4000 {
+			t.Errorf("GetNextTotal() want 4000, got %d", next)
+		}
+		reports := stor.GetNextReports()
+		reports[0].CurKwh += 1
+		if next := stor.GetNextTotal(reports); next != 4040 {
+			t.Errorf("GetNextTotal(r) want 4040, got %d", next)
+		}
+	})
+
+	t.Run("Budgets", func(t *testing.T) {
+		stor := newStore(t)
+		budgets := []*Budget{
+			{"Контора", MakeDate(2022, 1), false, 0},
+			{"", MakeDate(2021, 6), false, 12000},
+			{"Госпдвір", MakeDate(2021, 11), false, 7000},
+			{"АВМ", MakeDate(2022, 5), true, 2000},
+			{"Контора", MakeDate(2021, 1), false, 900},
+			{"", MakeDate(2022, 1), true, 15000},
+		}
+		for _, budget := range budgets {
+			err := stor.SetBudget(budget)
+			if err != nil {
+				t.Fatalf("budget not set: %s", err)
+			}
+		}
+
+		// Заміна ліміту і помилки
+		err := stor.SetBudget(&Budget{"Контора", MakeDate(2021, 1),
+			false, 1000})
+		if err != nil {
+			t.Fatalf("budget not replaced: %s", err)
+		}
+		err = stor.SetBudget(&Budget{"Нема", MakeDate(2021, 1), false, 1})
+		if err != ErrMissingPlace {
+			t.Errorf("budget of missing place: %v", err)
+		}
+		err = stor.SetBudget(&Budget{"", MakeDate(2021, 1), false, -1})
+		if err == nil {
+			t.Error("negative budget")
+		}
+		want := []*Budget{
+			{"", MakeDate(2021, 6), false, 12000},
+			{"", MakeDate(2022, 1), true, 15000},
+			{"АВМ", MakeDate(2022, 1), true, 2000},
+			{"Госпдвір", MakeDate(2021, 11), false, 7000},
+			{"Контора", MakeDate(2021, 1), false, 1000},
+			{"Контора", MakeDate(2022, 1), false, 0},
+		}
+		diff := cmp.Diff(want, stor.GetBudgets())
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+
+		// Використання лімітів. В січні ліміт Контори скасовано.
+		wantUsage := []*Usage{
+			{"", false, 12000, 10175, 84.79},
+			{"", true, 15000, 10175, 67.83},
+			{"АВМ", true, 2000, 1160, 58},
+			{"Госпдвір", false, 7000, 7440, 106.29},
+		}
+		usage := GetMonthUsage(stor, MakeDate(2022, 1))
+		diff = cmp.Diff(wantUsage, usage, cmpopts.EquateApprox(0, 0.01))
+		if diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+		if usage[2].Over() || !usage[3].Over() {
+			t.Error("Over() error")
+		}
+
+		// Річний ліміт з початку року і не збережені показники
+		usage = GetMonthUsage(stor, MakeDate(2022, 2))
+		if usage[2].Name != "АВМ" || usage[2].Energy != 2200 {
+			t.Errorf("yearly usage want АВМ 2200, got %s %d",
+				usage[2].Name, usage[2].Energy)
+		}
+		reports := stor.GetNextReports()
+		reports[0].CurKwh += 200
+		usage = GetUsage(stor, stor.GetNextDate(), reports)
+		if !usage[3].Over() || usage[3].Energy != 8000 {
+			t.Errorf("next usage want Госпдвір 8000, got %s %d",
+				usage[3].Name, usage[3].Energy)
+		}
+
+		// Видалення ліміту
+		err = stor.RemoveBudget(&Budget{Name: "АВМ",
+			Date: MakeDate(2022, 7), Yearly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(stor.GetBudgets()); n != 5 {
+			t.Errorf("budgets want 5, got %d", n)
+		}
+	})
+}
@@ -1,8 +1,8 @@
 BEGIN TRANSACTION;
 --
-INSERT INTO places VALUES(1,208,'1234567890abcdef','Госпдвір');
-INSERT INTO places VALUES(2,220,NULL,'АВМ');
-INSERT INTO places VALUES(3,205,NULL,'Контора');
+INSERT INTO places VALUES(1,1,208,'1234567890abcdef','Госпдвір');
+INSERT INTO places VALUES(2,1,220,NULL,'АВМ');
+INSERT INTO places VALUES(3,1,205,NULL,'Контора');
 --
 INSERT INTO meters VALUES(1,1,1,'НІК2301АП1',2020,'344848',4,40);
 INSERT INTO meters VALUES(2,2,0,'НІК2102-02',2021,'475434',4,40);
@@ -39,6 +39,6 @@ INSERT INTO readings VALUES('2022-02-01',3,2,13700,NULL);
 INSERT INTO readings VALUES('2022-03-01',4,1,7581,NULL);
 UPDATE meters SET active = false WHERE meter_id = 4;
 --
-UPDATE service SET value = '2022-03-01' WHERE skey = 'next_date';
+UPDATE sites SET next_date = '2022-03-01' WHERE site_id = 1;
 --
 COMMIT;
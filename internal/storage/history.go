@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// History містить показники лічильника за місяці поспіль.
+type History struct {
+	Meter *Meter
+	From  time.Time // місяць перших показників
+	Kwh   [][]int   // показники по місяцях, починаючи з From, по зонах
+}
+
+// To повертає місяць останніх показників.
+func (h *History) To() time.Time {
+	return h.From.AddDate(0, len(h.Kwh)-1, 0)
+}
+
+// Найбільша різниця показників при переході лічильника через нуль, у
+// відсотках від діапазону лічильника. Більша різниця скоріше означає
+// помилку в показниках або заміну лічильника.
+const RolloverPercent = 10
+
+var ErrSiteNotEmpty = i18n.NewError("організація вже має лічильники")
+
+// CheckHistory перевіряє показники лічильників: кожен місяць має ту ж
+// кількість зон, що і перший, показники не виходять за розрядність
+// лічильника, перехід через нуль правдоподібний (див. RolloverPercent),
+// а останні показники всіх лічильників за один місяць. Повертає помилки
+// всіх лічильників.
+func CheckHistory(history []*History) MetersError {
+	var errs MetersError
+	var last time.Time
+	for _, h := range history {
+		if len(h.Kwh) > 0 && h.To().After(last) {
+			last = h.To()
+		}
+	}
+	for i, h := range history {
+		err := checkMeterHistory(h, last)
+		if err != nil {
+			errs = append(errs, &MeterError{i, h.Meter, err})
+		}
+	}
+	return errs
+}
+
+// checkMeterHistory перевіряє показники одного лічильника.
+func checkMeterHistory(h *History, last time.Time) error {
+	if len(h.Kwh) == 0 {
+		return i18n.NewError("немає показників")
+	}
+	if !h.To().Equal(last) {
+		return i18n.Errorf("немає показників за %s",
+			monthRange(h.To().AddDate(0, 1, 0), last))
+	}
+	limit := int(math.Pow10(h.Meter.Digits))
+	zones := len(h.Kwh[0])
+	if zones == 0 {
+		return i18n.NewError("не вказано показники зон")
+	}
+	for m, kwh := range h.Kwh {
+		date := h.From.AddDate(0, m, 0)
+		if len(kwh) != zones {
+			return i18n.Errorf("кількість зон змінилась з %d на %d в %s",
+				zones, len(kwh), monthRange(date, date))
+		}
+		for z, cur := range kwh {
+			if cur >= limit {
+				return i18n.Errorf("показник %d більший за розрядність "+
+					"лічильника в %s, зона %d", cur,
+					monthRange(date, date), z+1)
+			}
+			if m == 0 {
+				continue
+			}
+			pre := h.Kwh[m-1][z]
+			if cur < pre && (cur-pre+limit)*100 > limit*RolloverPercent {
+				return i18n.Errorf("неправдоподібний перехід через "+
+					"нуль в %s, зона %d: %d → %d",
+					monthRange(date, date), z+1, pre, cur)
+			}
+		}
+	}
+	return nil
+}
+
+// AddHistory додає в поточну організацію точки обліку, лічильники і їх
+// показники за минулі місяці в одній транзакції та встановлює дату
+// наступного звіту на місяць після останніх показників. Організація не
+// повинна мати лічильників. Показники перевіряються функцією
+// CheckHistory. Якщо хоч один лічильник не додано, то не додається
+// жоден і повертається MetersError. З dryRun лічильники тільки
+// перевіряються.
+func (stor *Storage) AddHistory(history []*History, dryRun bool) error {
+	meters := make([]*Meter, len(history))
+	for i, h := range history {
+		meters[i] = h.Meter
+	}
+	errs := CheckHistory(history)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Початок транзакції
+	tx, err := stor.Begin()
+	if err != nil {
+		panic(err)
+	}
+	queryMetersCount := `
+	SELECT count(*)
+	  FROM meters JOIN places USING(place_id)
+	 WHERE site_id = ?
+	`
+	var count int
+	err = tx.QueryRow(queryMetersCount, stor.site).Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	if count > 0 {
+		tx.Rollback()
+		return ErrSiteNotEmpty
+	}
+
+	// Лічильники і показники
+	errs = addEachMeter(tx, meters, func(i int) error {
+		err := addPlaceIfNotExists(tx, stor.site, meters[i])
+		if err != nil {
+			return err
+		}
+		err = addMeter(tx, stor.site, meters[i])
+		if err != nil {
+			return err
+		}
+		return addHistoryKwh(tx, history[i])
+	})
+
+	// Дата наступного звіту
+	if len(errs) == 0 && len(history) > 0 {
+		stmtSetNextDate := `
+		UPDATE sites
+		   SET next_date = ?
+		 WHERE site_id = ?
+		`
+		nextDate := dateToString(history[0].To().AddDate(0, 1, 0))
+		_, err := tx.Exec(stmtSetNextDate, nextDate, stor.site)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return endAddMeters(tx, meters, errs, dryRun)
+}
+
+// addHistoryKwh додає показники лічильника за кілька місяців.
+func addHistoryKwh(tx *sql.Tx, h *History) error {
+	stmtAddKwh := `
+	INSERT INTO readings (rdate, meter_id, zone, kwh)
+	VALUES (?, ?, ?, ?)
+	`
+	stmt, err := tx.Prepare(stmtAddKwh)
+	if err != nil {
+		panic(err)
+	}
+	defer stmt.Close()
+	for m, kwh := range h.Kwh {
+		date := dateToString(h.From.AddDate(0, m, 0))
+		for z, v := range kwh {
+			_, err := stmt.Exec(date, h.Meter.id, z+1, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
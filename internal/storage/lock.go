@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Розширення файла блокування бази даних. Файл створюється поруч з
+// базою даних, тому блокування працює і в спільних мережевих
+// каталогах, де блокування файлів операційної системи ненадійні.
+const LockExt = ".lock"
+
+var ErrLocked = i18n.NewError("база даних відкрита для запису")
+
+// LockError повертається, якщо базу даних вже відкрито для запису.
+// Holder описує власника блокування: користувача, компʼютер, процес і
+// час відкриття.
+type LockError struct {
+	Path   string // файл блокування
+	Holder string
+	own    bool // заблоковано цим процесом
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrLocked, e.Holder)
+}
+
+func (e *LockError) Unwrap() error {
+	return ErrLocked
+}
+
+// Блокування бази даних одним процесом
+type dbLock struct {
+	path string
+}
+
+// lockDatabase створює файл блокування бази даних dbPath. Якщо файл
+// вже є, повертається LockError. Блокування процесу, який завершився
+// на цьому ж компʼютері, видаляється.
+func lockDatabase(dbPath string) (*dbLock, error) {
+	path := dbPath + LockExt
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+			0644)
+		if err == nil {
+			_, err = f.WriteString(lockHolder())
+			if err1 := f.Close(); err == nil {
+				err = err1
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return &dbLock{path}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		// Блокування іншим процесом
+		holder, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // щойно звільнено
+			}
+			return nil, err
+		}
+		if !staleLock(string(holder)) {
+			return nil, &LockError{path,
+				describeHolder(string(holder)),
+				ownLock(string(holder))}
+		}
+		err = os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+}
+
+// release видаляє файл блокування.
+func (lock *dbLock) release() error {
+	if lock == nil {
+		return nil
+	}
+	err := os.Remove(lock.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Unlock видаляє блокування бази даних dbPath, наприклад, залишене
+// програмою, яка аварійно завершилась на іншому компʼютері.
+func Unlock(dbPath string) error {
+	err := os.Remove(dbPath + LockExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// lockHolder повертає вміст файла блокування: рядки з користувачем,
+// компʼютером, ідентифікатором процесу і часом.
+func lockHolder() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s\n%s\n%d\n%s\n", name, host, os.Getpid(),
+		time.Now().Format(time.RFC3339))
+}
+
+// parseHolder розбирає вміст файла блокування.
+func parseHolder(holder string) (name, host string, pid int, since time.Time) {
+	lines := strings.Split(holder, "\n")
+	for len(lines) < 4 {
+		lines = append(lines, "")
+	}
+	pid, _ = strconv.Atoi(lines[2])
+	since, _ = time.Parse(time.RFC3339, lines[3])
+	return lines[0], lines[1], pid, since
+}
+
+// describeHolder описує власника блокування для користувача.
+func describeHolder(holder string) string {
+	name, host, pid, since := parseHolder(holder)
+	if name == "" && host == "" {
+		return i18n.T("невідомий власник")
+	}
+	desc := i18n.Sprintf("%s@%s, процес %d", name, host, pid)
+	if !since.IsZero() {
+		desc += i18n.Sprintf(", з %s", since.Format("2006-01-02 15:04"))
+	}
+	return desc
+}
+
+// ownLock перевіряє чи базу даних заблоковано цим процесом.
+func ownLock(holder string) bool {
+	_, host, pid, _ := parseHolder(holder)
+	thisHost, err := os.Hostname()
+	return err == nil && host == thisHost && pid == os.Getpid()
+}
+
+// staleLock перевіряє чи процес, який заблокував базу даних, вже
+// завершився. Перевіряються тільки процеси на цьому компʼютері.
+func staleLock(holder string) bool {
+	_, host, pid, _ := parseHolder(holder)
+	thisHost, err := os.Hostname()
+	if err != nil || host != thisHost || pid <= 0 {
+		return false
+	}
+	return !processAlive(pid)
+}
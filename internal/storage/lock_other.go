@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+// processAlive вважає процес живим, бо перевірка не підтримується.
+// Застаріле блокування видаляється функцією Unlock.
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	stor := createDatabase(t)
+	path := stor.GetFilepath()
+
+	// Друге відкриття для запису
+	_, err := Open(path)
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) || !errors.Is(err, ErrLocked) {
+		t.Fatalf("second open want LockError, got %v", err)
+	}
+	host, _ := os.Hostname()
+	want := fmt.Sprintf("@%s, процес %d", host, os.Getpid())
+	if !strings.Contains(lockErr.Holder, want) {
+		t.Errorf("holder want %q, got %q", want, lockErr.Holder)
+	}
+
+	// Читання під час запису
+	reader, err := OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("read-only open: %s", err)
+	}
+	defer reader.Close()
+	if !reader.ReadOnly() || stor.ReadOnly() {
+		t.Error("ReadOnly() error")
+	}
+	reports := stor.GetNextReports()
+	for _, report := range reports {
+		report.CurKwh += 10
+	}
+	err = stor.SaveReports(reports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reader.GetNextDate().Equal(MakeDate(2022, 4)) {
+		t.Error("reader does not see saved reports")
+	}
+	if reader.SaveDrafts(reader.GetNextReports()) == nil {
+		t.Error("read-only database changed")
+	}
+	var mode string
+	err = stor.QueryRow("PRAGMA journal_mode").Scan(&mode)
+	if err != nil || mode != "wal" {
+		t.Errorf("journal mode want wal, got %q %v", mode, err)
+	}
+
+	// Після закриття блокування знімається
+	stor.Close()
+	stor, err = Open(path)
+	if err != nil {
+		t.Fatalf("open after close: %s", err)
+	}
+	stor.Close()
+}
+
+func TestStaleLock(t *testing.T) {
+	stor := createDatabase(t)
+	path := stor.GetFilepath()
+	stor.Close()
+
+	// Процес на цьому компʼютері завершився
+	host, _ := os.Hostname()
+	holder := fmt.Sprintf("user\n%s\n%d\n", host, 1<<30)
+	err := os.WriteFile(path+LockExt, []byte(holder), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stor, err = Open(path)
+	if err != nil {
+		t.Fatalf("stale lock not removed: %s", err)
+	}
+	stor.Close()
+
+	// Процес на іншому компʼютері
+	holder = "user\nother-host\n1\n"
+	err = os.WriteFile(path+LockExt, []byte(holder), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Open(path)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("open locked by other host: %v", err)
+	}
+	err = Unlock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stor, err = Open(path)
+	if err != nil {
+		t.Fatalf("open after unlock: %s", err)
+	}
+	stor.Close()
+}
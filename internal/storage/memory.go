@@ -0,0 +1,723 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Memory зберігає дані обліку в памʼяті. Поводиться так само як
+// Storage, але не потребує файла бази даних, тому використовується для
+// тестування споживачів Store. SQL запити не підтримуються.
+type Memory struct {
+	mu       sync.Mutex
+	sites    []*memSite
+	site     *memSite // поточна організація
+	places   map[memPlaceKey]*memPlace
+	meters   []*memMeter
+	readings map[memKey]*memReading
+	budgets  map[memBudgetKey]int
+	lastID   int64
+}
+
+// Організація
+type memSite struct {
+	id       int64
+	name     string
+	nextDate time.Time
+	closedAt time.Time
+}
+
+// Ключ точки обліку
+type memPlaceKey struct {
+	site int64
+	name string
+}
+
+// Точка обліку
+type memPlace struct {
+	substation int
+	eic        string
+}
+
+// Лічильник
+type memMeter struct {
+	id     int64
+	site   int64
+	place  string
+	active bool
+	model  string
+	year   int
+	serial string
+	digits int
+	ratio  int
+}
+
+// Ключ показника
+type memKey struct {
+	date    string
+	meterID int64
+	zone    int
+}
+
+// Показник лічильника
+type memReading struct {
+	kwh        int
+	annotation string
+}
+
+// Ключ ліміту
+type memBudgetKey struct {
+	site   int64
+	place  string
+	date   string
+	yearly bool
+}
+
+// NewMemory створює пусте сховище в памʼяті з організацією
+// DefaultSiteName і датою наступного звіту firstDate.
+func NewMemory(firstDate time.Time) *Memory {
+	site := &memSite{
+		id:       1,
+		name:     DefaultSiteName,
+		nextDate: MakeDate(firstDate.Year(), int(firstDate.Month())),
+	}
+	return &Memory{
+		sites:    []*memSite{site},
+		site:     site,
+		places:   make(map[memPlaceKey]*memPlace),
+		readings: make(map[memKey]*memReading),
+		budgets:  make(map[memBudgetKey]int),
+	}
+}
+
+// Close нічого не робить, дані залишаються в памʼяті.
+func (mem *Memory) Close() {
+}
+
+// GetFilepath повертає пустий рядок, файла бази даних нема.
+func (mem *Memory) GetFilepath() string {
+	return ""
+}
+
+// ReadOnly завжди повертає false, дані в памʼяті можна змінювати.
+func (mem *Memory) ReadOnly() bool {
+	return false
+}
+
+//--------------------------- SITE FUNCTIONS ---------------------------
+
+// GetSites повертає всі організації.
+func (mem *Memory) GetSites() []*Site {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	sites := make([]*Site, 0)
+	for _, s := range mem.sites {
+		sites = append(sites, &Site{s.id, s.name, s.closedAt})
+	}
+	return sites
+}
+
+// GetSite повертає поточну організацію.
+func (mem *Memory) GetSite() *Site {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return &Site{mem.site.id, mem.site.name, mem.site.closedAt}
+}
+
+// SetSite робить організацію поточною. Лічильники, звіти і дати
+// відносяться до поточної організації.
+func (mem *Memory) SetSite(site *Site) error {
+	if site == nil {
+		return ErrMissingSite
+	}
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	for _, s := range mem.sites {
+		if s.id == site.id {
+			mem.site = s
+			return nil
+		}
+	}
+	return ErrMissingSite
+}
+
+// AddSite додає організацію з датою першого звіту firstDate.
+func (mem *Memory) AddSite(site *Site, firstDate time.Time) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	switch {
+	case utf8.RuneCountInString(strings.TrimSpace(site.Name)) == 0:
+		return constraintFailed("site_name_empty")
+	case utf8.RuneCountInString(site.Name) > 48:
+		return constraintFailed("site_name_too_long")
+	}
+	for _, s := range mem.sites {
+		if s.name == site.Name {
+			return constraintError(
+				"UNIQUE constraint failed: sites.name")
+		}
+	}
+	site.id = mem.sites[len(mem.sites)-1].id + 1
+	mem.sites = append(mem.sites, &memSite{
+		id:       site.id,
+		name:     site.Name,
+		nextDate: MakeDate(firstDate.Year(), int(firstDate.Month())),
+	})
+	return nil
+}
+
+//-------------------------- METER FUNCTIONS ---------------------------
+
+// GetActiveMeters повертає діючі лічильники.
+func (mem *Memory) GetActiveMeters() []*Meter {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	meters := make([]*Meter, 0)
+	for _, m := range mem.sortedMeters() {
+		if m.active && m.site == mem.site.id {
+			meters = append(meters, mem.meter(m))
+		}
+	}
+	return meters
+}
+
+// AddMeter додає лічильник з початковими показниками
+func (mem *Memory) AddMeter(meter *Meter, kwh []int) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return mem.addMeter(meter, kwh)
+}
+
+// AddMeters додає лічильники з початковими показниками kwh. Якщо хоч
+// один лічильник не додано, то не додається жоден і повертається
+// MetersError з помилками всіх лічильників. З dryRun лічильники тільки
+// перевіряються.
+func (mem *Memory) AddMeters(meters []*Meter, kwh [][]int, dryRun bool) error {
+	if len(meters) != len(kwh) {
+		return i18n.NewError("кількість лічильників і показників різна")
+	}
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	// Стан для відміни змін
+	places := make(map[memPlaceKey]*memPlace, len(mem.places))
+	for key, place := range mem.places {
+		places[key] = place
+	}
+	readings := make(map[memKey]*memReading, len(mem.readings))
+	for key, reading := range mem.readings {
+		readings[key] = reading
+	}
+	metersLen, lastID := len(mem.meters), mem.lastID
+
+	var errs MetersError
+	for i, meter := range meters {
+		err := mem.addMeter(meter, kwh[i])
+		if err != nil {
+			meter.id = 0
+			errs = append(errs, &MeterError{i, meter, err})
+		}
+	}
+	if len(errs) > 0 || dryRun {
+		mem.places, mem.readings = places, readings
+		mem.meters, mem.lastID = mem.meters[:metersLen], lastID
+		for _, meter := range meters {
+			meter.id = 0
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+	return nil
+}
+
+// AddHistory додає в поточну організацію лічильники і їх показники за
+// минулі місяці та встановлює дату наступного звіту на місяць після
+// останніх показників. Організація не повинна мати лічильників.
+func (mem *Memory) AddHistory(history []*History, dryRun bool) error {
+	errs := CheckHistory(history)
+	if len(errs) > 0 {
+		return errs
+	}
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	for _, m := range mem.meters {
+		if m.site == mem.site.id {
+			return ErrSiteNotEmpty
+		}
+	}
+	for i, h := range history {
+		for _, kwh := range h.Kwh {
+			err := checkMeter(h.Meter, kwh)
+			if err != nil {
+				errs = append(errs, &MeterError{i, h.Meter, err})
+				break
+			}
+		}
+	}
+	if len(errs) > 0 || dryRun {
+		for _, h := range history {
+			h.Meter.id = 0
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+
+	for _, h := range history {
+		mem.insertMeter(h.Meter)
+		for m, kwh := range h.Kwh {
+			mem.insertKwh(h.Meter.id, h.From.AddDate(0, m, 0), kwh)
+		}
+	}
+	if len(history) > 0 {
+		mem.site.nextDate = history[0].To().AddDate(0, 1, 0)
+	}
+	return nil
+}
+
+// addMeter додає лічильник з початковими показниками.
+func (mem *Memory) addMeter(meter *Meter, kwh []int) error {
+	err := checkMeter(meter, kwh)
+	if err != nil {
+		return err
+	}
+	mem.insertMeter(meter)
+	mem.insertKwh(meter.id, mem.site.nextDate.AddDate(0, -1, 0), kwh)
+	return nil
+}
+
+// insertMeter додає лічильник і точку обліку, якщо такої нема.
+func (mem *Memory) insertMeter(meter *Meter) {
+	// Додати точку обліку, якщо такої нема
+	placeKey := memPlaceKey{mem.site.id, meter.Name}
+	if _, ok := mem.places[placeKey]; !ok {
+		mem.places[placeKey] = &memPlace{
+			substation: meter.Substation,
+			eic:        meter.Eic,
+		}
+	}
+
+	// Додати лічильник
+	mem.lastID++
+	meter.id = mem.lastID
+	mem.meters = append(mem.meters, &memMeter{
+		id:     meter.id,
+		site:   mem.site.id,
+		place:  meter.Name,
+		active: true,
+		model:  meter.Model,
+		year:   meter.Year,
+		serial: meter.Serial,
+		digits: meter.Digits,
+		ratio:  meter.Ratio,
+	})
+}
+
+// insertKwh додає показники лічильника за місяць.
+func (mem *Memory) insertKwh(meterID int64, date time.Time, kwh []int) {
+	for i, v := range kwh {
+		mem.readings[memKey{dateToString(date), meterID, i + 1}] =
+			&memReading{kwh: v}
+	}
+}
+
+// checkMeter перевіряє лічильник і початкові показники так само, як
+// обмеження схеми бази даних.
+func checkMeter(meter *Meter, kwh []int) error {
+	switch {
+	case utf8.RuneCountInString(strings.TrimSpace(meter.Name)) == 0:
+		return constraintFailed("name_empty")
+	case utf8.RuneCountInString(meter.Name) > 24:
+		return constraintFailed("name_too_long")
+	case meter.Eic != "" && utf8.RuneCountInString(meter.Eic) != 16:
+		return constraintFailed("eic_not_valid")
+	case utf8.RuneCountInString(meter.Model) > 24:
+		return constraintFailed("model_too_long")
+	case meter.Year != 0 && (meter.Year < 1000 || meter.Year > 9999):
+		return constraintFailed("year_not_valid")
+	case utf8.RuneCountInString(meter.Serial) > 24:
+		return constraintFailed("serial_too_long")
+	case meter.Digits < 1 || meter.Digits > 8:
+		return constraintFailed("digits_not_valid")
+	case meter.Ratio <= 0:
+		return constraintFailed("ratio_not_valid")
+	case len(kwh) == 0:
+		return i18n.NewError("Не вказано початкові показники")
+	case len(kwh) > 3:
+		return constraintFailed("zone_not_valid")
+	}
+	for _, v := range kwh {
+		if v < 0 {
+			return constraintFailed("kwh_not_valid")
+		}
+	}
+	return nil
+}
+
+// Помилка порушення обмеження схеми, така ж як у SQLite
+type constraintError string
+
+func (e constraintError) Error() string {
+	return string(e)
+}
+
+// constraintFailed повертає помилку порушення обмеження схеми, таку ж як
+// у SQLite.
+func constraintFailed(name string) error {
+	return constraintError("CHECK constraint failed: " + name)
+}
+
+// UpdateMeter оновлює лічильник і точку обліку.
+func (mem *Memory) UpdateMeter(meter *Meter) error {
+	//TODO
+	return errNotImplemented
+}
+
+// RemoveMeter видаляє лічильник.
+func (mem *Memory) RemoveMeter(meter *Meter) error {
+	if meter == nil || meter.id == 0 {
+		return ErrMissingMeter
+	}
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	for _, m := range mem.meters {
+		if m.id == meter.id {
+			m.active = false
+		}
+	}
+	meter.id = 0
+	return nil
+}
+
+// sortedMeters повертає лічильники впорядковані за назвою точки обліку
+// та ідентифікатором.
+func (mem *Memory) sortedMeters() []*memMeter {
+	meters := make([]*memMeter, len(mem.meters))
+	copy(meters, mem.meters)
+	sort.SliceStable(meters, func(i, j int) bool {
+		if meters[i].place != meters[j].place {
+			return meters[i].place < meters[j].place
+		}
+		return meters[i].id < meters[j].id
+	})
+	return meters
+}
+
+// meter створює Meter з лічильника і його точки обліку.
+func (mem *Memory) meter(m *memMeter) *Meter {
+	place := mem.places[memPlaceKey{m.site, m.place}]
+	return &Meter{
+		id:         m.id,
+		Substation: place.substation,
+		Eic:        place.eic,
+		Name:       m.place,
+		Model:      m.model,
+		Year:       m.year,
+		Serial:     m.serial,
+		Digits:     m.digits,
+		Ratio:      m.ratio,
+	}
+}
+
+//-------------------------- REPORT FUNCTIONS --------------------------
+
+// GetReports повертає звіт за вказану дату.
+func (mem *Memory) GetReports(date time.Time) []*Report {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return mem.reports(date, func(*memMeter) bool { return true })
+}
+
+// GetNextReports повертає форму для введення показників. Дату можна
+// прочитати функцією GetNextDate. В кожному рядку потрібно заповнити
+// поле CurKwh після чого викликати функцію SaveReports.
+func (mem *Memory) GetNextReports() []*Report {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return mem.nextReports()
+}
+
+// reports повертає звіт за вказану дату по лічильникам поточної
+// організації, для яких filter повертає true.
+func (mem *Memory) reports(date time.Time, filter func(*memMeter) bool) []*Report {
+	cur := dateToString(date)
+	pre := dateToString(date.AddDate(0, -1, 0))
+	reports := make([]*Report, 0)
+	for _, m := range mem.sortedMeters() {
+		if m.site != mem.site.id || !filter(m) {
+			continue
+		}
+		for zone := 1; zone <= 3; zone++ {
+			curReading, ok1 := mem.readings[memKey{cur, m.id, zone}]
+			preReading, ok2 := mem.readings[memKey{pre, m.id, zone}]
+			if !ok1 || !ok2 {
+				continue
+			}
+			report := &Report{
+				Meter:      mem.meter(m),
+				Zone:       zone,
+				CurKwh:     curReading.kwh,
+				PreKwh:     preReading.kwh,
+				Annotation: curReading.annotation,
+			}
+			report.Calculate()
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// GetMissingReadings повертає кількість не введених показників
+// наступного звіту.
+func (mem *Memory) GetMissingReadings() int {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	date := dateToString(mem.site.nextDate)
+	count := 0
+	for _, report := range mem.nextReports() {
+		_, ok := mem.readings[memKey{date, report.id, report.Zone}]
+		if !ok {
+			count++
+		}
+	}
+	return count
+}
+
+// nextReports повертає форму для введення показників.
+func (mem *Memory) nextReports() []*Report {
+	cur := dateToString(mem.site.nextDate)
+	pre := dateToString(mem.site.nextDate.AddDate(0, -1, 0))
+	reports := make([]*Report, 0)
+	for _, m := range mem.sortedMeters() {
+		if !m.active || m.site != mem.site.id {
+			continue
+		}
+		for zone := 1; zone <= 3; zone++ {
+			preReading, ok := mem.readings[memKey{pre, m.id, zone}]
+			if !ok {
+				continue
+			}
+			report := &Report{
+				Meter:  mem.meter(m),
+				Zone:   zone,
+				CurKwh: preReading.kwh,
+				PreKwh: preReading.kwh,
+			}
+			curReading, ok := mem.readings[memKey{cur, m.id, zone}]
+			if ok {
+				report.CurKwh = curReading.kwh
+				report.Annotation = curReading.annotation
+				report.Calculate()
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// SaveReports зберігає звіт.
+func (mem *Memory) SaveReports(reports []*Report) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	err := mem.saveDrafts(reports)
+	if err != nil {
+		return err
+	}
+	return mem.gotoNextDate()
+}
+
+// SaveDrafts зберігає введені показники без переходу до наступної дати.
+func (mem *Memory) SaveDrafts(reports []*Report) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return mem.saveDrafts(reports)
+}
+
+// saveDrafts зберігає показники за дату наступного звіту. Спочатку
+// перевіряються всі показники, тож при помилці не зберігається жоден.
+func (mem *Memory) saveDrafts(reports []*Report) error {
+	next := make(map[memKey]bool)
+	for _, report := range mem.nextReports() {
+		next[memKey{"", report.id, report.Zone}] = true
+	}
+	for _, report := range reports {
+		report.Calculate()
+		if !next[memKey{"", report.id, report.Zone}] {
+			continue
+		}
+		if report.CurKwh < 0 {
+			return constraintFailed("kwh_not_valid")
+		}
+		if utf8.RuneCountInString(report.Annotation) > 32 {
+			return constraintFailed("annotation_too_long")
+		}
+	}
+	date := dateToString(mem.site.nextDate)
+	for _, report := range reports {
+		if next[memKey{"", report.id, report.Zone}] {
+			mem.readings[memKey{date, report.id, report.Zone}] =
+				&memReading{report.CurKwh, report.Annotation}
+		}
+	}
+	return nil
+}
+
+// gotoNextDate переходить до слідуючої дати, якщо всі показники введені.
+func (mem *Memory) gotoNextDate() error {
+	date := dateToString(mem.site.nextDate)
+	for _, report := range mem.nextReports() {
+		_, ok := mem.readings[memKey{date, report.id, report.Zone}]
+		if !ok {
+			return constraintError("missing_readings")
+		}
+	}
+	mem.site.nextDate = mem.site.nextDate.AddDate(0, 1, 0)
+	mem.site.closedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+// GetTotal повертає суму витраченої енергії за вказану дату, по вказаним
+// точкам обліку.
+func (mem *Memory) GetTotal(from, to time.Time, name ...string) int {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	names := make(map[string]bool)
+	for _, n := range name {
+		names[n] = true
+	}
+	filter := func(m *memMeter) bool {
+		return len(names) == 0 || names[m.place]
+	}
+	date := MakeDate(from.Year(), int(from.Month()))
+	if date.Before(from) {
+		date = date.AddDate(0, 1, 0)
+	}
+	var total int
+	for ; !date.After(to); date = date.AddDate(0, 1, 0) {
+		for _, report := range mem.reports(date, filter) {
+			total = total + report.Energy
+		}
+	}
+	return total
+}
+
+// GetNextTotal повертає суму витраченої енергії заданого звіту, плюс
+// сума енргії видалених лічильників за поточну дату.
+func (mem *Memory) GetNextTotal(reports []*Report) int {
+	var total int
+	for _, row := range reports {
+		row.Calculate()
+		total = total + row.Energy
+	}
+
+	// вибираємо видалені лічильники
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	notActive := func(m *memMeter) bool { return !m.active }
+	for _, report := range mem.reports(mem.site.nextDate, notActive) {
+		total = total + report.Energy
+	}
+	return total
+}
+
+//-------------------------- BUDGET FUNCTIONS --------------------------
+
+// GetBudgets повертає ліміти поточної організації: спочатку ліміти
+// організації, потім точок обліку за назвою, за датою.
+func (mem *Memory) GetBudgets() []*Budget {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	budgets := make([]*Budget, 0)
+	for key, kwh := range mem.budgets {
+		if key.site != mem.site.id {
+			continue
+		}
+		date, err := stringToDate(key.date)
+		if err != nil {
+			panic(err)
+		}
+		budgets = append(budgets, &Budget{key.place, date, key.yearly,
+			kwh})
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		a, b := budgets[i], budgets[j]
+		switch {
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Yearly != b.Yearly:
+			return b.Yearly
+		}
+		return a.Date.Before(b.Date)
+	})
+	return budgets
+}
+
+// SetBudget додає ліміт або замінює існуючий ліміт точки обліку з тим
+// самим початком дії. Дата ліміту встановлюється на перший місяць
+// його дії.
+func (mem *Memory) SetBudget(budget *Budget) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	key, err := mem.budgetKey(budget)
+	if err != nil {
+		return err
+	}
+	if budget.Kwh < 0 {
+		return constraintFailed("budget_not_valid")
+	}
+	mem.budgets[key] = budget.Kwh
+	return nil
+}
+
+// RemoveBudget видаляє ліміт. Відсутній ліміт не є помилкою.
+func (mem *Memory) RemoveBudget(budget *Budget) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	key, err := mem.budgetKey(budget)
+	if err != nil {
+		return err
+	}
+	delete(mem.budgets, key)
+	return nil
+}
+
+// budgetKey повертає ключ ліміту поточної організації.
+func (mem *Memory) budgetKey(budget *Budget) (memBudgetKey, error) {
+	if budget.Name != "" {
+		_, ok := mem.places[memPlaceKey{mem.site.id, budget.Name}]
+		if !ok {
+			return memBudgetKey{}, ErrMissingPlace
+		}
+	}
+	budget.normalize()
+	return memBudgetKey{mem.site.id, budget.Name,
+		dateToString(budget.Date), budget.Yearly}, nil
+}
+
+//-------------------------- DATE  FUNCTIONS ---------------------------
+
+// GetNextDate повертає дату наступного звіту.
+func (mem *Memory) GetNextDate() time.Time {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	return mem.site.nextDate
+}
+
+//-------------------------- QUERY FUNCTIONS ---------------------------
+
+// QueryLines не підтримується, повертає ErrQueryNotSupported.
+func (mem *Memory) QueryLines(query string, args ...any) ([][]string, error) {
+	return nil, ErrQueryNotSupported
+}
+
+// QueryLine не підтримується, повертає ErrQueryNotSupported.
+func (mem *Memory) QueryLine(query string, args ...any) ([]string, error) {
+	return nil, ErrQueryNotSupported
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	_ "embed"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+//go:embed migrate_2.sql
+var migrate2 string
+
+//go:embed migrate_3.sql
+var migrate3 string
+
+//go:embed migrate_4.sql
+var migrate4 string
+
+// Оновлення бази даних. Ключ - версія бази даних після оновлення.
+var migrations = map[int]string{
+	2: migrate2,
+	3: migrate3,
+	4: migrate4,
+}
+
+// migrate оновлює базу даних попередньої версії до DBVERSION. Перед
+// оновленням створюється резервна копія.
+func (stor *Storage) migrate() error {
+	version := stor.GetVersion()
+	if version == DBVERSION {
+		return nil
+	}
+	if version < 1 || version > DBVERSION {
+		return i18n.NewError("не підтримувана версія бази даних")
+	}
+
+	err := stor.autoBackup()
+	if err != nil {
+		return err
+	}
+	for v := version + 1; v <= DBVERSION; v++ {
+		_, err := stor.Exec(migrations[v])
+		if err != nil {
+			return i18n.Errorf("оновлення до версії %d: %w", v, err)
+		}
+	}
+
+	// Представлення і тригери останньої версії
+	_, err = stor.Exec(schema)
+	return err
+}
@@ -0,0 +1,62 @@
+-- EnergoZvit
+-- Оновлення бази даних з версії 1 до версії 2: організації.
+-- Після оновлення виконується schema.sql, який створює представлення
+-- та тригери.
+--
+PRAGMA foreign_keys = OFF;
+BEGIN TRANSACTION;
+--
+-- Представлення і тригери створюються заново
+DROP VIEW IF EXISTS reports;
+DROP VIEW IF EXISTS next_reports;
+DROP TRIGGER IF EXISTS goto_next_date_update;
+--
+-- Організація, до якої відносяться всі існуючі точки обліку
+CREATE TABLE sites (
+    site_id    INTEGER PRIMARY KEY ASC NOT NULL,
+    name       VARCHAR(48) UNIQUE NOT NULL
+               CONSTRAINT site_name_empty
+               CHECK(length(trim(name)) != 0)
+               CONSTRAINT site_name_too_long
+               CHECK(length(name) <= 48),
+    next_date  CHAR(10) NOT NULL
+               CONSTRAINT wrong_date_format
+               CHECK(date(next_date) NOT NULL)
+               CONSTRAINT wrong_day_in_date
+               CHECK(next_date == date(next_date, 'start of month'))
+);
+INSERT INTO sites (site_id, name, next_date)
+SELECT 1, 'Основна', value
+  FROM service
+ WHERE skey = 'next_date';
+--
+-- Точки обліку з посиланням на організацію
+CREATE TABLE places_v2 (
+    place_id   INTEGER PRIMARY KEY ASC NOT NULL,
+    site_id    INTEGER NOT NULL
+               REFERENCES sites
+               ON DELETE RESTRICT
+               ON UPDATE RESTRICT,
+    substation INTEGER,
+    eic        CHAR(16)
+               CONSTRAINT eic_not_valid
+               CHECK(length(eic) == 16),
+    name       VARCHAR(24) NOT NULL
+               CONSTRAINT name_empty
+               CHECK(length(trim(name)) != 0)
+               CONSTRAINT name_too_long
+               CHECK(length(name) <= 24),
+    UNIQUE (site_id, name)
+);
+INSERT INTO places_v2 (place_id, site_id, substation, eic, name)
+SELECT place_id, 1, substation, eic, name
+  FROM places;
+DROP TABLE places;
+ALTER TABLE places_v2 RENAME TO places;
+--
+-- Дата наступного звіту тепер в таблиці sites
+DROP TABLE service;
+--
+PRAGMA user_version = 2;
+COMMIT;
+PRAGMA foreign_keys = ON;
@@ -0,0 +1,6 @@
+-- EnergoZvit
+-- Оновлення бази даних з версії 2 до версії 3: ліміти споживання.
+-- Таблиця budgets створюється в schema.sql, який виконується після
+-- оновлення.
+--
+PRAGMA user_version = 3;
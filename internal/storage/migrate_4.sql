@@ -0,0 +1,6 @@
+-- EnergoZvit
+-- Оновлення бази даних з версії 3 до версії 4: час закриття місяця.
+--
+ALTER TABLE sites ADD COLUMN closed_at TEXT;
+--
+PRAGMA user_version = 4;
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"database/sql"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+//go:embed testdata/schema_v1.sql
+var schema_v1 string
+
+//go:embed testdata/data_v1.sql
+var data_v1 string
+
+func TestMigrateV1(t *testing.T) {
+	// База даних версії 1
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "v1.sqlite")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(schema_v1 + data_v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// Оновлення при відкритті
+	stor, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("open v1 database: %s", err)
+	}
+	defer stor.Close()
+	if stor.GetVersion() != DBVERSION {
+		t.Errorf("version want %d, got %d", DBVERSION,
+			stor.GetVersion())
+	}
+	if stor.GetSite().Name != DefaultSiteName {
+		t.Errorf("site want %s, got %s", DefaultSiteName,
+			stor.GetSite().Name)
+	}
+	if !stor.GetNextDate().Equal(MakeDate(2022, 3)) {
+		t.Errorf("next date want 2022-03, got %s",
+			stor.GetNextDate().Format(DateLayout))
+	}
+	want := []*Meter{
+		{1, 208, "1234567890abcdef", "Госпдвір",
+			"НІК2301АП1", 2020, "344848", 4, 40},
+		{3, 205, "", "Контора",
+			"", 0, "001930", 5, 1},
+	}
+	diff := cmp.Diff(want, stor.GetActiveMeters(),
+		cmp.AllowUnexported(Meter{}))
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if total := stor.GetTotal(MakeDate(2021, 12), MakeDate(2022, 2)); total != 30208 {
+		t.Errorf("GetTotal() want 30208, got %d", total)
+	}
+
+	// Ліміти з версії 3
+	err = stor.SetBudget(&Budget{"Госпдвір", MakeDate(2022, 1), false,
+		7000})
+	if err != nil {
+		t.Errorf("set budget after migration: %s", err)
+	}
+
+	// Закриття місяця після оновлення
+	err = stor.SaveReports(stor.GetNextReports())
+	if err != nil {
+		t.Errorf("save reports error: %s", err)
+	}
+	if stor.GetSite().ClosedAt.IsZero() {
+		t.Error("close time not saved after migration")
+	}
+	problems, err := stor.Check()
+	if err != nil || len(problems) != 0 {
+		t.Errorf("check after migration: %v %v", err, problems)
+	}
+
+	// Резервна копія перед оновленням
+	backups, err := os.ReadDir(filepath.Join(dir, BackupDir))
+	if err != nil || len(backups) == 0 {
+		t.Error("no backup before migration")
+	}
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+//-------------------------- PERIOD FUNCTIONS --------------------------
+
+// Period містить спожиту енергію за кілька місяців по точках обліку і
+// тарифних зонах з розбивкою по місяцях. Енергія замінених і видалених
+// лічильників додається до їх точок обліку.
+type Period struct {
+	From   time.Time
+	To     time.Time
+	Months []time.Time // місяці від From до To
+	Places []*PeriodPlace
+	Energy []int // енергія всіх точок обліку по місяцях
+	Total  int
+}
+
+// PeriodPlace містить спожиту енергію точки обліку за період.
+type PeriodPlace struct {
+	Substation int
+	Eic        string
+	Name       string
+	Zones      []*PeriodZone
+	Energy     []int // енергія по місяцях
+	Total      int
+}
+
+// PeriodZone містить спожиту енергію тарифної зони точки обліку за
+// період.
+type PeriodZone struct {
+	Zone   int
+	Energy []int // енергія по місяцях
+	Total  int
+}
+
+// GetPeriod повертає спожиту енергію за місяці від from до to
+// включно. Місяці без звітів мають нульову енергію.
+func GetPeriod(stor Store, from, to time.Time) *Period {
+	period := &Period{From: from, To: to}
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		period.Months = append(period.Months, date)
+	}
+	months := len(period.Months)
+	period.Energy = make([]int, months)
+	period.Places = make([]*PeriodPlace, 0)
+
+	places := make(map[string]*PeriodPlace)
+	for i, date := range period.Months {
+		for _, report := range stor.GetReports(date) {
+			place, ok := places[report.Name]
+			if !ok {
+				place = &PeriodPlace{
+					Substation: report.Substation,
+					Eic:        report.Eic,
+					Name:       report.Name,
+					Zones:      []*PeriodZone{},
+					Energy:     make([]int, months),
+				}
+				places[report.Name] = place
+				period.Places = append(period.Places, place)
+			}
+			zone := place.zone(report.Zone, months)
+			zone.Energy[i] += report.Energy
+			zone.Total += report.Energy
+			place.Energy[i] += report.Energy
+			place.Total += report.Energy
+			period.Energy[i] += report.Energy
+			period.Total += report.Energy
+		}
+	}
+	sort.Slice(period.Places, func(i, j int) bool {
+		return period.Places[i].Name < period.Places[j].Name
+	})
+	return period
+}
+
+// zone повертає тарифну зону точки обліку. Відсутня зона додається
+// зі збереженням порядку зон.
+func (place *PeriodPlace) zone(number, months int) *PeriodZone {
+	i := sort.Search(len(place.Zones), func(i int) bool {
+		return place.Zones[i].Zone >= number
+	})
+	if i < len(place.Zones) && place.Zones[i].Zone == number {
+		return place.Zones[i]
+	}
+	zone := &PeriodZone{Zone: number, Energy: make([]int, months)}
+	place.Zones = append(place.Zones, nil)
+	copy(place.Zones[i+1:], place.Zones[i:])
+	place.Zones[i] = zone
+	return zone
+}
+
+// GetQuarter повертає спожиту енергію за квартал, в який входить date.
+func GetQuarter(stor Store, date time.Time) *Period {
+	from, to := QuarterRange(date)
+	return GetPeriod(stor, from, to)
+}
+
+// GetYear повертає спожиту енергію за рік, в який входить date.
+func GetYear(stor Store, date time.Time) *Period {
+	from, to := YearRange(date)
+	return GetPeriod(stor, from, to)
+}
+
+// Quarter повертає номер кварталу (1-4), в який входить date.
+func Quarter(date time.Time) int {
+	return (int(date.Month())-1)/3 + 1
+}
+
+// QuarterRange повертає перший і останній місяць кварталу, в який
+// входить date.
+func QuarterRange(date time.Time) (from, to time.Time) {
+	from = MakeDate(date.Year(), (Quarter(date)-1)*3+1)
+	return from, from.AddDate(0, 2, 0)
+}
+
+// YearRange повертає перший і останній місяць року, в який входить
+// date.
+func YearRange(date time.Time) (from, to time.Time) {
+	return MakeDate(date.Year(), 1), MakeDate(date.Year(), 12)
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetPeriod(t *testing.T) {
+	stor := createDatabase(t)
+
+	// В АВМ в листопаді замінено лічильник, в січні старий видалено.
+	period := GetPeriod(stor, MakeDate(2021, 11), MakeDate(2022, 1))
+	want := &Period{
+		From: MakeDate(2021, 11),
+		To:   MakeDate(2022, 1),
+		Months: []time.Time{MakeDate(2021, 11), MakeDate(2021, 12),
+			MakeDate(2022, 1)},
+		Places: []*PeriodPlace{
+			{220, "", "АВМ", []*PeriodZone{
+				{1, []int{1160, 2080, 1160}, 4400}},
+				[]int{1160, 2080, 1160}, 4400},
+			{208, "1234567890abcdef", "Госпдвір", []*PeriodZone{
+				{1, []int{7080, 7840, 7440}, 22360}},
+				[]int{7080, 7840, 7440}, 22360},
+			{205, "", "Контора", []*PeriodZone{
+				{1, []int{841, 998, 775}, 2614},
+				{2, []int{1500, 1000, 800}, 3300}},
+				[]int{2341, 1998, 1575}, 5914},
+		},
+		Energy: []int{10581, 11918, 10175},
+		Total:  32674,
+	}
+	diff := cmp.Diff(want, period)
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Сума за період збігається з GetTotal.
+	total := stor.GetTotal(period.From, period.To)
+	if total != period.Total {
+		t.Errorf("total want %d, got %d", total, period.Total)
+	}
+
+	// Місяці без звітів
+	period = GetYear(stor, MakeDate(2023, 5))
+	if len(period.Months) != 12 || len(period.Places) != 0 ||
+		period.Total != 0 {
+		t.Errorf("empty year: %d months, %d places, total %d",
+			len(period.Months), len(period.Places), period.Total)
+	}
+}
+
+func TestQuarterRange(t *testing.T) {
+	tests := []struct {
+		month, quarter, from, to int
+	}{
+		{1, 1, 1, 3},
+		{3, 1, 1, 3},
+		{4, 2, 4, 6},
+		{8, 3, 7, 9},
+		{12, 4, 10, 12},
+	}
+	for _, test := range tests {
+		date := MakeDate(2022, test.month)
+		from, to := QuarterRange(date)
+		got := []int{Quarter(date), int(from.Month()), int(to.Month())}
+		want := []int{test.quarter, test.from, test.to}
+		diff := cmp.Diff(want, got)
+		if diff != "" || from.Year() != 2022 || to.Year() != 2022 {
+			t.Errorf("month %d mismatch (-want +got):\n%s",
+				test.month, diff)
+		}
+	}
+}
@@ -1,27 +1,55 @@
 -- EnergoZvit
 -- Sqlite database schema
 --
-PRAGMA user_version = 1;
+PRAGMA user_version = 4;
 PRAGMA foreign_keys = ON;
 --
 -------------------------------- TABLES --------------------------------
 --
+-- Таблиця організацій. Кожна організація має свої точки обліку і
+-- власну дату наступного звіту.
+CREATE TABLE IF NOT EXISTS sites (
+    site_id    -- Первинний ключ
+               INTEGER PRIMARY KEY ASC NOT NULL,
+    name       -- Назва організації
+               VARCHAR(48) UNIQUE NOT NULL
+               CONSTRAINT site_name_empty
+               CHECK(length(trim(name)) != 0)
+               CONSTRAINT site_name_too_long
+               CHECK(length(name) <= 48),
+    next_date  -- Дата наступного звіту в форматі РРРР-ММ-ДД, день 01
+               CHAR(10) NOT NULL
+               CONSTRAINT wrong_date_format
+               CHECK(date(next_date) NOT NULL)
+               CONSTRAINT wrong_day_in_date
+               CHECK(next_date == date(next_date, 'start of month')),
+    closed_at  -- Час закриття останнього місяця, RFC 3339 UTC
+               TEXT
+);
+--
 -- Таблиця точок обліку, де встановлено лічильник
 CREATE TABLE IF NOT EXISTS places (
     place_id   -- Первинний ключ
                INTEGER PRIMARY KEY ASC NOT NULL,      
+    site_id    -- Посилання на організацію
+               INTEGER NOT NULL
+               REFERENCES sites
+               ON DELETE RESTRICT
+               ON UPDATE RESTRICT,
     substation -- Номер підстанції
                INTEGER,
     eic        -- Energy Identification Code
                CHAR(16)                      
                CONSTRAINT eic_not_valid
                CHECK(length(eic) == 16),
-    name       -- Назва точки обліку
-               VARCHAR(24) UNIQUE NOT NULL   
+    name       -- Назва точки обліку, унікальна в організації
+               VARCHAR(24) NOT NULL   
                CONSTRAINT name_empty
                CHECK(length(trim(name)) != 0)
                CONSTRAINT name_too_long
-               CHECK(length(name) <= 24)
+               CHECK(length(name) <= 24),
+    -- Унікальний ключ рядка
+    UNIQUE (site_id, name)
 );
 --
 -- Таблиця лічильників
@@ -88,40 +116,57 @@ CREATE TABLE IF NOT EXISTS readings (
     PRIMARY KEY (rdate, meter_id, zone)
 );
 --
--- Сервісна таблиця для внутрішнього використання
-CREATE TABLE IF NOT EXISTS service ( 
-    skey       -- Ключ
-               VARCHAR(16) PRIMARY KEY NOT NULL,
-    value      -- Значення
-               VARCHAR(16) NOT NULL
+-- Ліміти споживання енергії точок обліку або всієї організації.
+-- Місячний ліміт діє з вказаного місяця до наступного місячного
+-- ліміту, річний ліміт тільки на вказаний рік.
+CREATE TABLE IF NOT EXISTS budgets (
+    site_id    -- Посилання на організацію
+               INTEGER NOT NULL
+               REFERENCES sites
+               ON DELETE RESTRICT
+               ON UPDATE RESTRICT,
+    place_id   -- Посилання на точку обліку, NULL для всієї організації
+               INTEGER
+               REFERENCES places
+               ON DELETE CASCADE
+               ON UPDATE RESTRICT,
+    bdate      -- Початок дії ліміту в форматі РРРР-ММ-ДД, день 01
+               CHAR(10) NOT NULL
+               CONSTRAINT wrong_date_format
+               CHECK(date(bdate) NOT NULL)
+               CONSTRAINT wrong_day_in_date
+               CHECK(bdate == date(bdate, 'start of month')),
+    yearly     -- Річний ліміт, bdate завжди січень
+               BOOLEAN DEFAULT false NOT NULL
+               CONSTRAINT yearly_not_valid
+               CHECK(yearly IN (false, true))
+               CONSTRAINT wrong_month_in_date
+               CHECK(NOT yearly OR bdate == date(bdate, 'start of year')),
+    kwh        -- Ліміт, кВт·год. 0 скасовує місячний ліміт
+               INTEGER NOT NULL
+               CONSTRAINT budget_not_valid
+               CHECK(kwh >= 0)
 );
 --
--- Запис початкових даних
-INSERT OR IGNORE INTO service VALUES
-    ('goto_next_date', '0'),
-    ('next_date', date('now', 'start of month'));
+-- Унікальний ключ ліміту, NULL в place_id теж унікальний
+CREATE UNIQUE INDEX IF NOT EXISTS budgets_key
+    ON budgets (site_id, ifnull(place_id, 0), bdate, yearly);
 --
---
-CREATE TRIGGER IF NOT EXISTS goto_next_date_update
-AFTER UPDATE ON service
-WHEN NEW.skey = 'goto_next_date'
+-- Закриття місяця: збільшення next_date організації на один місяць.
+-- Повертається помилка якщо введені не всі показники.
+CREATE TRIGGER IF NOT EXISTS next_date_update
+BEFORE UPDATE OF next_date ON sites
+WHEN NEW.next_date = date(OLD.next_date, '+1 month')
 BEGIN
     -- Перевірка чи дані всіх лічильників введені
     VALUES(
     CASE
         WHEN (SELECT count(*)
                 FROM next_reports
-               WHERE cur_kwh IS NULL) > 0
+               WHERE site_id = OLD.site_id
+                 AND cur_kwh IS NULL) > 0
         THEN RAISE(ABORT, 'missing_readings')
     END);
-    -- Оновлення дати
-    UPDATE service
-       SET value = date(
-           (SELECT value
-              FROM service
-             WHERE skey = 'next_date'),
-              'start of month', '+1 months')
-     WHERE skey = 'next_date';
 END;
 --
 -------------------------------- VIEWS ---------------------------------
@@ -130,6 +175,7 @@ END;
 CREATE VIEW IF NOT EXISTS reports AS
 SELECT cur.rdate      AS rdate,     -- Дата
        cur.meter_id  AS meter_id,   -- ID лічильника
+       site_id,                     -- ID організації
        substation,                  -- Номер підстанції
        eic,                         -- EIC код
        name,                        -- Назва площадки вимірювання
@@ -160,14 +206,14 @@ SELECT cur.rdate      AS rdate,     -- Дата
 -- 1. Читається ця таблиця;
 -- 2. Оновлюється (update) cur_kwh для кожного запису.
 --    Ключами є meter_id та zone;
--- 3. Оновлюється (update) ключ goto_next_date в таблиці service.
---    Повертається помилка якщо введені не всі показники. В іншому
---    випадку збільшується next_date в таблиці service.
+-- 3. Збільшується на місяць next_date організації в таблиці sites.
+--    Повертається помилка якщо введені не всі показники.
 CREATE VIEW IF NOT EXISTS next_reports AS
 SELECT pre.meter_id  AS meter_id,   -- ID лічильника
+       site_id,                     -- ID організації
        substation,                  -- Номер підстанції
        eic,                         -- EIC код
-       name,                        -- Назва площадки вимірювання
+       places.name    AS name,      -- Назва площадки вимірювання
        model,                       -- Модель лічильника
        year,                        -- Рік виготовлення лічильника
        serial,                      -- Серійний номер лічильника
@@ -188,12 +234,9 @@ SELECT pre.meter_id  AS meter_id,   -- ID лічильника
    AND cur.zone = pre.zone
   JOIN meters USING(meter_id)
   JOIN places  USING(place_id)
+  JOIN sites   USING(site_id)
  WHERE meters.active = true
-   AND pre.rdate = date(
-       (SELECT value 
-          FROM service
-         WHERE skey = 'next_date'),
-       '-1 month');
+   AND pre.rdate = date(sites.next_date, '-1 month');
 --
 --
 --
@@ -204,8 +247,10 @@ BEGIN
     INSERT OR REPLACE INTO readings (
         rdate, meter_id, zone, kwh, annotation)
     VALUES (
-        date((SELECT value FROM service WHERE skey = 'next_date'),
-            'start of month'),
+        (SELECT next_date
+           FROM sites JOIN places USING(site_id)
+           JOIN meters USING(place_id)
+          WHERE meter_id = NEW.meter_id),
         NEW.meter_id,
         NEW.zone,
         NEW.cur_kwh,
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+//-------------------------- SERIES FUNCTIONS --------------------------
+
+// SeriesFlag позначає особливості даних за місяць, які треба врахувати
+// при побудові графіків, пошуку аномалій і прогнозах.
+type SeriesFlag uint8
+
+const (
+	FlagMissing   SeriesFlag = 1 << iota // немає звіту за місяць
+	FlagReplaced                         // змінився склад лічильників
+	FlagRollover                         // перехід показників через нуль
+	FlagZero                             // енергія не спожита
+	FlagAnnotated                        // є примітка до показників
+)
+
+// Назви ознак в порядку бітів
+var seriesFlagNames = []string{
+	"немає звіту", "заміна лічильника", "перехід через нуль",
+	"нульове споживання", "примітка",
+}
+
+// Has перевіряє чи встановлена ознака flag.
+func (f SeriesFlag) Has(flag SeriesFlag) bool {
+	return f&flag != 0
+}
+
+// String повертає назви встановлених ознак через кому.
+func (f SeriesFlag) String() string {
+	names := make([]string, 0)
+	for i, name := range seriesFlagNames {
+		if f.Has(1 << i) {
+			names = append(names, i18n.T(name))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Point містить спожиту енергію точки обліку або лічильника за місяць.
+type Point struct {
+	Date   time.Time
+	Energy int
+	Zones  []*SeriesZone // енергія по тарифних зонах
+	Flags  SeriesFlag
+}
+
+// SeriesZone містить спожиту енергію тарифної зони за місяць.
+type SeriesZone struct {
+	Zone   int
+	Energy int
+}
+
+// GetPlaceSeries повертає спожиту енергію точки обліку name по місяцях
+// від from до to включно. Енергія всіх лічильників точки обліку
+// додається, тому ряд продовжується після заміни лічильника.
+func GetPlaceSeries(stor Store, name string, from, to time.Time) []*Point {
+	return getSeries(stor, from, to, func(report *Report) bool {
+		return report.Name == name
+	})
+}
+
+// GetMeterSeries повертає спожиту енергію лічильника з номером serial
+// по місяцях від from до to включно.
+func GetMeterSeries(stor Store, serial string, from, to time.Time) []*Point {
+	return getSeries(stor, from, to, func(report *Report) bool {
+		return report.Serial == serial
+	})
+}
+
+// LastMonths повертає перший і останній місяць з n останніх закритих
+// місяців.
+func LastMonths(stor Store, n int) (from, to time.Time) {
+	to = stor.GetNextDate().AddDate(0, -1, 0)
+	return to.AddDate(0, 1-n, 0), to
+}
+
+// getSeries збирає ряд з рядків звітів, які вибирає функція match.
+func getSeries(stor Store, from, to time.Time,
+	match func(report *Report) bool) []*Point {
+
+	series := make([]*Point, 0)
+	var prevMeters string
+	for date := from; !date.After(to); date = date.AddDate(0, 1, 0) {
+		point := &Point{Date: date, Zones: []*SeriesZone{}}
+
+		// рядки звіту одного лічильника йдуть підряд
+		serials := make([]string, 0)
+		for _, report := range stor.GetReports(date) {
+			if !match(report) {
+				continue
+			}
+			last := len(serials) - 1
+			if last < 0 || serials[last] != report.Serial {
+				serials = append(serials, report.Serial)
+			}
+			if report.CurKwh < report.PreKwh {
+				point.Flags |= FlagRollover
+			}
+			if report.Annotation != "" {
+				point.Flags |= FlagAnnotated
+			}
+			point.addZone(report.Zone, report.Energy)
+			point.Energy += report.Energy
+		}
+
+		meters := strings.Join(serials, "\n")
+		switch {
+		case len(serials) == 0:
+			point.Flags |= FlagMissing
+		case point.Energy == 0:
+			point.Flags |= FlagZero
+		}
+		if len(serials) > 0 {
+			if prevMeters != "" && meters != prevMeters {
+				point.Flags |= FlagReplaced
+			}
+			prevMeters = meters
+		}
+		series = append(series, point)
+	}
+	return series
+}
+
+// addZone додає енергію до тарифної зони. Зони впорядковані за номером.
+func (point *Point) addZone(number, energy int) {
+	for i, zone := range point.Zones {
+		switch {
+		case zone.Zone == number:
+			zone.Energy += energy
+			return
+		case zone.Zone > number:
+			point.Zones = append(point.Zones[:i+1], point.Zones[i:]...)
+			point.Zones[i] = &SeriesZone{number, energy}
+			return
+		}
+	}
+	point.Zones = append(point.Zones, &SeriesZone{number, energy})
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetPlaceSeries(t *testing.T) {
+	stor := createDatabase(t)
+
+	// В АВМ в листопаді встановлено новий лічильник, в січні старий
+	// видалено. За жовтень тільки перші показники.
+	series := GetPlaceSeries(stor, "АВМ", MakeDate(2021, 10),
+		MakeDate(2022, 2))
+	want := []*Point{
+		{MakeDate(2021, 10), 0, []*SeriesZone{}, FlagMissing},
+		{MakeDate(2021, 11), 1160, []*SeriesZone{{1, 1160}}, 0},
+		{MakeDate(2021, 12), 2080, []*SeriesZone{{1, 2080}},
+			FlagReplaced},
+		{MakeDate(2022, 1), 1160, []*SeriesZone{{1, 1160}},
+			FlagReplaced},
+		{MakeDate(2022, 2), 1040, []*SeriesZone{{1, 1040}}, 0},
+	}
+	diff := cmp.Diff(want, series)
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Тарифні зони і перехід через нуль
+	from, to := LastMonths(stor, 2)
+	series = GetPlaceSeries(stor, "Контора", from, to)
+	want = []*Point{
+		{MakeDate(2022, 1), 1575, []*SeriesZone{{1, 775}, {2, 800}}, 0},
+		{MakeDate(2022, 2), 795, []*SeriesZone{{1, 395}, {2, 400}}, 0},
+	}
+	diff = cmp.Diff(want, series)
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	series = GetMeterSeries(stor, "344848", from, to)
+	want = []*Point{
+		{MakeDate(2022, 1), 7440, []*SeriesZone{{1, 7440}}, 0},
+		{MakeDate(2022, 2), 6280, []*SeriesZone{{1, 6280}},
+			FlagRollover},
+	}
+	diff = cmp.Diff(want, series)
+	if diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSeriesFlagString(t *testing.T) {
+	flags := FlagReplaced | FlagRollover
+	want := "заміна лічильника, перехід через нуль"
+	if flags.String() != want {
+		t.Errorf("want %q, got %q", want, flags.String())
+	}
+	if !flags.Has(FlagRollover) || flags.Has(FlagMissing) {
+		t.Errorf("Has() error: %b", flags)
+	}
+}
@@ -3,25 +3,36 @@ package storage
 import (
 	"database/sql"
 	_ "embed"
-	"errors"
 	"fmt"
 	"math"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	sqlite3 "github.com/mattn/go-sqlite3"
+
+	"github.com/kraserh/energozvit/internal/i18n"
 )
 
 // Версія бази даних яку підтримує ця програма.
-const DBVERSION = 1
+const DBVERSION = 4
 
 //go:embed schema.sql
 var schema string
 
+// Час очікування зайнятої бази даних, мілісекунди.
+const BusyTimeout = 5000
+
 type Storage struct {
 	*sql.DB
-	filepath string
+	filepath   string
+	readOnly   bool
+	lock       *dbLock // блокування бази даних для запису
+	site       int64   // поточна організація
+	backupDir  string  // каталог автоматичних резервних копій
+	backupKeep int     // кількість автоматичних резервних копій
 }
 
 // Create створює нову базу даних.
@@ -30,7 +41,7 @@ func Create(filepath string, firstDate time.Time) error {
 	var err error
 	_, err = os.Stat(filepath)
 	if err == nil {
-		return errors.New("база даних вже існує")
+		return i18n.NewError("база даних вже існує")
 	}
 
 	// Створення бази даних
@@ -45,10 +56,11 @@ func Create(filepath string, firstDate time.Time) error {
 		return (err)
 	}
 
-	// Початкова дата
+	// Організація з початковою датою
+	firstDate = MakeDate(firstDate.Year(), int(firstDate.Month()))
 	date := dateToString(firstDate)
-	stmtService := "INSERT OR REPLACE INTO service VALUES (?, ?)"
-	_, err = db.Exec(stmtService, "next_date", date)
+	stmtAddSite := "INSERT INTO sites (name, next_date) VALUES (?, ?)"
+	_, err = db.Exec(stmtAddSite, DefaultSiteName, date)
 	if err != nil {
 		return (err)
 	}
@@ -61,7 +73,9 @@ func Create(filepath string, firstDate time.Time) error {
 	return nil
 }
 
-// Open відкриває базу даних.
+// Open відкриває базу даних для запису. База даних блокується до
+// виклику Close, якщо її вже відкрито для запису, повертається
+// LockError. Тоді базу даних можна відкрити функцією OpenReadOnly.
 func Open(filepath string) (*Storage, error) {
 	// Перевірка існування файла бази даних
 	var err error
@@ -70,34 +84,101 @@ func Open(filepath string) (*Storage, error) {
 		return nil, err
 	}
 
-	// Відкриття бази даних
-	stor := new(Storage)
-	stor.DB, err = sql.Open("sqlite3", filepath)
+	lock, err := lockDatabase(filepath)
 	if err != nil {
 		return nil, err
 	}
-	stor.filepath = filepath
+	stor, err := open(filepath, false)
+	if err != nil {
+		lock.release()
+		return nil, err
+	}
+	stor.lock = lock
+	return stor, nil
+}
 
-	// Set foreign keys
-	_, err = stor.Exec("PRAGMA foreign_keys = ON")
+// OpenReadOnly відкриває базу даних тільки для читання, без блокування.
+// Читати можна, поки інша програма записує. База даних попередньої
+// версії не оновлюється, тому не відкривається.
+func OpenReadOnly(filepath string) (*Storage, error) {
+	_, err := os.Stat(filepath)
 	if err != nil {
 		return nil, err
 	}
+	return open(filepath, true)
+}
+
+// open відкриває базу даних. База даних для запису переводиться в
+// режим журналу WAL і оновлюється до поточної версії.
+func open(filepath string, readOnly bool) (*Storage, error) {
+	var err error
+	stor := new(Storage)
+	stor.DB, err = sql.Open("sqlite3", openURI(filepath, readOnly))
+	if err != nil {
+		return nil, err
+	}
+	stor.filepath = filepath
+	stor.readOnly = readOnly
+	stor.SetAutoBackup(defaultBackupDir(filepath), BackupKeep)
 
 	// Формат дати в базі даних
 	sqlite3.SQLiteTimestampFormats = []string{DateLayout}
 
-	// Перевірка версії
+	// Оновлення і перевірка версії
+	if !readOnly {
+		err = stor.migrate()
+		if err != nil {
+			stor.DB.Close()
+			return nil, err
+		}
+	}
 	if stor.GetVersion() != DBVERSION {
-		err := errors.New("не підтримувана версія бази даних")
+		stor.DB.Close()
+		err := i18n.NewError("не підтримувана версія бази даних")
 		return nil, err
 	}
+
+	// Перша організація стає поточною
+	sites := stor.GetSites()
+	if len(sites) == 0 {
+		stor.DB.Close()
+		return nil, ErrMissingSite
+	}
+	stor.site = sites[0].id
 	return stor, nil
 }
 
-// Close закриває базу даних.
+// openURI повертає URI бази даних з параметрами зʼєднання. Кожне
+// зʼєднання перевіряє зовнішні ключі і чекає BusyTimeout, поки база
+// даних зайнята іншим зʼєднанням. В режимі WAL читання не блокує запис
+// і навпаки, але WAL працює тільки коли всі програми на одному
+// компʼютері.
+func openURI(path string, readOnly bool) string {
+	params := url.Values{}
+	params.Set("_busy_timeout", strconv.Itoa(BusyTimeout))
+	params.Set("_foreign_keys", "1")
+	if readOnly {
+		params.Set("mode", "ro")
+	} else {
+		params.Set("_journal_mode", "WAL")
+	}
+	u := url.URL{
+		Scheme:   "file",
+		Opaque:   url.PathEscape(path),
+		RawQuery: params.Encode(),
+	}
+	return u.String()
+}
+
+// Close закриває базу даних і знімає блокування.
 func (stor *Storage) Close() {
 	stor.DB.Close()
+	stor.lock.release()
+}
+
+// ReadOnly перевіряє чи база даних відкрита тільки для читання.
+func (stor *Storage) ReadOnly() bool {
+	return stor.readOnly
 }
 
 // GetFilepath повертає шлях до бази даних.
@@ -116,6 +197,96 @@ func (stor *Storage) GetVersion() int {
 	return version
 }
 
+//--------------------------- SITE FUNCTIONS ---------------------------
+
+// Назва організації, яка створюється разом з базою даних.
+const DefaultSiteName = "Основна"
+
+var ErrMissingSite = i18n.NewError("організацію не знайдено")
+
+type Site struct {
+	id       int64
+	Name     string
+	ClosedAt time.Time // час закриття останнього місяця або нульовий
+}
+
+// GetSites повертає всі організації.
+func (stor *Storage) GetSites() []*Site {
+	querySites := `
+	SELECT site_id, name, ifnull(closed_at, '')
+	  FROM sites
+	 ORDER BY site_id
+	`
+	rows, err := stor.Query(querySites)
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+	sites := make([]*Site, 0)
+	for rows.Next() {
+		site := new(Site)
+		var closedAt string
+		err := rows.Scan(&site.id, &site.Name, &closedAt)
+		if err != nil {
+			panic(err)
+		}
+		if closedAt != "" {
+			site.ClosedAt, err = time.Parse(time.RFC3339, closedAt)
+			if err != nil {
+				panic(err)
+			}
+		}
+		sites = append(sites, site)
+	}
+	if err := rows.Err(); err != nil {
+		panic(err)
+	}
+	return sites
+}
+
+// GetSite повертає поточну організацію.
+func (stor *Storage) GetSite() *Site {
+	for _, site := range stor.GetSites() {
+		if site.id == stor.site {
+			return site
+		}
+	}
+	panic(ErrMissingSite)
+}
+
+// SetSite робить організацію поточною. Лічильники, звіти і дати
+// відносяться до поточної організації.
+func (stor *Storage) SetSite(site *Site) error {
+	if site == nil {
+		return ErrMissingSite
+	}
+	for _, s := range stor.GetSites() {
+		if s.id == site.id {
+			stor.site = site.id
+			return nil
+		}
+	}
+	return ErrMissingSite
+}
+
+// AddSite додає організацію з датою першого звіту firstDate.
+func (stor *Storage) AddSite(site *Site, firstDate time.Time) error {
+	stmtAddSite := `
+	INSERT INTO sites (name, next_date)
+	VALUES (?, ?)
+	`
+	date := MakeDate(firstDate.Year(), int(firstDate.Month()))
+	result, err := stor.Exec(stmtAddSite, site.Name, date)
+	if err != nil {
+		return err
+	}
+	site.id, err = result.LastInsertId()
+	if err != nil {
+		panic(err)
+	}
+	return nil
+}
+
 //-------------------------- METER FUNCTIONS ---------------------------
 
 type Meter struct {
@@ -143,10 +314,10 @@ func (stor *Storage) GetActiveMeters() []*Meter {
 	       digits,
 	       ratio
 	  FROM meters JOIN places USING(place_id)
-	 WHERE active = true
+	 WHERE active = true AND site_id = ?
 	 ORDER BY name, meter_id
 	`
-	rows, err := stor.Query(queryActiveMeters)
+	rows, err := stor.Query(queryActiveMeters, stor.site)
 	if err != nil {
 		panic(err)
 	}
@@ -177,29 +348,108 @@ func (stor *Storage) AddMeter(meter *Meter, kwh []int) error {
 		panic(err)
 	}
 
-	// Додати точку обліку, якщо такої нема
-	err = addPlaceIfNotExists(tx, meter)
+	// Додати точку обліку, лічильник і показники
+	err = addMeterWithKwh(tx, stor.site, meter, kwh)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// Додати лічильник
-	err = addMeter(tx, meter)
+	// Кінець транзакції
+	err = tx.Commit()
 	if err != nil {
 		tx.Rollback()
-		return err
+		panic(err)
 	}
+	return nil
+}
 
-	// Додати початкові показники
-	err = addFirstKwh(tx, meter, kwh)
+// MeterError описує лічильник, який не вдалось додати.
+type MeterError struct {
+	Index int // номер лічильника в списку, з нуля
+	Meter *Meter
+	Err   error
+}
+
+func (e *MeterError) Error() string {
+	return i18n.Sprintf("лічильник %d (%s, %s): %s", e.Index+1,
+		e.Meter.Name, e.Meter.Serial, e.Err)
+}
+
+func (e *MeterError) Unwrap() error {
+	return e.Err
+}
+
+// MetersError містить помилки всіх лічильників, які не вдалось додати
+// функцією AddMeters.
+type MetersError []*MeterError
+
+func (e MetersError) Error() string {
+	return i18n.Sprintf("не вдалось додати лічильників: %d", len(e))
+}
+
+// AddMeters додає лічильники з початковими показниками kwh в одній
+// транзакції, так само як AddMeter. Якщо хоч один лічильник не
+// додано, то не додається жоден і повертається MetersError з помилками
+// всіх лічильників. З dryRun лічильники тільки перевіряються.
+func (stor *Storage) AddMeters(meters []*Meter, kwh [][]int, dryRun bool) error {
+	if len(meters) != len(kwh) {
+		return i18n.NewError("кількість лічильників і показників різна")
+	}
+
+	// Початок транзакції
+	tx, err := stor.Begin()
 	if err != nil {
-		tx.Rollback()
-		return err
+		panic(err)
 	}
 
-	// Кінець транзакції
-	err = tx.Commit()
+	errs := addEachMeter(tx, meters, func(i int) error {
+		return addMeterWithKwh(tx, stor.site, meters[i], kwh[i])
+	})
+	return endAddMeters(tx, meters, errs, dryRun)
+}
+
+// addEachMeter додає лічильники функцією add, кожен в своїй точці
+// збереження, щоб помилка одного не впливала на перевірку наступних.
+// Повертає помилки всіх лічильників.
+func addEachMeter(tx *sql.Tx, meters []*Meter, add func(i int) error) MetersError {
+	var errs MetersError
+	for i, meter := range meters {
+		_, err := tx.Exec("SAVEPOINT add_meter")
+		if err != nil {
+			panic(err)
+		}
+		err = add(i)
+		if err != nil {
+			meter.id = 0
+			errs = append(errs, &MeterError{i, meter, err})
+			_, err = tx.Exec("ROLLBACK TO add_meter")
+			if err != nil {
+				panic(err)
+			}
+		}
+		_, err = tx.Exec("RELEASE add_meter")
+		if err != nil {
+			panic(err)
+		}
+	}
+	return errs
+}
+
+// endAddMeters завершує транзакцію додавання лічильників. Зміни
+// відміняються, якщо є помилки або це перевірка dryRun.
+func endAddMeters(tx *sql.Tx, meters []*Meter, errs MetersError, dryRun bool) error {
+	if len(errs) > 0 || dryRun {
+		tx.Rollback()
+		for _, meter := range meters {
+			meter.id = 0
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		return nil
+	}
+	err := tx.Commit()
 	if err != nil {
 		tx.Rollback()
 		panic(err)
@@ -207,17 +457,32 @@ func (stor *Storage) AddMeter(meter *Meter, kwh []int) error {
 	return nil
 }
 
-// addPlaceIfNotExists додає точку обліку, якщо таке імʼя відсутнє.
-func addPlaceIfNotExists(tx *sql.Tx, meter *Meter) error {
+// addMeterWithKwh додає точку обліку, якщо такої нема, лічильник і
+// його початкові показники.
+func addMeterWithKwh(tx *sql.Tx, site int64, meter *Meter, kwh []int) error {
+	err := addPlaceIfNotExists(tx, site, meter)
+	if err != nil {
+		return err
+	}
+	err = addMeter(tx, site, meter)
+	if err != nil {
+		return err
+	}
+	return addFirstKwh(tx, site, meter, kwh)
+}
+
+// addPlaceIfNotExists додає точку обліку, якщо таке імʼя відсутнє в
+// організації.
+func addPlaceIfNotExists(tx *sql.Tx, site int64, meter *Meter) error {
 	// Перевірка існування точки обліку
 	queryPlaceExists := `
 	SELECT EXISTS (
 	       SELECT place_id
 	         FROM places
-	        WHERE name = ?)
+	        WHERE name = ? AND site_id = ?)
 	`
 	var placeExists bool
-	row := tx.QueryRow(queryPlaceExists, meter.Name)
+	row := tx.QueryRow(queryPlaceExists, meter.Name, site)
 	err := row.Scan(&placeExists)
 	if err != nil {
 		panic(err)
@@ -229,18 +494,19 @@ func addPlaceIfNotExists(tx *sql.Tx, meter *Meter) error {
 	// Додавання точки обліку
 	stmtAddPlace := `
 	INSERT INTO places (
+		site_id,
 		substation,
 		eic,
 		name)
-	VALUES (nullif(?, 0), nullif(?, ''), ?)
+	VALUES (?, nullif(?, 0), nullif(?, ''), ?)
 	`
-	_, err = tx.Exec(stmtAddPlace, meter.Substation, meter.Eic,
+	_, err = tx.Exec(stmtAddPlace, site, meter.Substation, meter.Eic,
 		meter.Name)
 	return err
 }
 
 // addMeter додає лічильник.
-func addMeter(tx *sql.Tx, meter *Meter) error {
+func addMeter(tx *sql.Tx, site int64, meter *Meter) error {
 	stmtAddMeter := `
 	INSERT INTO meters (
 	       place_id,
@@ -250,11 +516,13 @@ func addMeter(tx *sql.Tx, meter *Meter) error {
 	       serial,
 	       digits,
 	       ratio)
-	VALUES ((SELECT place_id FROM places WHERE name = ?),
+	VALUES ((SELECT place_id
+	          FROM places
+	         WHERE name = ? AND site_id = ?),
 	       true,
 	       nullif(?, ''), nullif(?, 0), ?, ?, ?)
 	`
-	result, err := tx.Exec(stmtAddMeter, meter.Name,
+	result, err := tx.Exec(stmtAddMeter, meter.Name, site,
 		meter.Model, meter.Year, meter.Serial,
 		meter.Digits, meter.Ratio)
 	if err != nil {
@@ -268,12 +536,12 @@ func addMeter(tx *sql.Tx, meter *Meter) error {
 }
 
 // addFirstKwh додає початкові показники лічильника.
-func addFirstKwh(tx *sql.Tx, meter *Meter, kwh []int) error {
+func addFirstKwh(tx *sql.Tx, site int64, meter *Meter, kwh []int) error {
 	stmtAddKwh := `
 	INSERT OR REPLACE INTO readings (rdate, meter_id, zone, kwh)
-	VALUES (date((SELECT value
-                        FROM service
-                       WHERE skey == 'next_date'),
+	VALUES (date((SELECT next_date
+                        FROM sites
+                       WHERE site_id == ?),
 		'start of month', '-1 month'), ?, ?, ?)
 	`
 	stmt, err := tx.Prepare(stmtAddKwh)
@@ -281,10 +549,10 @@ func addFirstKwh(tx *sql.Tx, meter *Meter, kwh []int) error {
 		panic(err)
 	}
 	if len(kwh) == 0 {
-		return errors.New("Не вказано початкові показники")
+		return i18n.NewError("Не вказано початкові показники")
 	}
 	for i, v := range kwh {
-		_, err = stmt.Exec(meter.id, i+1, v)
+		_, err = stmt.Exec(site, meter.id, i+1, v)
 		if err != nil {
 			return err
 		}
@@ -295,10 +563,10 @@ func addFirstKwh(tx *sql.Tx, meter *Meter, kwh []int) error {
 // UpdateMeter оновлює лічильник і точку обліку.
 func (stor *Storage) UpdateMeter(em *Meter) error {
 	//TODO
-	return errors.New("Функціонал поки не реалізований")
+	return errNotImplemented
 }
 
-var ErrMissingMeter = errors.New("missing meter")
+var ErrMissingMeter = i18n.NewError("лічильник не знайдено")
 
 // RemoveMeter видаляє лічильник.
 func (stor *Storage) RemoveMeter(meter *Meter) error {
@@ -349,10 +617,10 @@ func (stor *Storage) GetReports(date time.Time) []*Report {
 	       energy,
 	       ifnull(annotation, '')
 	  FROM reports
-	 WHERE rdate = ?
+	 WHERE rdate = ? AND site_id = ?
 	 ORDER BY name, meter_id, zone
 	`
-	rows, err := stor.Query(queryReports, date)
+	rows, err := stor.Query(queryReports, date, stor.site)
 	if err != nil {
 		panic(err)
 	}
@@ -381,9 +649,10 @@ func (stor *Storage) GetNextReports() []*Report {
 	       ifnull(energy, 0),
 	       ifnull(annotation, '')
 	  FROM next_reports
+	 WHERE site_id = ?
 	 ORDER BY name, meter_id, zone
 	`
-	rows, err := stor.Query(queryNextReports)
+	rows, err := stor.Query(queryNextReports, stor.site)
 	if err != nil {
 		panic(err)
 	}
@@ -391,6 +660,22 @@ func (stor *Storage) GetNextReports() []*Report {
 	return scanToReports(rows)
 }
 
+// GetMissingReadings повертає кількість не введених показників
+// наступного звіту.
+func (stor *Storage) GetMissingReadings() int {
+	queryMissing := `
+	SELECT count(*)
+	  FROM next_reports
+	 WHERE site_id = ? AND cur_kwh IS NULL
+	`
+	var count int
+	err := stor.QueryRow(queryMissing, stor.site).Scan(&count)
+	if err != nil {
+		panic(err)
+	}
+	return count
+}
+
 // scanToReports сканує рядки бази даних в масив Report.
 func scanToReports(rows *sql.Rows) []*Report {
 	reports := make([]*Report, 0)
@@ -424,38 +709,75 @@ func (report *Report) Calculate() {
 	report.Energy = report.Diff * report.Ratio
 }
 
-// SaveReports зберігає звіт до бази даних.
+// SaveReports зберігає звіт до бази даних. Перед закриттям місяця
+// створюється резервна копія (див. SetAutoBackup). Якщо введено не всі
+// показники, місяць не закривається і копія не створюється.
 func (stor *Storage) SaveReports(reports []*Report) error {
+	err := stor.SaveDrafts(reports)
+	if err != nil {
+		return err
+	}
+	if stor.GetMissingReadings() == 0 {
+		err = stor.autoBackup()
+		if err != nil {
+			return err
+		}
+	}
+	return stor.gotoNextDate()
+}
+
+// SaveDrafts зберігає введені показники без переходу до наступної дати.
+// Збережені показники повертає GetNextReports, тож їх можна перевірити
+// і доповнити перед закриттям місяця функцією SaveReports. Показники
+// зберігаються в одній транзакції: якщо хоч один не підходить, не
+// зберігається жоден.
+func (stor *Storage) SaveDrafts(reports []*Report) error {
 	stmtUpdateNextReports := `
 	UPDATE next_reports
 	   SET cur_kwh = ?,
 	       annotation = ?
 	 WHERE meter_id = ? AND zone = ?
 	`
-	stmt, err := stor.Prepare(stmtUpdateNextReports)
+	tx, err := stor.Begin()
 	if err != nil {
 		panic(err)
 	}
+	stmt, err := tx.Prepare(stmtUpdateNextReports)
+	if err != nil {
+		tx.Rollback()
+		panic(err)
+	}
+	defer stmt.Close()
 	for _, report := range reports {
 		report.Calculate()
 		_, err := stmt.Exec(report.CurKwh, report.Annotation,
 			report.id, report.Zone)
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
 	}
-	return stor.gotoNextDate()
+
+	// Кінець транзакції
+	err = tx.Commit()
+	if err != nil {
+		tx.Rollback()
+		panic(err)
+	}
+	return nil
 }
 
 // gotoNextDate підтверджує що всі показники введені і можна переходити
 // до слідуючої дати.
 func (stor *Storage) gotoNextDate() error {
 	stmtgotoNextDate := `
-	UPDATE service
-	   SET value = 1
-	 WHERE skey = 'goto_next_date'
+	UPDATE sites
+	   SET next_date = date(next_date, '+1 month'),
+	       closed_at = ?
+	 WHERE site_id = ?
 	`
-	_, err := stor.Exec(stmtgotoNextDate)
+	closedAt := time.Now().UTC().Format(time.RFC3339)
+	_, err := stor.Exec(stmtgotoNextDate, closedAt, stor.site)
 	return err
 }
 
@@ -465,15 +787,17 @@ func (stor *Storage) GetTotal(from, to time.Time, name ...string) int {
 	queryTotal := `
 	SELECT total(energy)
 	  FROM reports
-	 WHERE (rdate BETWEEN ? AND ?)`
-	var nameStr string
+	 WHERE (rdate BETWEEN ? AND ?) AND site_id = ?`
+	args := []any{from, to, stor.site}
 	if len(name) > 0 {
-		nameStr = fmt.Sprintf(" AND (name IN ('%s'))",
-			strings.Join(name, "', '"))
+		queryTotal += " AND (name IN (?" +
+			strings.Repeat(", ?", len(name)-1) + "))"
+		for _, n := range name {
+			args = append(args, n)
+		}
 	}
 	var total int
-	err := stor.QueryRow(queryTotal+nameStr, from, to).
-		Scan(&total)
+	err := stor.QueryRow(queryTotal, args...).Scan(&total)
 	if err != nil {
 		panic(err)
 	}
@@ -493,10 +817,10 @@ func (stor *Storage) GetNextTotal(reports []*Report) int {
 	queryTotal := `
 	SELECT total(energy)
 	  FROM reports JOIN meters USING (meter_id)
-	 WHERE rdate = (SELECT value FROM service WHERE skey = 'next_date')
-	   AND active = false`
+	 WHERE rdate = (SELECT next_date FROM sites WHERE site_id = ?)
+	   AND active = false AND site_id = ?`
 	var totalForNotActive int
-	err := stor.QueryRow(queryTotal).
+	err := stor.QueryRow(queryTotal, stor.site, stor.site).
 		Scan(&totalForNotActive)
 	if err != nil {
 		panic(err)
@@ -524,10 +848,10 @@ func DateParse(value string) (time.Time, error) {
 // GetNextDate повертає дату наступного звіту.
 func (stor *Storage) GetNextDate() time.Time {
 	queryNextDate := `
-	SELECT value
-	  FROM service
-	 WHERE skey = 'next_date'`
-	row := stor.QueryRow(queryNextDate)
+	SELECT next_date
+	  FROM sites
+	 WHERE site_id = ?`
+	row := stor.QueryRow(queryNextDate, stor.site)
 	var nextDate string
 	err := row.Scan(&nextDate)
 	if err != nil {
@@ -1,8 +1,10 @@
 package storage
 
 import (
+	"context"
 	_ "embed"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,7 +18,7 @@ var data_test string
 
 func createDatabase(t *testing.T) *Storage {
 	tempDir := t.TempDir()
-	dbName := t.Name() + ".sqlite"
+	dbName := strings.ReplaceAll(t.Name(), "/", "_") + ".sqlite"
 	dbPath := path.Join(tempDir, dbName)
 
 	startDate := time.Now()
@@ -315,3 +317,28 @@ func TestQueryLine(t *testing.T) {
 		t.Errorf("QueryLine() mismatch (-want +got):\n%s", diff)
 	}
 }
+
+//---------------------------- Connections -----------------------------
+
+func TestForeignKeys(t *testing.T) {
+	stor := createDatabase(t)
+
+	// кожне зʼєднання пулу перевіряє зовнішні ключі
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		conn, err := stor.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var enabled int
+		err = conn.QueryRowContext(ctx, "PRAGMA foreign_keys").
+			Scan(&enabled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enabled != 1 {
+			t.Errorf("connection %d: foreign keys off", i)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Store описує операції з даними обліку: організації, лічильники,
+// звіти, ліміти, суми спожитої енергії, дати та запити. Його
+// реалізують Storage (база даних SQLite) та Memory (дані в памʼяті,
+// для тестування).
+type Store interface {
+	Close()
+	GetFilepath() string
+	ReadOnly() bool
+
+	// Організації
+	GetSites() []*Site
+	GetSite() *Site
+	SetSite(site *Site) error
+	AddSite(site *Site, firstDate time.Time) error
+
+	// Лічильники
+	GetActiveMeters() []*Meter
+	AddMeter(meter *Meter, kwh []int) error
+	AddMeters(meters []*Meter, kwh [][]int, dryRun bool) error
+	AddHistory(history []*History, dryRun bool) error
+	UpdateMeter(meter *Meter) error
+	RemoveMeter(meter *Meter) error
+
+	// Звіти
+	GetReports(date time.Time) []*Report
+	GetNextReports() []*Report
+	GetMissingReadings() int
+	SaveReports(reports []*Report) error
+	SaveDrafts(reports []*Report) error
+
+	// Ліміти
+	GetBudgets() []*Budget
+	SetBudget(budget *Budget) error
+	RemoveBudget(budget *Budget) error
+
+	// Суми спожитої енергії
+	GetTotal(from, to time.Time, name ...string) int
+	GetNextTotal(reports []*Report) int
+
+	// Дати
+	GetNextDate() time.Time
+
+	// Запити
+	QueryLines(query string, args ...any) ([][]string, error)
+	QueryLine(query string, args ...any) ([]string, error)
+}
+
+var (
+	_ Store = (*Storage)(nil)
+	_ Store = (*Memory)(nil)
+)
+
+// SelectSite робить поточною організацію з назвою name.
+func SelectSite(stor Store, name string) error {
+	for _, site := range stor.GetSites() {
+		if site.Name == name {
+			return stor.SetSite(site)
+		}
+	}
+	return ErrMissingSite
+}
+
+// IsConstraint перевіряє чи err є порушенням обмеження схеми бази
+// даних: неправильні або повторені дані, не введені показники.
+func IsConstraint(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrConstraint
+	}
+	var memErr constraintError
+	return errors.As(err, &memErr)
+}
+
+// ErrQueryNotSupported повертається сховищем, яке не виконує SQL запити.
+var ErrQueryNotSupported = i18n.NewError("запити не підтримуються")
+
+// errNotImplemented повертається функціями, які ще не реалізовані.
+var errNotImplemented = i18n.NewError("Функціонал поки не реалізований")
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// overBudget містить назви точок обліку, які перевищили місячний або
+// річний ліміт. Пуста назва означає всю організацію.
+type overBudget map[string]bool
+
+func newOverBudget(usages []*storage.Usage) overBudget {
+	over := make(overBudget)
+	for _, usage := range usages {
+		if usage.Over() {
+			over[usage.Name] = true
+		}
+	}
+	return over
+}
+
+// highlight виділяє комірку точки обліку name, яка перевищила ліміт.
+func (over overBudget) highlight(cell *tview.TableCell, name string) *tview.TableCell {
+	if over[name] {
+		cell.SetTextColor(tcell.ColorRed)
+	}
+	return cell
+}
+
+// showUsage виводе використання лімітів.
+func (t *Tui) showUsage(usages []*storage.Usage) {
+	if len(usages) == 0 {
+		t.Message(i18n.T("Лімітів нема"))
+		return
+	}
+	lines := make([]string, 0, len(usages))
+	for _, usage := range usages {
+		name := usage.Name
+		if name == "" {
+			name = i18n.T("Всього")
+		}
+		period := i18n.T("місяць")
+		if usage.Yearly {
+			period = i18n.T("рік")
+		}
+		line := i18n.Sprintf("%s, %s: %d з %d (%.0f%%)", name, period,
+			usage.Energy, usage.Budget, usage.Percent)
+		if usage.Over() {
+			line += " " + i18n.T("ПЕРЕВИЩЕНО")
+		}
+		lines = append(lines, line)
+	}
+	t.Message(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// getCompareCell повертає комірку таблиці порівняння спожитої енергії з
+// попереднім місяцем і тим самим місяцем минулого року.
+func (c *contentReport) getCompareCell(row, column int) *tview.TableCell {
+	// header
+	var colName = []string{"Назва", "Всього", "Попер. місяць",
+		"Різниця", "%", "Минулий рік", "Різниця", "%"}
+	row -= 1 // -1 header
+	places := c.comparison.Places
+
+	var place *storage.PlaceComparison
+	switch {
+	case row < 0:
+		return tview.NewTableCell(i18n.T(colName[column]))
+	case row < len(places):
+		place = places[row]
+	case row == len(places):
+		if column == 0 {
+			return tview.NewTableCell("")
+		}
+		return tview.NewTableCell("------").
+			SetAlign(tview.AlignRight)
+	default:
+		place = c.comparison.Total
+	}
+
+	var v string
+	switch column {
+	case 0:
+		return tview.NewTableCell(place.Name)
+	case 1:
+		v = strconv.Itoa(place.Energy)
+	case 2, 3, 4:
+		v = deltaString(place.PrevMonth, column-2)
+	case 5, 6, 7:
+		v = deltaString(place.PrevYear, column-5)
+	}
+	return tview.NewTableCell(v).
+		SetAlign(tview.AlignRight)
+}
+
+// deltaString повертає енергію місяця для порівняння (field 0), різницю
+// (field 1) або різницю у відсотках (field 2).
+func deltaString(delta storage.Delta, field int) string {
+	switch {
+	case delta.Missing:
+		return "-"
+	case field == 0:
+		return strconv.Itoa(delta.Energy)
+	case field == 1:
+		return fmt.Sprintf("%+d", delta.Diff)
+	case !delta.HasPercent():
+		return "-"
+	default:
+		return fmt.Sprintf("%+.1f", delta.Percent)
+	}
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"io"
+	"os"
+
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// export запитує імʼя файла і записує в нього дані функцією write.
+// Формат визначається розширенням файла.
+func (t *Tui) export(path string, write func(io.Writer, exchange.Options) error) {
+	dialog := newDialogExport(path)
+
+	dialog.SetOkFunc(func() {
+		opts := exchange.Options{
+			Format: exchange.FormatByPath(dialog.path),
+			Comma:  exchange.DefaultComma,
+		}
+		err := writeFile(dialog.path, func(w io.Writer) error {
+			return write(w, opts)
+		})
+		t.closeDialog(dialog)
+		if err != nil {
+			t.ErrorShow(err)
+			return
+		}
+		t.Message(i18n.Sprintf("Збережено в файл %s", dialog.path))
+	})
+
+	dialog.SetCancelFunc(func() {
+		t.closeDialog(dialog)
+	})
+
+	t.addAndSwitchToDialog(dialog)
+}
+
+// writeFile створює файл і записує в нього дані функцією write.
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+////////////////////////////////////////////////////////////////////////
+
+type dialogExport struct {
+	form       *tview.Form
+	path       string
+	okFunc     func()
+	cancelFunc func()
+}
+
+func newDialogExport(path string) *dialogExport {
+	dialog := &dialogExport{
+		form: tview.NewForm(),
+		path: path,
+	}
+	dialog.addPathField()
+	dialog.addButtonOk()
+	dialog.addButtonCancel()
+	return dialog
+}
+
+func (d *dialogExport) GetTitle() string {
+	return i18n.T("Експорт в CSV або JSON")
+}
+
+func (d *dialogExport) GetPrimitive() tview.Primitive {
+	return d.form
+}
+
+func (d *dialogExport) GetBox() *tview.Box {
+	return d.form.Box
+}
+
+func (d *dialogExport) SetOkFunc(f func()) {
+	d.okFunc = f
+	d.form.GetButton(0).SetSelectedFunc(f)
+}
+
+func (d *dialogExport) SetCancelFunc(f func()) {
+	d.cancelFunc = f
+	d.form.GetButton(1).SetSelectedFunc(f)
+}
+
+// Поле вводу імені файла
+func (d *dialogExport) addPathField() {
+	pathField := tview.NewInputField()
+	pathField.
+		SetLabel(i18n.T("Файл (.csv, .json)")).
+		SetFieldWidth(inputWidth).
+		SetText(d.path).
+		SetChangedFunc(func(text string) {
+			d.path = text
+		})
+	d.form.AddFormItem(pathField)
+}
+
+// Кнопка ОК
+func (d *dialogExport) addButtonOk() {
+	d.form.AddButton("OK", d.okFunc)
+}
+
+// Кнопка Відміна
+func (d *dialogExport) addButtonCancel() {
+	d.form.AddButton(i18n.T("Відміна"), d.cancelFunc)
+}
@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/iec62056"
+)
+
+// importCSV заносить показники з CSV файла в форму введення показників.
+// Показники не зберігаються, їх потрібно перевірити і зберегти.
+func (c *contentNewReport) importCSV() {
+	dialog := newDialogImport(i18n.T("Імпорт показників з CSV"))
+
+	dialog.SetOkFunc(func() {
+		rows, err := readCSV(dialog.path)
+		if err != nil {
+			c.tui.ErrorShow(err)
+			return
+		}
+		c.tui.closeDialog(dialog)
+		c.applyRows(rows)
+	})
+
+	dialog.SetCancelFunc(func() {
+		c.tui.closeDialog(dialog)
+	})
+
+	c.tui.addAndSwitchToDialog(dialog)
+}
+
+// importReadout заносить в форму введення показників показники
+// лічильника, прочитані через оптичний порт (шлях до послідовного
+// порту) або з записаного файла. Показники не зберігаються.
+func (c *contentNewReport) importReadout() {
+	dialog := newDialogImport(i18n.T("Показники з оптичного порту"))
+
+	dialog.SetOkFunc(func() {
+		readout, err := iec62056.ReadPath(dialog.path)
+		if err != nil {
+			c.tui.ErrorShow(err)
+			return
+		}
+		rows, err := readout.Rows()
+		if err != nil {
+			c.tui.ErrorShow(err)
+			return
+		}
+		c.tui.closeDialog(dialog)
+		c.applyRows(rows)
+	})
+
+	dialog.SetCancelFunc(func() {
+		c.tui.closeDialog(dialog)
+	})
+
+	c.tui.addAndSwitchToDialog(dialog)
+}
+
+// applyRows заносить показники в форму і показує рядки, які не вдалось
+// занести.
+func (c *contentNewReport) applyRows(rows []*exchange.Row) {
+	changed, errs := exchange.Apply(c.data, rows)
+	if len(changed) > 0 {
+		c.modified = true
+		c.checkBudgets()
+	}
+	if len(errs) > 0 {
+		lines := make([]string, 0, len(errs)+1)
+		lines = append(lines, i18n.Sprintf(
+			"Занесено показників: %d. Не занесено рядків: %d",
+			len(changed), len(errs)))
+		for _, err := range errs {
+			lines = append(lines, err.Error())
+		}
+		c.tui.Message(strings.Join(lines, "\n"))
+	}
+}
+
+// importMeters додає лічильники з CSV файла. Спочатку лічильники
+// перевіряються, і тільки якщо помилок нема, додаються після
+// підтвердження.
+func (c *contentMeters) importMeters() {
+	dialog := newDialogImport(i18n.T("Імпорт лічильників з CSV"))
+
+	dialog.SetOkFunc(func() {
+		file, err := os.Open(dialog.path)
+		if err != nil {
+			c.tui.ErrorShow(err)
+			return
+		}
+		rows, err := exchange.ReadMetersCSV(file)
+		file.Close()
+		if err != nil {
+			c.tui.ErrorShow(err)
+			return
+		}
+		c.tui.closeDialog(dialog)
+
+		errs := exchange.ImportMeters(c.tui.stor, rows, true)
+		if len(errs) > 0 {
+			lines := []string{i18n.Sprintf(
+				"Помилок: %d, лічильники не додано", len(errs))}
+			for _, err := range errs {
+				lines = append(lines, err.Error())
+			}
+			c.tui.Message(strings.Join(lines, "\n"))
+			return
+		}
+		message := i18n.Sprintf("Буде додано лічильників: %d", len(rows))
+		c.tui.Confirm(message, func() {
+			errs := exchange.ImportMeters(c.tui.stor, rows, false)
+			if len(errs) > 0 {
+				c.tui.ErrorShow(errs[0])
+			}
+			c.data = c.tui.stor.GetActiveMeters()
+			c.updateMetersOnNewReports()
+		})
+	})
+
+	dialog.SetCancelFunc(func() {
+		c.tui.closeDialog(dialog)
+	})
+
+	c.tui.addAndSwitchToDialog(dialog)
+}
+
+// readCSV читає показники з CSV файла.
+func readCSV(path string) ([]*exchange.Row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return exchange.ReadCSV(file)
+}
+
+////////////////////////////////////////////////////////////////////////
+
+type dialogImport struct {
+	form       *tview.Form
+	title      string
+	path       string
+	okFunc     func()
+	cancelFunc func()
+}
+
+func newDialogImport(title string) *dialogImport {
+	dialog := &dialogImport{
+		form:  tview.NewForm(),
+		title: title,
+	}
+	dialog.addPathField()
+	dialog.addButtonOk()
+	dialog.addButtonCancel()
+	return dialog
+}
+
+func (d *dialogImport) GetTitle() string {
+	return d.title
+}
+
+func (d *dialogImport) GetPrimitive() tview.Primitive {
+	return d.form
+}
+
+func (d *dialogImport) GetBox() *tview.Box {
+	return d.form.Box
+}
+
+func (d *dialogImport) SetOkFunc(f func()) {
+	d.okFunc = f
+	d.form.GetButton(0).SetSelectedFunc(f)
+}
+
+func (d *dialogImport) SetCancelFunc(f func()) {
+	d.cancelFunc = f
+	d.form.GetButton(1).SetSelectedFunc(f)
+}
+
+// Поле вводу імені файла
+func (d *dialogImport) addPathField() {
+	pathField := tview.NewInputField()
+	pathField.
+		SetLabel(i18n.T("Файл")).
+		SetFieldWidth(inputWidth).
+		SetChangedFunc(func(text string) {
+			d.path = text
+		})
+	d.form.AddFormItem(pathField)
+}
+
+// Кнопка ОК
+func (d *dialogImport) addButtonOk() {
+	d.form.AddButton("OK", d.okFunc)
+}
+
+// Кнопка Відміна
+func (d *dialogImport) addButtonCancel() {
+	d.form.AddButton(i18n.T("Відміна"), d.cancelFunc)
+}
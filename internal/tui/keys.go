@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+)
+
+// Дії, яким можна призначити клавіші в файлі налаштувань, і клавіші за
+// замовчуванням.
+var defaultKeys = map[string]rune{
+	// всі панелі
+	"quit":  'q',
+	"sites": 'o',
+	// показники
+	"save":    's',
+	"undo":    'u',
+	"import":  'i',
+	"readout": 'p',
+	"budget":  'l',
+	// лічильники
+	"meter_add":    'n',
+	"meter_remove": 'd',
+	"meter_edit":   'e',
+	"meter_import": 'i',
+	"export":       'x',
+	// звіт і період
+	"prev":       'm',
+	"next":       'M',
+	"prev_year":  'y',
+	"next_year":  'Y',
+	"last":       'z',
+	"compare":    'c',
+	"additional": 'a',
+	"quarter":    'k',
+	"year":       'r',
+}
+
+// Дії на кожній панелі. Клавіші дій однієї панелі і дій всіх панелей не
+// мають збігатися.
+var panelActions = [][]string{
+	{"save", "undo", "import", "readout", "budget"},
+	{"prev", "next", "prev_year", "next_year", "last", "additional",
+		"compare", "budget", "export"},
+	{"meter_add", "meter_remove", "meter_edit", "meter_import", "export"},
+	{"prev", "next", "quarter", "year", "last"},
+}
+
+// keys містить клавіші дій.
+type keys map[string]rune
+
+// newKeys повертає клавіші за замовчуванням, замінені клавішами з
+// налаштувань. Цифри зайняті перемиканням панелей.
+func newKeys(config map[string]string) (keys, error) {
+	k := make(keys)
+	for action, key := range defaultKeys {
+		k[action] = key
+	}
+	for action, key := range config {
+		if _, ok := k[action]; !ok {
+			return nil, i18n.Errorf("невідома дія %s, можливі дії: %s",
+				action, strings.Join(actions(), ", "))
+		}
+		r, _ := utf8.DecodeRuneInString(key)
+		if unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return nil, i18n.Errorf("клавіша дії %s зайнята: %q",
+				action, key)
+		}
+		k[action] = r
+	}
+
+	for _, actions := range panelActions {
+		used := make(map[rune]string)
+		for _, action := range append(actions, "quit", "sites") {
+			if other, ok := used[k[action]]; ok {
+				return nil, i18n.Errorf("дії %s і %s мають однакову "+
+					"клавішу %q", other, action, k[action])
+			}
+			used[k[action]] = action
+		}
+	}
+	return k, nil
+}
+
+// help повертає опис клавіш. Параметри йдуть парами: дії через "/" і
+// опис.
+func (k keys) help(items ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(items); i += 2 {
+		actions := strings.Split(items[i], "/")
+		for j, action := range actions {
+			if j > 0 {
+				b.WriteByte('/')
+			}
+			b.WriteRune(k[action])
+		}
+		fmt.Fprintf(&b, ": %s  ", i18n.T(items[i+1]))
+	}
+	return b.String()
+}
+
+// actions повертає відсортований список дій для довідки.
+func actions() []string {
+	names := make([]string, 0, len(defaultKeys))
+	for action := range defaultKeys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,13 +1,15 @@
 package tui
 
 import (
-	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
@@ -32,7 +34,7 @@ func (c *contentMeters) GetName() string {
 }
 
 func (c *contentMeters) GetMenuName() string {
-	return "Лічильники"
+	return i18n.T("Лічильники")
 }
 
 func (c *contentMeters) GetTitle() string {
@@ -51,7 +53,7 @@ func (c *contentMeters) GetCell(row, column int) *tview.TableCell {
 
 	if row < 0 {
 		// header row
-		v = colName[column]
+		v = i18n.T(colName[column])
 
 	} else {
 		// data rows
@@ -86,7 +88,12 @@ func (c *contentMeters) GetColumnCount() int {
 }
 
 func (c *contentMeters) GetKeybindingString() string {
-	return "n: Додати  d: Видалити  e: Редагувати"
+	if c.tui.stor.ReadOnly() {
+		return c.tui.keys.help("export", "Експорт")
+	}
+	return c.tui.keys.help("meter_add", "Додати", "meter_remove",
+		"Видалити", "meter_edit", "Редагувати", "meter_import", "Імпорт",
+		"export", "Експорт")
 }
 
 func (c *contentMeters) NeedToSave() bool {
@@ -110,12 +117,24 @@ func (c *contentMeters) getSelection() (*storage.Meter, bool) {
 func (c *contentMeters) setKeybinding() {
 	c.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
-		case 'n':
-			c.create()
-		case 'd':
-			c.delete()
-		case 'e':
-			c.edit()
+		case c.tui.keys["meter_add"]:
+			if c.tui.writable() {
+				c.create()
+			}
+		case c.tui.keys["meter_remove"]:
+			if c.tui.writable() {
+				c.delete()
+			}
+		case c.tui.keys["meter_edit"]:
+			if c.tui.writable() {
+				c.edit()
+			}
+		case c.tui.keys["export"]:
+			c.export()
+		case c.tui.keys["meter_import"]:
+			if c.tui.writable() {
+				c.importMeters()
+			}
 		}
 		return event
 	})
@@ -151,7 +170,7 @@ func (c *contentMeters) delete() {
 	if !ok {
 		return
 	}
-	message := fmt.Sprintf("Буде видалено лічильник %s № %s",
+	message := i18n.Sprintf("Буде видалено лічильник %s № %s",
 		meter.Name, meter.Serial)
 	c.tui.Confirm(message,
 		func() {
@@ -189,6 +208,13 @@ func (c *contentMeters) edit() {
 	c.tui.addAndSwitchToDialog(dialog)
 }
 
+func (c *contentMeters) export() {
+	c.tui.export("meters.csv",
+		func(w io.Writer, opts exchange.Options) error {
+			return exchange.ExportMeters(w, c.tui.stor, opts)
+		})
+}
+
 func (c *contentMeters) updateMetersOnNewReports() {
 	content, ok := c.tui.searchContent("newReports")
 	if ok {
@@ -248,9 +274,9 @@ func newDialogEditMeter(meter *storage.Meter) *dialogMeter {
 func (d *dialogMeter) GetTitle() string {
 	var title string
 	if d.isCreate {
-		title = "Додавання лічильника"
+		title = i18n.T("Додавання лічильника")
 	} else {
-		title = "Редагування " + d.meter.Name
+		title = i18n.Sprintf("Редагування %s", d.meter.Name)
 	}
 	return title
 }
@@ -279,7 +305,7 @@ func (d *dialogMeter) SetCancelFunc(f func()) {
 func (d *dialogMeter) addSubstationField() {
 	substationField := tview.NewInputField()
 	substationField.
-		SetLabel("Номер підстанції").
+		SetLabel(i18n.T("Номер підстанції")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(strconv.Itoa(d.meter.Substation)).
 		SetAcceptanceFunc(isNumber).
@@ -297,7 +323,7 @@ func (d *dialogMeter) addSubstationField() {
 func (d *dialogMeter) addEicField() {
 	eicCodeField := tview.NewInputField()
 	eicCodeField.
-		SetLabel("EIC Код").
+		SetLabel(i18n.T("EIC Код")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(d.meter.Eic).
 		SetAcceptanceFunc(func(text string, _ rune) bool {
@@ -316,7 +342,7 @@ func (d *dialogMeter) addEicField() {
 func (d *dialogMeter) addNameField() {
 	nameField := tview.NewInputField()
 	nameField.
-		SetLabel("Назва точки обліку").
+		SetLabel(i18n.T("Назва точки обліку")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(d.meter.Name).
 		SetAcceptanceFunc(func(text string, _ rune) bool {
@@ -335,7 +361,7 @@ func (d *dialogMeter) addNameField() {
 func (d *dialogMeter) addModelField() {
 	modelField := tview.NewInputField()
 	modelField.
-		SetLabel("Модель лічильника").
+		SetLabel(i18n.T("Модель лічильника")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(d.meter.Model).
 		SetAcceptanceFunc(func(text string, _ rune) bool {
@@ -354,7 +380,7 @@ func (d *dialogMeter) addModelField() {
 func (d *dialogMeter) addYearField() {
 	yearField := tview.NewInputField()
 	yearField.
-		SetLabel("Рік лічильника").
+		SetLabel(i18n.T("Рік лічильника")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(strconv.Itoa(d.meter.Year)).
 		SetAcceptanceFunc(isNumber).
@@ -372,7 +398,7 @@ func (d *dialogMeter) addYearField() {
 func (d *dialogMeter) addSerialField() {
 	serialNumField := tview.NewInputField()
 	serialNumField.
-		SetLabel("Серійний номер").
+		SetLabel(i18n.T("Серійний номер")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(d.meter.Serial).
 		SetAcceptanceFunc(func(text string, _ rune) bool {
@@ -391,7 +417,7 @@ func (d *dialogMeter) addSerialField() {
 func (d *dialogMeter) addDigitsField() {
 	digitsMaxField := tview.NewInputField()
 	digitsMaxField.
-		SetLabel("Значучі розряди").
+		SetLabel(i18n.T("Значучі розряди")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(strconv.Itoa(d.meter.Digits)).
 		SetAcceptanceFunc(isNumber).
@@ -409,7 +435,7 @@ func (d *dialogMeter) addDigitsField() {
 func (d *dialogMeter) addRatioField() {
 	ratioField := tview.NewInputField()
 	ratioField.
-		SetLabel("Коефіцієнт тр-ції").
+		SetLabel(i18n.T("Коефіцієнт тр-ції")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(strconv.Itoa(d.meter.Ratio)).
 		SetAcceptanceFunc(isNumber).
@@ -427,7 +453,7 @@ func (d *dialogMeter) addRatioField() {
 func (d *dialogMeter) addFirstKwhField() {
 	firstKwhField := tview.NewInputField()
 	firstKwhField.
-		SetLabel("Показники, через пробіл").
+		SetLabel(i18n.T("Показники, через пробіл")).
 		SetFieldWidth(inputWidth).
 		SetAcceptanceFunc(func(text string, _ rune) bool {
 			return len(text) <= inputWidth
@@ -458,5 +484,5 @@ func (d *dialogMeter) addButtonOk() {
 
 // Кнопка Відміна
 func (d *dialogMeter) addButtonCancel() {
-	d.form.AddButton("Відміна", d.cancelFunc)
+	d.form.AddButton(i18n.T("Відміна"), d.cancelFunc)
 }
@@ -7,12 +7,14 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/kraserh/energozvit/internal/i18n"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
 type contentNewReport struct {
 	tui      *Tui
 	data     []*storage.Report
+	over     overBudget
 	table    *tview.Table
 	modified bool
 }
@@ -21,6 +23,7 @@ func newContentNewReport(t *Tui) *contentNewReport {
 	content := new(contentNewReport)
 	content.tui = t
 	content.data = t.stor.GetNextReports()
+	content.checkBudgets()
 	content.table = tview.NewTable().
 		SetSelectable(true, false)
 	content.setKeybinding()
@@ -35,14 +38,14 @@ func (c *contentNewReport) GetName() string {
 }
 
 func (c *contentNewReport) GetMenuName() string {
-	return "Нові дані"
+	return i18n.T("Нові дані")
 }
 
 func (c *contentNewReport) GetTitle() string {
 	date := c.tui.stor.GetNextDate()
 	title := fmt.Sprintf("%d-%02d", date.Year(), date.Month())
 	if c.modified {
-		title = title + " [:red](НЕ ЗБЕРЕЖЕНО)"
+		title = title + " [:red](" + i18n.T("НЕ ЗБЕРЕЖЕНО") + ")"
 	}
 	return title
 }
@@ -61,7 +64,7 @@ func (c *contentNewReport) GetCell(row, column int) *tview.TableCell {
 
 	if row < 0 {
 		// header row
-		v = colName[column]
+		v = i18n.T(colName[column])
 		cell = tview.NewTableCell(v)
 
 	} else if row < len(c.data) {
@@ -69,7 +72,7 @@ func (c *contentNewReport) GetCell(row, column int) *tview.TableCell {
 		switch column {
 		case 0:
 			v = c.data[row].Name
-			cell = tview.NewTableCell(v)
+			cell = c.over.highlight(tview.NewTableCell(v), v)
 		case 1:
 			v = c.data[row].Serial
 			cell = tview.NewTableCell(v)
@@ -101,7 +104,7 @@ func (c *contentNewReport) GetCell(row, column int) *tview.TableCell {
 				SetAlign(tview.AlignRight)
 		case column == 5 && row == len(c.data)+1:
 			v = strconv.Itoa(c.tui.stor.GetNextTotal(c.data))
-			cell = tview.NewTableCell(v).
+			cell = c.over.highlight(tview.NewTableCell(v), "").
 				SetAlign(tview.AlignRight)
 		default:
 			cell = tview.NewTableCell("")
@@ -119,7 +122,12 @@ func (c *contentNewReport) GetColumnCount() int {
 }
 
 func (c *contentNewReport) GetKeybindingString() string {
-	return "s: Зберегти,  u: Відміна"
+	if c.tui.stor.ReadOnly() {
+		return c.tui.keys.help("budget", "Ліміти")
+	}
+	return c.tui.keys.help("save", "Зберегти", "undo", "Відміна",
+		"import", "Імпорт CSV", "readout", "Оптичний порт",
+		"budget", "Ліміти")
 }
 
 func (c *contentNewReport) NeedToSave() bool {
@@ -129,12 +137,13 @@ func (c *contentNewReport) NeedToSave() bool {
 func (c *contentNewReport) RereadTable() {
 	c.data = c.tui.stor.GetNextReports()
 	c.modified = false
+	c.checkBudgets()
 	c.tui.updateTable(c)
 }
 
 func (c *contentNewReport) selectedRow(row int) {
 	row-- // -1 header
-	if row >= len(c.data) || row < 0 {
+	if row >= len(c.data) || row < 0 || !c.tui.writable() {
 		return
 	}
 
@@ -144,6 +153,7 @@ func (c *contentNewReport) selectedRow(row int) {
 
 	dialog.SetOkFunc(func() {
 		c.modified = true
+		c.checkBudgets()
 		c.tui.closeDialog(dialog)
 		if row+1 < len(c.data) {
 			c.table.Select(row+2, 0) // +1 header, +1 next
@@ -163,10 +173,22 @@ func (c *contentNewReport) selectedRow(row int) {
 func (c *contentNewReport) setKeybinding() {
 	c.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
-		case 's':
-			c.save()
-		case 'u':
+		case c.tui.keys["save"]:
+			if c.tui.writable() {
+				c.save()
+			}
+		case c.tui.keys["undo"]:
 			c.undo()
+		case c.tui.keys["import"]:
+			if c.tui.writable() {
+				c.importCSV()
+			}
+		case c.tui.keys["readout"]:
+			if c.tui.writable() {
+				c.importReadout()
+			}
+		case c.tui.keys["budget"]:
+			c.tui.showUsage(c.usage())
 		}
 		return event
 	})
@@ -183,6 +205,17 @@ func (c *contentNewReport) save() {
 	}
 }
 
+// usage рахує використання лімітів введеними показниками.
+func (c *contentNewReport) usage() []*storage.Usage {
+	return storage.GetUsage(c.tui.stor, c.tui.stor.GetNextDate(), c.data)
+}
+
+// checkBudgets шукає точки обліку, які перевищили ліміт з введеними
+// показниками.
+func (c *contentNewReport) checkBudgets() {
+	c.over = newOverBudget(c.usage())
+}
+
 func (c *contentNewReport) undo() {
 	c.modified = false
 	c.RereadTable()
@@ -242,7 +275,7 @@ func (d *dialogNewReport) SetCancelFunc(f func()) {
 func (d *dialogNewReport) addCurKwhField() {
 	curKwhField := tview.NewInputField()
 	curKwhField.
-		SetLabel("Показник лічильника").
+		SetLabel(i18n.T("Показник лічильника")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(strconv.Itoa(d.report.CurKwh)).
 		// Дозволено ввод лише чисел
@@ -266,7 +299,7 @@ func (d *dialogNewReport) addCurKwhField() {
 func (d *dialogNewReport) addAnnotationField() {
 	annotationField := tview.NewInputField()
 	annotationField.
-		SetLabel("Примітка").
+		SetLabel(i18n.T("Примітка")).
 		SetFieldWidth(inputWidth).
 		SetPlaceholder(d.report.Annotation).
 		// Обмеження довжини примітки
@@ -290,5 +323,5 @@ func (d *dialogNewReport) addButtonOk() {
 
 // Кнопка Відміна
 func (d *dialogNewReport) addButtonCancel() {
-	d.form.AddButton("Відміна", d.cancelFunc)
+	d.form.AddButton(i18n.T("Відміна"), d.cancelFunc)
 }
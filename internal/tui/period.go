@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/report"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Римські номери кварталів
+var quarterNames = [5]string{"", "I", "II", "III", "IV"}
+
+type contentPeriod struct {
+	tui    *Tui
+	date   time.Time
+	year   bool // звіт за рік, інакше за квартал
+	period *storage.Period
+	rows   []*periodRow
+	table  *tview.Table
+}
+
+// Рядок таблиці: тарифна зона точки обліку або підсумок
+type periodRow struct {
+	name   string
+	zone   string
+	energy []int
+	total  int
+}
+
+func newContentPeriod(t *Tui) *contentPeriod {
+	content := new(contentPeriod)
+	content.tui = t
+	content.date = t.stor.GetNextDate().AddDate(0, -1, 0)
+	content.read()
+	content.table = tview.NewTable().
+		SetSelectable(false, false)
+	content.setKeybinding()
+	return content
+}
+
+func (c *contentPeriod) GetName() string {
+	return "period"
+}
+
+func (c *contentPeriod) GetMenuName() string {
+	return i18n.T("Період")
+}
+
+func (c *contentPeriod) GetTitle() string {
+	if c.year {
+		return i18n.Sprintf("%d рік", c.date.Year())
+	}
+	return i18n.Sprintf("%d, %s квартал", c.date.Year(),
+		quarterNames[storage.Quarter(c.date)])
+}
+
+func (c *contentPeriod) GetTable() *tview.Table {
+	return c.table
+}
+
+func (c *contentPeriod) GetCell(row, column int) *tview.TableCell {
+	months := len(c.period.Months)
+	row -= 1 // -1 header
+
+	// header row
+	if row < 0 {
+		switch {
+		case column == 0:
+			return tview.NewTableCell(i18n.T("Назва"))
+		case column == 1:
+			return tview.NewTableCell(i18n.T("Зона"))
+		case column < months+2:
+			month := c.period.Months[column-2].Month()
+			return tview.NewTableCell(i18n.T(report.MonthName(month)))
+		default:
+			return tview.NewTableCell(i18n.T("Всього"))
+		}
+	}
+
+	// data and total rows
+	line := c.rows[row]
+	var v string
+	switch {
+	case column == 0:
+		return tview.NewTableCell(line.name)
+	case column == 1:
+		return tview.NewTableCell(line.zone)
+	case column < months+2:
+		v = strconv.Itoa(line.energy[column-2])
+	default:
+		v = strconv.Itoa(line.total)
+	}
+	return tview.NewTableCell(v).
+		SetAlign(tview.AlignRight)
+}
+
+func (c *contentPeriod) GetRowCount() int {
+	return len(c.rows) + 1 // +1 header row
+}
+
+func (c *contentPeriod) GetColumnCount() int {
+	return len(c.period.Months) + 3
+}
+
+func (c *contentPeriod) GetKeybindingString() string {
+	return c.tui.keys.help("prev/next", "Період", "quarter", "Квартал",
+		"year", "Рік", "last", "Останній звіт")
+}
+
+func (c *contentPeriod) NeedToSave() bool {
+	return false
+}
+
+func (c *contentPeriod) RereadTable() {
+	c.lastDate()
+}
+
+func (c *contentPeriod) setKeybinding() {
+	c.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case c.tui.keys["prev"]:
+			c.move(-1)
+		case c.tui.keys["next"]:
+			c.move(1)
+		case c.tui.keys["quarter"]:
+			c.year = false
+			c.update()
+		case c.tui.keys["year"]:
+			c.year = true
+			c.update()
+		case c.tui.keys["last"]:
+			c.lastDate()
+		}
+		return event
+	})
+}
+
+// move переходить на вказану кількість кварталів або років.
+func (c *contentPeriod) move(n int) {
+	if c.year {
+		c.date = c.date.AddDate(n, 0, 0)
+	} else {
+		c.date = c.date.AddDate(0, 3*n, 0)
+	}
+	c.update()
+}
+
+func (c *contentPeriod) lastDate() {
+	c.date = c.tui.stor.GetNextDate().AddDate(0, -1, 0)
+	c.update()
+}
+
+func (c *contentPeriod) update() {
+	c.read()
+	c.tui.updateTable(c)
+}
+
+// read читає звіт за період і формує рядки таблиці: по рядку на кожну
+// тарифну зону і підсумок.
+func (c *contentPeriod) read() {
+	if c.year {
+		c.period = storage.GetYear(c.tui.stor, c.date)
+	} else {
+		c.period = storage.GetQuarter(c.tui.stor, c.date)
+	}
+	c.rows = make([]*periodRow, 0)
+	for _, place := range c.period.Places {
+		name := place.Name
+		for _, zone := range place.Zones {
+			c.rows = append(c.rows, &periodRow{
+				name:   name,
+				zone:   strconv.Itoa(zone.Zone),
+				energy: zone.Energy,
+				total:  zone.Total,
+			})
+			name = ""
+		}
+	}
+	c.rows = append(c.rows, &periodRow{
+		name:   i18n.T("Всього"),
+		energy: c.period.Energy,
+		total:  c.period.Total,
+	})
+}
@@ -2,22 +2,29 @@ package tui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/kraserh/energozvit/internal/exchange"
+	"github.com/kraserh/energozvit/internal/i18n"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
 type contentReport struct {
-	tui   *Tui
-	date  time.Time
-	data  []*storage.Report
-	table *tview.Table
+	tui        *Tui
+	date       time.Time
+	data       []*storage.Report
+	over       overBudget
+	compare    bool // режим порівняння з минулими місяцями
+	comparison *storage.Comparison
+	table      *tview.Table
 }
 
 func newContentReport(t *Tui) *contentReport {
@@ -25,6 +32,7 @@ func newContentReport(t *Tui) *contentReport {
 	content.tui = t
 	content.date = t.stor.GetNextDate().AddDate(0, -1, 0)
 	content.data = t.stor.GetReports(content.date)
+	content.over = newOverBudget(content.usage())
 	content.table = tview.NewTable().
 		SetSelectable(false, false)
 	content.setKeybinding()
@@ -36,11 +44,15 @@ func (c *contentReport) GetName() string {
 }
 
 func (c *contentReport) GetMenuName() string {
-	return "Звіт"
+	return i18n.T("Звіт")
 }
 
 func (c *contentReport) GetTitle() string {
-	return fmt.Sprintf("%d-%02d", c.date.Year(), c.date.Month())
+	title := fmt.Sprintf("%d-%02d", c.date.Year(), c.date.Month())
+	if c.compare {
+		title += ", " + i18n.T("порівняння")
+	}
+	return title
 }
 
 func (c *contentReport) GetTable() *tview.Table {
@@ -48,6 +60,10 @@ func (c *contentReport) GetTable() *tview.Table {
 }
 
 func (c *contentReport) GetCell(row, column int) *tview.TableCell {
+	if c.compare {
+		return c.getCompareCell(row, column)
+	}
+
 	// header
 	var colName = []string{"Назва", "Номер", "Зона", "Теперешні",
 		"Попередні", "Різниця", "Всього", "Примітка"}
@@ -57,7 +73,7 @@ func (c *contentReport) GetCell(row, column int) *tview.TableCell {
 
 	if row < 0 {
 		// header row
-		v = colName[column]
+		v = i18n.T(colName[column])
 		cell = tview.NewTableCell(v)
 
 	} else if row < len(c.data) {
@@ -65,7 +81,7 @@ func (c *contentReport) GetCell(row, column int) *tview.TableCell {
 		switch column {
 		case 0:
 			v = c.data[row].Name
-			cell = tview.NewTableCell(v)
+			cell = c.over.highlight(tview.NewTableCell(v), v)
 		case 1:
 			v = c.data[row].Serial
 			cell = tview.NewTableCell(v)
@@ -101,7 +117,7 @@ func (c *contentReport) GetCell(row, column int) *tview.TableCell {
 				SetAlign(tview.AlignRight)
 		case column == 6 && row == len(c.data)+1:
 			v = strconv.Itoa(c.tui.stor.GetTotal(c.date, c.date))
-			cell = tview.NewTableCell(v).
+			cell = c.over.highlight(tview.NewTableCell(v), "").
 				SetAlign(tview.AlignRight)
 		default:
 			cell = tview.NewTableCell("")
@@ -111,15 +127,21 @@ func (c *contentReport) GetCell(row, column int) *tview.TableCell {
 }
 
 func (c *contentReport) GetRowCount() int {
+	if c.compare {
+		// +1 header row and +2 total row
+		return len(c.comparison.Places) + 3
+	}
 	return len(c.data) + 3 // +1 header row and +2 total row
 }
 
 func (c *contentReport) GetColumnCount() int {
-	return 8
+	return 8 // в режимі порівняння теж 8
 }
 
 func (c *contentReport) GetKeybindingString() string {
-	return "m/M: Місяць,  y/Y: Рік,  z: Останній звіт  a: Додатково"
+	return c.tui.keys.help("prev/next", "Місяць", "prev_year/next_year",
+		"Рік", "last", "Останній звіт", "additional", "Додатково",
+		"compare", "Порівняння", "budget", "Ліміти", "export", "Експорт")
 }
 
 func (c *contentReport) NeedToSave() bool {
@@ -133,18 +155,25 @@ func (c *contentReport) RereadTable() {
 func (c *contentReport) setKeybinding() {
 	c.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
-		case 'm':
+		case c.tui.keys["prev"]:
 			c.prevMonth()
-		case 'M':
+		case c.tui.keys["next"]:
 			c.nextMonth()
-		case 'y':
+		case c.tui.keys["prev_year"]:
 			c.prevYear()
-		case 'Y':
+		case c.tui.keys["next_year"]:
 			c.nextYear()
-		case 'z':
+		case c.tui.keys["last"]:
 			c.lastDate()
-		case 'a':
+		case c.tui.keys["compare"]:
+			c.compare = !c.compare
+			c.read()
+		case c.tui.keys["budget"]:
+			c.tui.showUsage(c.usage())
+		case c.tui.keys["additional"]:
 			c.additional()
+		case c.tui.keys["export"]:
+			c.export()
 		}
 		return event
 	})
@@ -152,34 +181,53 @@ func (c *contentReport) setKeybinding() {
 
 func (c *contentReport) nextMonth() {
 	c.date = c.date.AddDate(0, 1, 0)
-	c.data = c.tui.stor.GetReports(c.date)
-	c.tui.updateTable(c)
+	c.read()
 }
 
 func (c *contentReport) prevMonth() {
 	c.date = c.date.AddDate(0, -1, 0)
-	c.data = c.tui.stor.GetReports(c.date)
-	c.tui.updateTable(c)
+	c.read()
 }
 
 func (c *contentReport) nextYear() {
 	c.date = c.date.AddDate(1, 0, 0)
-	c.data = c.tui.stor.GetReports(c.date)
-	c.tui.updateTable(c)
+	c.read()
 }
 
 func (c *contentReport) prevYear() {
 	c.date = c.date.AddDate(-1, 0, 0)
-	c.data = c.tui.stor.GetReports(c.date)
-	c.tui.updateTable(c)
+	c.read()
 }
 
 func (c *contentReport) lastDate() {
 	c.date = c.tui.stor.GetNextDate().AddDate(0, -1, 0)
+	c.read()
+}
+
+// read читає звіт за поточну дату і оновлює таблицю.
+func (c *contentReport) read() {
 	c.data = c.tui.stor.GetReports(c.date)
+	c.over = newOverBudget(c.usage())
+	if c.compare {
+		c.comparison = storage.GetComparison(c.tui.stor, c.date)
+	}
 	c.tui.updateTable(c)
 }
 
+// usage рахує використання лімітів за місяць звіту.
+func (c *contentReport) usage() []*storage.Usage {
+	return storage.GetUsage(c.tui.stor, c.date, c.data)
+}
+
+func (c *contentReport) export() {
+	path := fmt.Sprintf("zvit-%d-%02d.csv", c.date.Year(),
+		c.date.Month())
+	c.tui.export(path, func(w io.Writer, opts exchange.Options) error {
+		return exchange.ExportReports(w, c.tui.stor, c.date, c.date,
+			opts)
+	})
+}
+
 func (c *contentReport) additional() {
 	dialog := newDialogAdditional(c.tui, c.date)
 	c.tui.addAndSwitchToDialog(dialog)
@@ -196,7 +244,9 @@ func newDialogAdditional(t *Tui, date time.Time) *dialogAdditional {
 		list: tview.NewList(),
 	}
 
-	files, err := os.ReadDir(".")
+	// додаткові команди знаходяться в каталозі шаблонів
+	dir := t.config.Templates
+	files, err := os.ReadDir(dir)
 	if err != nil {
 		panic(err)
 	}
@@ -204,7 +254,7 @@ func newDialogAdditional(t *Tui, date time.Time) *dialogAdditional {
 	var cmdFiles []string
 	for _, file := range files {
 
-		fileInfo, err := os.Stat(file.Name())
+		fileInfo, err := os.Stat(filepath.Join(dir, file.Name()))
 		if err != nil {
 			continue
 		}
@@ -224,7 +274,7 @@ func newDialogAdditional(t *Tui, date time.Time) *dialogAdditional {
 		list.SetSelectedFunc(func(_ int, cmdName, _ string, _ rune) {
 			yymm := fmt.Sprintf("%d-%02d",
 				date.Year(), int(date.Month()))
-			t.execCommand("./"+cmdName, yymm)
+			t.execCommand(dir, cmdName, yymm, t.stor.GetSite().Name)
 			t.closeDialog(dialog)
 
 		})
@@ -238,7 +288,7 @@ func newDialogAdditional(t *Tui, date time.Time) *dialogAdditional {
 }
 
 func (d *dialogAdditional) GetTitle() string {
-	return "Додаткові команди"
+	return i18n.T("Додаткові команди")
 }
 
 func (d *dialogAdditional) GetPrimitive() tview.Primitive {
@@ -255,18 +305,24 @@ func (d *dialogAdditional) SetOkFunc(f func()) {
 func (d *dialogAdditional) SetCancelFunc(f func()) {
 }
 
-// Виконує команду операційної системи
+// Виконує команду операційної системи з каталога dir
 // Перший аргумент завжди зберігай імʼя бази даних
-func (t *Tui) execCommand(cmdName string, args ...string) {
+func (t *Tui) execCommand(dir, cmdName string, args ...string) {
 	pwd, err := os.Getwd()
 	if err != nil {
 		panic(err)
 	}
 
-	argsMod := []string{t.stor.GetFilepath()}
+	// команда виконується в своєму каталозі, тому шлях до БД повний
+	pathDB, err := filepath.Abs(t.stor.GetFilepath())
+	if err != nil {
+		panic(err)
+	}
+	argsMod := []string{pathDB}
 	argsMod = append(argsMod, args...)
 	t.app.Suspend(func() {
-		cmd := exec.Command(cmdName, argsMod...)
+		cmd := exec.Command(filepath.Join(dir, cmdName), argsMod...)
+		cmd.Dir = dir
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
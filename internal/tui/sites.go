@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"github.com/rivo/tview"
+
+	"github.com/kraserh/energozvit/internal/i18n"
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// sites відкриває діалог вибору організації.
+func (t *Tui) sites() {
+	if t.needToSave() {
+		return
+	}
+	dialog := newDialogSites(t)
+	t.addAndSwitchToDialog(dialog)
+}
+
+// switchSite робить поточною вказану організацію і оновлює всі таблиці.
+func (t *Tui) switchSite(site *storage.Site) {
+	err := t.stor.SetSite(site)
+	if err != nil {
+		t.ErrorShow(err)
+		return
+	}
+	for _, content := range t.contents {
+		content.RereadTable()
+	}
+	name, _ := t.pages.GetFrontPage()
+	t.updateTabBar()
+	t.tabBar.Highlight(name)
+}
+
+////////////////////////////////////////////////////////////////////////
+
+type dialogSites struct {
+	list *tview.List
+}
+
+func newDialogSites(t *Tui) *dialogSites {
+	dialog := &dialogSites{
+		list: tview.NewList(),
+	}
+
+	sites := t.stor.GetSites()
+	current := t.stor.GetSite()
+	list := dialog.list
+	for i, site := range sites {
+		var shotcut rune
+		if i < 9 {
+			shotcut = rune(i + int('1'))
+		}
+		list.AddItem(site.Name, "", shotcut, nil)
+		if site.Name == current.Name {
+			list.SetCurrentItem(i)
+		}
+	}
+
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) {
+		t.closeDialog(dialog)
+		t.switchSite(sites[index])
+	})
+
+	list.SetDoneFunc(func() {
+		t.closeDialog(dialog)
+	})
+
+	return dialog
+}
+
+func (d *dialogSites) GetTitle() string {
+	return i18n.T("Організація")
+}
+
+func (d *dialogSites) GetPrimitive() tview.Primitive {
+	return d.list
+}
+
+func (d *dialogSites) GetBox() *tview.Box {
+	return d.list.Box
+}
+
+func (d *dialogSites) SetOkFunc(f func()) {
+}
+
+func (d *dialogSites) SetCancelFunc(f func()) {
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/kraserh/energozvit/internal/config"
+	"github.com/kraserh/energozvit/internal/i18n"
 	"github.com/kraserh/energozvit/internal/storage"
 )
 
@@ -40,11 +42,19 @@ type Tui struct {
 	pages    *tview.Pages
 	tabBar   *tview.TextView
 	contents []Content
-	stor     *storage.Storage
+	stor     storage.Store
+	config   *config.Config
+	keys     keys
 }
 
-// Start запускає інтерфейс.
-func Start(stor *storage.Storage) {
+// Start запускає інтерфейс. Реквізити організації, каталог додаткових
+// команд і клавіші беруться з налаштувань cfg.
+func Start(stor storage.Store, cfg *config.Config) {
+	keys, err := newKeys(cfg.Keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// створюєм структуру інтерфейсу.
 	t := &Tui{
 		app:   tview.NewApplication(),
@@ -53,7 +63,9 @@ func Start(stor *storage.Storage) {
 			SetDynamicColors(true).
 			SetRegions(true).
 			SetWrap(false),
-		stor: stor,
+		stor:   stor,
+		config: cfg,
+		keys:   keys,
 	}
 
 	// додаєм сторінки і перемикаємо на першу сторінку.
@@ -61,6 +73,8 @@ func Start(stor *storage.Storage) {
 	t.addContent(content)
 	t.addContent(newContentReport(t))
 	t.addContent(newContentMeters(t))
+	t.addContent(newContentPeriod(t))
+	t.updateTabBar()
 	t.switchToContent(content)
 
 	// створюєм верхній рядок табів і показ сторінки.
@@ -77,14 +91,8 @@ func Start(stor *storage.Storage) {
 
 // Stop зупиняє інтерфейс.
 func (t *Tui) Stop() {
-	for _, content := range t.contents {
-		if content.NeedToSave() {
-			message := fmt.Sprintf(
-				"Не збережені дані в панелі \"%s\"",
-				content.GetMenuName())
-			t.Message(message)
-			return
-		}
+	if t.needToSave() {
+		return
 	}
 	t.app.Stop()
 }
@@ -103,9 +111,26 @@ func (t *Tui) addContent(content Content) {
 	// додаєм сторінку.
 	t.pages.AddPage(content.GetName(), flex, true, true)
 	t.contents = append(t.contents, content)
-	num := len(t.contents)
-	fmt.Fprintf(t.tabBar, `  ["%s"]%d %s[""] `, content.GetName(),
-		num, content.GetMenuName())
+}
+
+// updateTabBar виводе рядок табів і назву поточної організації.
+func (t *Tui) updateTabBar() {
+	t.tabBar.Clear()
+	for i, content := range t.contents {
+		fmt.Fprintf(t.tabBar, `  ["%s"]%d %s[""] `,
+			content.GetName(), i+1, content.GetMenuName())
+	}
+	if t.config.Organization.Name != "" {
+		fmt.Fprintf(t.tabBar, "   [::b]%s[::-]",
+			tview.Escape(t.config.Organization.Name))
+	}
+	if len(t.stor.GetSites()) > 1 {
+		fmt.Fprintf(t.tabBar, "   [::b]%s[::-]",
+			tview.Escape(t.stor.GetSite().Name))
+	}
+	if t.stor.ReadOnly() {
+		fmt.Fprintf(t.tabBar, "   [red]%s[-]", i18n.T("Тільки читання"))
+	}
 }
 
 // initTable ініциалізує таблицю.
@@ -149,8 +174,10 @@ func (t *Tui) setKeybinding(content Content) *tview.TextView {
 	tableKeybinding := table.GetInputCapture()
 	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Rune() {
-		case 'q':
+		case t.keys["quit"]:
 			t.Stop()
+		case t.keys["sites"]:
+			t.sites()
 		}
 
 		num, err := strconv.Atoi(string(event.Rune()))
@@ -163,12 +190,38 @@ func (t *Tui) setKeybinding(content Content) *tview.TextView {
 	})
 
 	// рядок підсказка
-	generalKeybinding := "  q: Вихід"
+	generalKeybinding := t.keys.help("sites", "Організація", "quit",
+		"Вихід")
 	keybindingString := tview.NewTextView().
 		SetText(content.GetKeybindingString() + generalKeybinding)
 	return keybindingString
 }
 
+// writable перевіряє чи можна змінювати дані. Виводе повідомлення,
+// якщо база даних відкрита тільки для читання.
+func (t *Tui) writable() bool {
+	if t.stor.ReadOnly() {
+		t.Message(i18n.T("База даних відкрита тільки для читання"))
+		return false
+	}
+	return true
+}
+
+// needToSave перевіряє чи є не збережені дані. Виводе повідомлення
+// якщо є.
+func (t *Tui) needToSave() bool {
+	for _, content := range t.contents {
+		if content.NeedToSave() {
+			message := i18n.Sprintf(
+				"Не збережені дані в панелі \"%s\"",
+				content.GetMenuName())
+			t.Message(message)
+			return true
+		}
+	}
+	return false
+}
+
 // switchToContent перемикає сторінки з контентом.
 func (t *Tui) switchToContent(content Content) {
 	t.pages.SwitchToPage(content.GetName())
@@ -237,7 +290,7 @@ func (t *Tui) Message(text string) {
 func (t *Tui) Confirm(text string, okFunc func()) {
 	modal := tview.NewModal().
 		SetText(text).
-		AddButtons([]string{"Відміна"}).
+		AddButtons([]string{i18n.T("Відміна")}).
 		AddButtons([]string{"OK"}).
 		SetDoneFunc(func(index int, label string) {
 			t.pages.RemovePage("message")
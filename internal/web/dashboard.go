@@ -0,0 +1,213 @@
+package web
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+// Кількість закритих місяців на панелі
+const dashboardMonths = 12
+
+// Кількість останніх аномалій на панелі
+const maxAnomalies = 10
+
+// Споживання, яке в стільки разів більше середнього за інші місяці,
+// вважається аномалією.
+const spikeRatio = 2
+
+//go:embed templates
+var templates embed.FS
+
+var dashboardTmpl = template.Must(template.ParseFS(templates,
+	"templates/dashboard.html"))
+
+// Дані панелі
+type dashboard struct {
+	Site      string
+	Sites     []string // всі організації, якщо їх більше однієї
+	From      string
+	To        string
+	Months    []monthTotal
+	Total     int
+	Chart     template.HTML
+	Places    []*placeTrend
+	Progress  progress
+	Anomalies []*anomaly
+}
+
+// Спожита енергія за місяць
+type monthTotal struct {
+	Date  string
+	Total int
+}
+
+// Споживання точки обліку по місяцях
+type placeTrend struct {
+	Name  string
+	Last  int // енергія за останній місяць
+	Total int
+	Chart template.HTML
+}
+
+// Введення показників наступного звіту
+type progress struct {
+	Date    string
+	Entered int
+	Count   int
+	Percent int
+}
+
+// Особливість даних точки обліку за місяць
+type anomaly struct {
+	Date time.Time
+	Name string
+	Text string
+}
+
+// Month повертає місяць аномалії в форматі YYYY-MM.
+func (a *anomaly) Month() string {
+	return monthString(a.Date)
+}
+
+// Dashboard повертає обробник сторінки з історією споживання поточної
+// організації: суми по місяцях, графіки точок обліку, введення
+// показників наступного звіту і останні аномалії. Сторінка тільки
+// читає дані.
+func Dashboard(stor storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "метод не підтримується",
+				http.StatusMethodNotAllowed)
+			return
+		}
+		var buf bytes.Buffer
+		err := dashboardTmpl.Execute(&buf, newDashboard(stor))
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		buf.WriteTo(w)
+	})
+}
+
+// newDashboard збирає дані панелі за останні закриті місяці.
+func newDashboard(stor storage.Store) *dashboard {
+	from, to := storage.LastMonths(stor, dashboardMonths)
+	period := storage.GetPeriod(stor, from, to)
+	d := &dashboard{
+		Site:      stor.GetSite().Name,
+		From:      monthString(from),
+		To:        monthString(to),
+		Total:     period.Total,
+		Places:    make([]*placeTrend, 0),
+		Anomalies: make([]*anomaly, 0),
+		Progress:  newProgress(stor),
+	}
+	if sites := stor.GetSites(); len(sites) > 1 {
+		for _, site := range sites {
+			d.Sites = append(d.Sites, site.Name)
+		}
+	}
+
+	labels := make([]string, 0)
+	for i, date := range period.Months {
+		d.Months = append(d.Months, monthTotal{monthString(date),
+			period.Energy[i]})
+		labels = append(labels, fmt.Sprintf("%02d.%02d",
+			date.Month(), date.Year()%100))
+	}
+	d.Chart = barChart(period.Energy, labels)
+
+	for _, place := range period.Places {
+		series := storage.GetPlaceSeries(stor, place.Name, from, to)
+		values := make([]int, len(series))
+		missing := make([]bool, len(series))
+		for i, point := range series {
+			values[i] = point.Energy
+			missing[i] = point.Flags.Has(storage.FlagMissing)
+		}
+		d.Places = append(d.Places, &placeTrend{
+			Name:  place.Name,
+			Last:  values[len(values)-1],
+			Total: place.Total,
+			Chart: lineChart(values, missing),
+		})
+		d.Anomalies = append(d.Anomalies,
+			findAnomalies(place.Name, series)...)
+	}
+
+	sort.SliceStable(d.Anomalies, func(i, j int) bool {
+		return d.Anomalies[i].Date.After(d.Anomalies[j].Date)
+	})
+	if len(d.Anomalies) > maxAnomalies {
+		d.Anomalies = d.Anomalies[:maxAnomalies]
+	}
+	return d
+}
+
+// newProgress рахує введені показники наступного звіту.
+func newProgress(stor storage.Store) progress {
+	count := len(stor.GetNextReports())
+	p := progress{
+		Date:    monthString(stor.GetNextDate()),
+		Entered: count - stor.GetMissingReadings(),
+		Count:   count,
+	}
+	if p.Count > 0 {
+		p.Percent = p.Entered * 100 / p.Count
+	}
+	return p
+}
+
+// findAnomalies шукає в ряді точки обліку місяці з особливостями даних
+// і різким зростанням споживання. Відсутній звіт є аномалією тільки між
+// місяцями зі звітами.
+func findAnomalies(name string, series []*storage.Point) []*anomaly {
+	first, last := -1, -1
+	sum, count := 0, 0
+	for i, point := range series {
+		if point.Flags.Has(storage.FlagMissing) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		sum += point.Energy
+		count++
+	}
+
+	anomalies := make([]*anomaly, 0)
+	for i := first; first >= 0 && i <= last; i++ {
+		point := series[i]
+		texts := make([]string, 0)
+		if point.Flags != 0 {
+			texts = append(texts, point.Flags.String())
+		}
+		if count > 1 && !point.Flags.Has(storage.FlagMissing) {
+			average := (sum - point.Energy) / (count - 1)
+			if average > 0 && point.Energy > spikeRatio*average {
+				texts = append(texts, "різке зростання")
+			}
+		}
+		if len(texts) > 0 {
+			anomalies = append(anomalies, &anomaly{point.Date, name,
+				strings.Join(texts, ", ")})
+		}
+	}
+	return anomalies
+}
+
+// monthString повертає місяць в форматі YYYY-MM.
+func monthString(date time.Time) string {
+	return fmt.Sprintf("%d-%02d", date.Year(), date.Month())
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/kraserh/energozvit/internal/storage"
+)
+
+func TestCharts(t *testing.T) {
+	bars := string(barChart([]int{10, 0, 20}, []string{"01", "02", "<3>"}))
+	if n := strings.Count(bars, "<rect"); n != 3 {
+		t.Errorf("bars: want 3 rect, got %d", n)
+	}
+	if !strings.Contains(bars, `height="200.0"`) ||
+		!strings.Contains(bars, "&lt;3&gt;") {
+		t.Errorf("bars: %s", bars)
+	}
+
+	line := string(lineChart([]int{1, 2, 0, 3, 4},
+		[]bool{false, false, true, false, false}))
+	if n := strings.Count(line, "<polyline"); n != 2 {
+		t.Errorf("line: want 2 polyline, got %d", n)
+	}
+	if n := strings.Count(line, "<circle"); n != 1 {
+		t.Errorf("line: want 1 circle, got %d", n)
+	}
+	if empty := string(lineChart(nil, nil)); strings.Contains(empty,
+		"<polyline") {
+		t.Errorf("empty line: %s", empty)
+	}
+}
+
+func TestFindAnomalies(t *testing.T) {
+	point := func(month, energy int, flags storage.SeriesFlag) *storage.Point {
+		return &storage.Point{Date: storage.MakeDate(2022, month),
+			Energy: energy, Flags: flags}
+	}
+	series := []*storage.Point{
+		point(1, 0, storage.FlagMissing),
+		point(2, 100, 0),
+		point(3, 0, storage.FlagMissing),
+		point(4, 120, storage.FlagReplaced),
+		point(5, 500, 0),
+		point(6, 0, storage.FlagZero),
+		point(7, 0, storage.FlagMissing),
+	}
+	got := make([]string, 0)
+	for _, a := range findAnomalies("Склад", series) {
+		got = append(got, a.Month()+" "+a.Name+": "+a.Text)
+	}
+	want := []string{
+		"2022-03 Склад: немає звіту",
+		"2022-04 Склад: заміна лічильника",
+		"2022-05 Склад: різке зростання",
+		"2022-06 Склад: нульове споживання",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	stor := storage.NewMemory(storage.MakeDate(2022, 1))
+	err := stor.AddMeter(&storage.Meter{Name: "Госпдвір",
+		Serial: "344848", Digits: 4, Ratio: 1}, []int{100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stor.AddMeter(&storage.Meter{Name: "Контора",
+		Serial: "001930", Digits: 5, Ratio: 1}, []int{200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, kwh := range []int{10, 10, 90} {
+		reports := stor.GetNextReports()
+		for _, r := range reports {
+			r.CurKwh = r.PreKwh + kwh
+		}
+		if err := stor.SaveReports(reports); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports := stor.GetNextReports()
+	reports[0].CurKwh += 5
+	if err := stor.SaveDrafts(reports[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	Dashboard(stor).ServeHTTP(rec, httptest.NewRequest("GET", "/dashboard",
+		nil))
+	body := rec.Body.String()
+	if rec.Code != 200 {
+		t.Fatalf("status %d", rec.Code)
+	}
+	for _, s := range []string{
+		"2021-04 — 2022-03",
+		"<svg class=\"bars\"",
+		"<td>Контора</td><td><svg class=\"line\"",
+		"1 з 2 (50%)",
+		"<td>2022-03</td><td>Госпдвір</td><td>різке зростання</td>",
+		"<th>Всього</th><th class=\"num\">220</th>",
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("%q not found", s)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	Dashboard(stor).ServeHTTP(rec, httptest.NewRequest("POST", "/dashboard",
+		nil))
+	if rec.Code != 405 {
+		t.Errorf("POST status %d", rec.Code)
+	}
+}
@@ -0,0 +1,250 @@
+// Форма введення показників, як на вкладці «Нові дані». Спожита енергія
+// рахується сервером (POST /next/preview), показники зберігаються тим
+// самим шляхом, що і в програмі.
+'use strict';
+
+const api = '/api/v1';
+
+let site = '';   // організація, пуста для організації за замовчуванням
+let rows = [];   // рядки форми
+let timer = null;
+
+const $ = (id) => document.getElementById(id);
+
+// request виконує запит API і повертає код відповіді та дані.
+async function request(method, path, body) {
+  let url = api + path;
+  if (site !== '') {
+    url += '?site=' + encodeURIComponent(site);
+  }
+  const options = { method: method, headers: {} };
+  if (method !== 'GET') {
+    // сервер приймає зміни тільки в JSON (захист від CSRF)
+    options.headers['Content-Type'] = 'application/json';
+  }
+  if (body !== undefined) {
+    options.body = JSON.stringify(body);
+  }
+  const resp = await fetch(url, options);
+  return { status: resp.status, data: await resp.json() };
+}
+
+// showMessage виводить повідомлення або помилку.
+function showMessage(text, error) {
+  const message = $('message');
+  message.textContent = text;
+  message.className = error ? 'error' : '';
+}
+
+// showError виводить помилку API і позначає рядки з помилками.
+function showError(data, sent) {
+  let text = data.error;
+  for (const e of data.rows || []) {
+    const row = sent[e.index];
+    if (row) {
+      row.tr.classList.add('invalid');
+      text += '\n' + row.reading.name + ', ' + row.reading.serial +
+        ', зона ' + row.reading.zone + ': ' + e.error;
+    }
+  }
+  showMessage(text, true);
+}
+
+// loadSites заповнює список організацій, якщо їх більше однієї.
+async function loadSites() {
+  const { status, data } = await request('GET', '/sites');
+  if (status !== 200 || data.length < 2) {
+    return;
+  }
+  const select = $('site');
+  for (const s of data) {
+    select.add(new Option(s.name, s.name));
+  }
+  select.hidden = false;
+  select.addEventListener('change', () => {
+    if (changed() && !confirm('Не збережені дані буде втрачено')) {
+      select.value = site;
+      return;
+    }
+    site = select.value;
+    load();
+  });
+  site = select.value;
+}
+
+// load читає наступний звіт і будує форму.
+async function load() {
+  const { status, data } = await request('GET', '/next');
+  if (status !== 200) {
+    showError(data, []);
+    return;
+  }
+  render(data);
+}
+
+// render будує форму з наступного звіту next.
+function render(next) {
+  $('date').textContent = next.date;
+  $('readonly').hidden = !next.read_only;
+  for (const id of ['save', 'close', 'undo']) {
+    $(id).disabled = next.read_only;
+  }
+
+  const tbody = $('reports');
+  tbody.textContent = '';
+  rows = [];
+  for (const reading of next.reports) {
+    const tr = tbody.insertRow();
+    const row = { reading: reading, tr: tr };
+    tr.insertCell().textContent = reading.name;
+    tr.insertCell().textContent = reading.serial;
+    tr.insertCell().textContent = reading.zone;
+
+    row.kwh = document.createElement('input');
+    row.kwh.className = 'kwh';
+    row.kwh.inputMode = 'numeric';
+    row.kwh.value = reading.cur_kwh;
+    tr.insertCell().append(row.kwh);
+
+    const pre = tr.insertCell();
+    pre.className = 'num';
+    pre.textContent = reading.pre_kwh;
+
+    row.energy = tr.insertCell();
+    row.energy.className = 'num';
+    row.energy.textContent = reading.energy;
+
+    row.note = document.createElement('input');
+    row.note.maxLength = 32;
+    row.note.value = reading.annotation;
+    tr.insertCell().append(row.note);
+
+    row.kwh.disabled = row.note.disabled = next.read_only;
+    row.kwh.addEventListener('input', () => edit(row));
+    row.note.addEventListener('input', () => edit(row));
+    row.kwh.addEventListener('keydown', (event) => {
+      if (event.key === 'Enter') {
+        focusNext(row);
+      }
+    });
+    rows.push(row);
+  }
+  $('total').textContent = next.total;
+}
+
+// focusNext переходить до показника наступного рядка.
+function focusNext(row) {
+  const i = rows.indexOf(row);
+  if (i + 1 < rows.length) {
+    rows[i + 1].kwh.focus();
+    rows[i + 1].kwh.select();
+  }
+}
+
+// edit позначає змінений рядок і перераховує енергію.
+function edit(row) {
+  row.changed = true;
+  row.tr.classList.add('changed');
+  validate(row);
+  clearTimeout(timer);
+  timer = setTimeout(preview, 300);
+}
+
+// validate перевіряє показник: ціле число, яке вміщається в розрядність
+// лічильника.
+function validate(row) {
+  const text = row.kwh.value.trim();
+  const kwh = Number(text);
+  row.valid = /^\d+$/.test(text) && kwh < 10 ** row.reading.digits;
+  row.kwh.classList.toggle('invalid', !row.valid);
+  row.tr.classList.remove('invalid');
+  return row.valid;
+}
+
+// changed перевіряє чи є не збережені зміни.
+function changed() {
+  return rows.some((row) => row.changed);
+}
+
+// readings повертає показники рядків form для запиту.
+function readings(form) {
+  return {
+    readings: form.map((row) => ({
+      key: row.reading.serial,
+      zone: row.reading.zone,
+      kwh: Number(row.kwh.value.trim()),
+      annotation: row.note.value,
+    })),
+  };
+}
+
+// preview рахує енергію введених показників без збереження.
+async function preview() {
+  const form = rows.filter((row) => row.valid !== false);
+  const { status, data } = await request('POST', '/next/preview',
+    readings(form));
+  if (status !== 200) {
+    showError(data, form);
+    return;
+  }
+  showMessage(rows.length === form.length ? '' :
+    'Є неправильні показники', rows.length !== form.length);
+  data.reports.forEach((reading, i) => {
+    if (rows[i]) {
+      rows[i].energy.textContent = reading.energy;
+    }
+  });
+  $('total').textContent = data.total;
+}
+
+// save зберігає змінені показники без закриття місяця.
+async function save(form) {
+  if (rows.some((row) => row.valid === false)) {
+    showMessage('Виправте неправильні показники', true);
+    return false;
+  }
+  const { status, data } = await request('POST', '/next/readings',
+    readings(form));
+  if (status !== 200) {
+    showError(data, form);
+    return false;
+  }
+  render(data);
+  return true;
+}
+
+$('save').addEventListener('click', async () => {
+  if (await save(rows.filter((row) => row.changed))) {
+    showMessage('Збережено', false);
+  }
+});
+
+$('close').addEventListener('click', async () => {
+  if (!confirm('Закрити місяць ' + $('date').textContent + '?')) {
+    return;
+  }
+  if (!await save(rows)) {
+    return;
+  }
+  const { status, data } = await request('POST', '/next/close');
+  if (status !== 200) {
+    showError(data, []);
+    return;
+  }
+  await load();
+  showMessage('Місяць ' + data.closed + ' закрито', false);
+});
+
+$('undo').addEventListener('click', () => {
+  showMessage('', false);
+  load();
+});
+
+window.addEventListener('beforeunload', (event) => {
+  if (changed()) {
+    event.preventDefault();
+    event.returnValue = '';
+  }
+});
+
+loadSites().then(load);
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// Розміри графіків в пікселях
+const (
+	barWidth    = 640
+	barHeight   = 200
+	labelHeight = 20
+	lineWidth   = 240
+	lineHeight  = 48
+)
+
+// barChart малює стовпчикову діаграму значень values з підписами labels
+// під стовпчиками. Значення стовпчика показується в підказці.
+func barChart(values []int, labels []string) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="bars" viewBox="0 0 %d %d" `+
+		`role="img" xmlns="http://www.w3.org/2000/svg">`,
+		barWidth, barHeight+labelHeight)
+	max := maxValue(values)
+	if len(values) > 0 {
+		step := float64(barWidth) / float64(len(values))
+		for i, v := range values {
+			h := scale(v, max, barHeight)
+			x := step * float64(i)
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" `+
+				`height="%.1f"><title>%s: %d</title></rect>`,
+				x+step*0.1, barHeight-h, step*0.8, h,
+				html.EscapeString(labels[i]), v)
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d">%s</text>`,
+				x+step/2, barHeight+labelHeight-4,
+				html.EscapeString(labels[i]))
+		}
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+// lineChart малює графік значень values. Відсутні значення (missing)
+// розривають лінію, останнє значення позначається точкою.
+func lineChart(values []int, missing []bool) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="line" viewBox="0 0 %d %d" `+
+		`role="img" xmlns="http://www.w3.org/2000/svg">`,
+		lineWidth, lineHeight)
+	max := maxValue(values)
+	step := float64(lineWidth)
+	if len(values) > 1 {
+		step = float64(lineWidth-4) / float64(len(values)-1)
+	}
+	points := make([]string, 0)
+	flush := func() {
+		if len(points) > 0 {
+			fmt.Fprintf(&b, `<polyline points="%s"/>`,
+				strings.Join(points, " "))
+			points = points[:0]
+		}
+	}
+	var lastX, lastY float64
+	for i, v := range values {
+		if missing[i] {
+			flush()
+			continue
+		}
+		lastX = 2 + step*float64(i)
+		lastY = lineHeight - 2 - scale(v, max, lineHeight-4)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", lastX, lastY))
+	}
+	flush()
+	if len(values) > 0 && !missing[len(values)-1] {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2"/>`,
+			lastX, lastY)
+	}
+	b.WriteString("</svg>")
+	return template.HTML(b.String())
+}
+
+// scale переводить значення v в висоту від 0 до height.
+func scale(v, max, height int) float64 {
+	if max <= 0 || v <= 0 {
+		return 0
+	}
+	return float64(v) * float64(height) / float64(max)
+}
+
+// maxValue повертає найбільше значення або 0.
+func maxValue(values []int) int {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
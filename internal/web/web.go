@@ -0,0 +1,22 @@
+// Пакет web містить вебінтерфейс введення показників для планшета або
+// компʼютера в локальній мережі. Файли сторінки вбудовані в програму,
+// дані читаються і зберігаються через REST API пакета server.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler повертає обробник файлів вебінтерфейсу.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html", "Нові дані"},
+		{"/app.js", "javascript", "/next/preview"},
+		{"/style.css", "text/css", "table"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.path, nil)
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, req)
+		resp := rec.Result()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: status %d", test.path, resp.StatusCode)
+		}
+		ct := resp.Header.Get("Content-Type")
+		if !strings.Contains(ct, test.contentType) {
+			t.Errorf("%s: content type %q", test.path, ct)
+		}
+		if !strings.Contains(string(body), test.contains) {
+			t.Errorf("%s: %q not found", test.path, test.contains)
+		}
+	}
+}